@@ -12,6 +12,7 @@ import (
 	"github.com/shoppee/ecommerce/internal/config"
 	"github.com/shoppee/ecommerce/internal/database"
 	"github.com/shoppee/ecommerce/internal/router"
+	"github.com/shoppee/ecommerce/internal/service"
 	"github.com/shoppee/ecommerce/pkg/logger"
 	"go.uber.org/zap"
 )
@@ -65,9 +66,27 @@ func main() {
 		logger.Fatal("Redis初始化失败", zap.Error(err))
 	}
 
+	// 初始化搜索引擎
+	if err := service.InitSearchEngine(); err != nil {
+		logger.Fatal("搜索引擎初始化失败", zap.Error(err))
+	}
+
+	// 初始化媒体存储
+	if err := service.InitMediaStorage(); err != nil {
+		logger.Fatal("媒体存储初始化失败", zap.Error(err))
+	}
+
 	// 初始化路由
 	r := router.SetupRouter()
 
+	// 启动后台任务
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go service.NewStockSubscriptionService().RunRestockNotifier(jobCtx, time.Minute)
+	go service.NewRecommendService().RunRelationRefresher(jobCtx, config.AppConfig.Recommend.RefreshInterval)
+	go service.NewProductService().RunPublishScheduler(jobCtx, time.Minute)
+	go service.NewProductService().RunViewCounterFlusher(jobCtx, config.AppConfig.ViewCounter.FlushInterval)
+
 	// 创建HTTP服务器
 	srv := &http.Server{
 		Addr:           fmt.Sprintf(":%d", config.AppConfig.Port),
@@ -91,6 +110,7 @@ func main() {
 	<-quit
 
 	logger.Info("正在关闭服务器...")
+	stopJobs()
 
 	// 设置5秒超时上下文
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/shoppee/ecommerce/internal/config"
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 全量重建商品搜索索引，切换搜索引擎或调整分词规则后执行
+func main() {
+	// 初始化配置
+	if err := config.InitConfig(); err != nil {
+		panic(fmt.Sprintf("初始化配置失败: %v", err))
+	}
+
+	// 初始化日志
+	if err := logger.InitLogger(config.AppConfig.LogLevel, config.AppConfig.LogFilePath); err != nil {
+		panic(fmt.Sprintf("初始化日志失败: %v", err))
+	}
+	defer logger.Sync()
+
+	// 初始化数据库
+	if err := database.InitDB(); err != nil {
+		logger.Fatal("数据库初始化失败", zap.Error(err))
+	}
+
+	// 初始化搜索引擎
+	if err := service.InitSearchEngine(); err != nil {
+		logger.Fatal("搜索引擎初始化失败", zap.Error(err))
+	}
+
+	count, err := service.NewSearchService().ReindexProducts()
+	if err != nil {
+		logger.Fatal("重建搜索索引失败", zap.Int("indexed", count), zap.Error(err))
+	}
+
+	fmt.Printf("重建搜索索引完成，共索引 %d 个商品\n", count)
+}
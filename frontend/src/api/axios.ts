@@ -13,6 +13,13 @@ api.interceptors.request.use(
     if (token) {
       config.headers.Authorization = `Bearer ${token}`
     }
+    // 匿名访客ID：未登录时记录最近浏览，登录时合并到账户
+    let visitorId = localStorage.getItem('visitor_id')
+    if (!visitorId) {
+      visitorId = `${Date.now().toString(36)}-${Math.random().toString(36).slice(2, 12)}`
+      localStorage.setItem('visitor_id', visitorId)
+    }
+    config.headers['X-Visitor-ID'] = visitorId
     return config
   },
   (error) => {
@@ -10,6 +10,7 @@ export interface Product {
   category_id: number
   images?: string[]
   status: string
+  version: number
   created_at: string
   updated_at: string
 }
@@ -52,9 +53,9 @@ export const productAPI = {
     return api.post('/products', data)
   },
 
-  // 更新商品（管理员）
+  // 更新商品（管理员），只提交要修改的字段；带上 version 可避免覆盖他人的修改
   updateProduct: (id: number, data: Partial<Product>) => {
-    return api.put(`/products/${id}`, data)
+    return api.patch(`/products/${id}`, data)
   },
 
   // 删除商品（管理员）
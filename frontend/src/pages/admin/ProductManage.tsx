@@ -72,7 +72,11 @@ const ProductManage = () => {
   const showModal = (product?: any) => {
     setEditingProduct(product);
     if (product) {
-      form.setFieldsValue(product);
+      // 缺货由库存决定，编辑时按上架显示
+      form.setFieldsValue({
+        ...product,
+        status: product.status === 'out_of_stock' ? 'active' : product.status,
+      });
     } else {
       form.resetFields();
     }
@@ -84,7 +88,9 @@ const ProductManage = () => {
     try {
       const values = await form.validateFields();
       if (editingProduct) {
-        await updateProduct(editingProduct.id, values);
+        // 库存通过库存接口调整，不随商品信息提交
+        const { stock, ...fields } = values;
+        await updateProduct(editingProduct.id, { ...fields, version: editingProduct.version });
         message.success('更新成功');
       } else {
         await createProduct(values);
@@ -309,7 +315,12 @@ const ProductManage = () => {
             label="库存"
             rules={[{ required: true, message: '请输入库存' }]}
           >
-            <InputNumber min={0} style={{ width: '100%' }} placeholder="请输入库存" />
+            <InputNumber
+              min={0}
+              disabled={!!editingProduct}
+              style={{ width: '100%' }}
+              placeholder="请输入库存"
+            />
           </Form.Item>
 
           <Form.Item name="status" label="状态" initialValue="active">
@@ -11,6 +11,9 @@ INSERT INTO categories (name, description, icon, sort, status, created_at, updat
   ('家居生活', '家具、家纺、日用百货', '🏠', 4, 'active', NOW(), NOW()),
   ('美妆个护', '化妆品、护肤品、个人护理', '💄', 5, 'active', NOW(), NOW());
 
+-- 根分类的物化路径
+UPDATE categories SET path = '/' || id || '/', depth = 0 WHERE parent_id IS NULL;
+
 -- 创建商品（电子产品）
 INSERT INTO products (name, description, price, orig_price, stock, sku, category_id, status, view_count, sale_count, created_at, updated_at) VALUES
   ('iPhone 15 Pro 256GB', '苹果最新旗舰手机，A17仿生芯片，钛金属边框，超强性能', 7999.00, 8999.00, 50, 'IPHONE15PRO-256', 1, 'active', 1250, 87, NOW(), NOW()),
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -18,6 +19,11 @@ type Config struct {
 	Redis       RedisConfig
 	JWT         JWTConfig
 	CORS        CORSConfig
+	Inventory   InventoryConfig
+	Search      SearchConfig
+	Media       MediaConfig
+	Recommend   RecommendConfig
+	ViewCounter ViewCounterConfig
 }
 
 // DatabaseConfig 数据库配置
@@ -50,6 +56,55 @@ type CORSConfig struct {
 	AllowedOrigins []string
 }
 
+// InventoryConfig 库存配置
+type InventoryConfig struct {
+	AllocationStrategy  string        // 多仓分配策略: nearest（就近发货）, most_stock（库存最多优先）
+	LowStockAlertWindow time.Duration // 同一商品低库存预警的抑制时间窗口
+	RestockNotifyRatio  float64       // 到货通知每批人数 = 可售库存 × 该系数
+	RestockNotifyWait   time.Duration // 同一商品两批到货通知的最小间隔
+}
+
+// SearchConfig 商品搜索配置
+type SearchConfig struct {
+	SalesWeight    float64 // 销量对排序的影响系数：得分 = 相关度 × (1 + 系数 × ln(1 + 销量))
+	FuzzyThreshold float64 // 拼写容错的最低相似度（0-1），全文检索无结果时启用
+	Engine         string  // 搜索引擎: postgres（默认）, memory, meilisearch
+	EngineURL      string  // 外部搜索引擎地址
+	EngineAPIKey   string  // 外部搜索引擎密钥
+	EngineIndex    string  // 外部搜索引擎中的商品索引名
+}
+
+// MediaConfig 媒体文件配置
+type MediaConfig struct {
+	Storage        string // 存储: local（默认）, s3
+	LocalDir       string // 本地存储目录
+	BaseURL        string // 本地存储的访问前缀
+	MaxUploadSize  int64  // 单个文件大小上限（字节）
+	MaxPixels      int    // 图片像素数上限，防止解码超大图片耗尽内存
+	ThumbnailSizes string // 缩略图尺寸，name:边长 逗号分隔，如 small:200,medium:400,large:800
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PublicURL string // 对外访问前缀（如 CDN），为空时使用 S3 地址
+	S3PathStyle bool   // MinIO 等需使用路径形式的地址
+}
+
+// RecommendConfig 商品推荐配置
+type RecommendConfig struct {
+	RefreshInterval time.Duration // 「经常一起购买」离线计算的间隔
+	LookbackDays    int           // 只统计最近多少天的订单
+	MinSupport      int           // 两商品至少在多少个订单中同时出现才视为关联
+	MaxRelated      int           // 每个商品保存的关联商品数上限
+}
+
+// ViewCounterConfig 商品浏览量计数配置
+type ViewCounterConfig struct {
+	FlushInterval time.Duration // 缓冲的浏览量批量写回数据库的间隔
+}
+
 // AppConfig 全局配置实例
 var AppConfig *Config
 
@@ -102,6 +157,44 @@ func InitConfig() error {
 		CORS: CORSConfig{
 			AllowedOrigins: viper.GetStringSlice("CORS_ALLOWED_ORIGINS"),
 		},
+		Inventory: InventoryConfig{
+			AllocationStrategy:  viper.GetString("INVENTORY_ALLOCATION_STRATEGY"),
+			LowStockAlertWindow: viper.GetDuration("INVENTORY_LOW_STOCK_ALERT_WINDOW"),
+			RestockNotifyRatio:  viper.GetFloat64("INVENTORY_RESTOCK_NOTIFY_RATIO"),
+			RestockNotifyWait:   viper.GetDuration("INVENTORY_RESTOCK_NOTIFY_WAIT"),
+		},
+		Search: SearchConfig{
+			SalesWeight:    viper.GetFloat64("SEARCH_SALES_WEIGHT"),
+			FuzzyThreshold: viper.GetFloat64("SEARCH_FUZZY_THRESHOLD"),
+			Engine:         viper.GetString("SEARCH_ENGINE"),
+			EngineURL:      viper.GetString("SEARCH_ENGINE_URL"),
+			EngineAPIKey:   viper.GetString("SEARCH_ENGINE_API_KEY"),
+			EngineIndex:    viper.GetString("SEARCH_ENGINE_INDEX"),
+		},
+		Media: MediaConfig{
+			Storage:        viper.GetString("MEDIA_STORAGE"),
+			LocalDir:       viper.GetString("MEDIA_LOCAL_DIR"),
+			BaseURL:        viper.GetString("MEDIA_BASE_URL"),
+			MaxUploadSize:  int64(viper.GetSizeInBytes("MEDIA_MAX_UPLOAD_SIZE")),
+			MaxPixels:      viper.GetInt("MEDIA_MAX_PIXELS"),
+			ThumbnailSizes: viper.GetString("MEDIA_THUMBNAIL_SIZES"),
+			S3Endpoint:     viper.GetString("S3_ENDPOINT"),
+			S3Region:       viper.GetString("S3_REGION"),
+			S3Bucket:       viper.GetString("S3_BUCKET"),
+			S3AccessKey:    viper.GetString("S3_ACCESS_KEY"),
+			S3SecretKey:    viper.GetString("S3_SECRET_KEY"),
+			S3PublicURL:    viper.GetString("S3_PUBLIC_URL"),
+			S3PathStyle:    viper.GetBool("S3_PATH_STYLE"),
+		},
+		Recommend: RecommendConfig{
+			RefreshInterval: viper.GetDuration("RECOMMEND_REFRESH_INTERVAL"),
+			LookbackDays:    viper.GetInt("RECOMMEND_LOOKBACK_DAYS"),
+			MinSupport:      viper.GetInt("RECOMMEND_MIN_SUPPORT"),
+			MaxRelated:      viper.GetInt("RECOMMEND_MAX_RELATED"),
+		},
+		ViewCounter: ViewCounterConfig{
+			FlushInterval: viper.GetDuration("VIEW_COUNTER_FLUSH_INTERVAL"),
+		},
 	}
 
 	return nil
@@ -133,6 +226,31 @@ func setDefaults() {
 	viper.SetDefault("JWT_EXPIRE_HOURS", 24)
 
 	viper.SetDefault("CORS_ALLOWED_ORIGINS", []string{"*"})
+
+	viper.SetDefault("INVENTORY_ALLOCATION_STRATEGY", "nearest")
+	viper.SetDefault("INVENTORY_LOW_STOCK_ALERT_WINDOW", "1h")
+	viper.SetDefault("INVENTORY_RESTOCK_NOTIFY_RATIO", 2.0)
+	viper.SetDefault("INVENTORY_RESTOCK_NOTIFY_WAIT", "10m")
+
+	viper.SetDefault("SEARCH_SALES_WEIGHT", 0.1)
+	viper.SetDefault("SEARCH_FUZZY_THRESHOLD", 0.3)
+	viper.SetDefault("SEARCH_ENGINE", "postgres")
+	viper.SetDefault("SEARCH_ENGINE_INDEX", "products")
+
+	viper.SetDefault("MEDIA_STORAGE", "local")
+	viper.SetDefault("MEDIA_LOCAL_DIR", "./uploads")
+	viper.SetDefault("MEDIA_BASE_URL", "/uploads")
+	viper.SetDefault("MEDIA_MAX_UPLOAD_SIZE", "10MB")
+	viper.SetDefault("MEDIA_MAX_PIXELS", 40000000)
+	viper.SetDefault("MEDIA_THUMBNAIL_SIZES", "small:200,medium:400,large:800")
+	viper.SetDefault("S3_REGION", "us-east-1")
+
+	viper.SetDefault("RECOMMEND_REFRESH_INTERVAL", "6h")
+	viper.SetDefault("RECOMMEND_LOOKBACK_DAYS", 180)
+	viper.SetDefault("RECOMMEND_MIN_SUPPORT", 2)
+	viper.SetDefault("RECOMMEND_MAX_RELATED", 20)
+
+	viper.SetDefault("VIEW_COUNTER_FLUSH_INTERVAL", "10s")
 }
 
 // GetDSN 获取数据库连接字符串
@@ -1,9 +1,14 @@
 package database
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
 	"github.com/shoppee/ecommerce/internal/models"
 	"github.com/shoppee/ecommerce/pkg/logger"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // AutoMigrate 自动迁移数据库表结构
@@ -21,6 +26,33 @@ func AutoMigrate() error {
 		&models.Address{},
 		&models.Payment{},
 		&models.Review{},
+		&models.Warehouse{},
+		&models.WarehouseStock{},
+		&models.StockTransfer{},
+		&models.Shipment{},
+		&models.ShipmentItem{},
+		&models.StockMovement{},
+		&models.Notification{},
+		&models.StockSubscription{},
+		&models.ProductOption{},
+		&models.ProductOptionValue{},
+		&models.ProductVariant{},
+		&models.Attribute{},
+		&models.ProductAttributeValue{},
+		&models.SearchSynonym{},
+		&models.SearchLog{},
+		&models.Media{},
+		&models.MediaThumbnail{},
+		&models.ProductMedia{},
+		&models.ReviewMedia{},
+		&models.Tag{},
+		&models.Collection{},
+		&models.CollectionProduct{},
+		&models.ProductRelation{},
+		&models.Wishlist{},
+		&models.WishlistItem{},
+		&models.SlugRedirect{},
+		&models.ViewFlushBatch{},
 	)
 
 	if err != nil {
@@ -28,6 +60,273 @@ func AutoMigrate() error {
 		return err
 	}
 
+	if err := migrateSearchIndex(); err != nil {
+		logger.Error("全文检索索引迁移失败", zap.Error(err))
+		return err
+	}
+
+	if err := migrateLegacyImages(); err != nil {
+		logger.Error("历史图片导入失败", zap.Error(err))
+		return err
+	}
+
+	if err := migrateCategoryPaths(); err != nil {
+		logger.Error("分类路径生成失败", zap.Error(err))
+		return err
+	}
+
+	if err := migrateSlugs(); err != nil {
+		logger.Error("slug 生成失败", zap.Error(err))
+		return err
+	}
+
+	if err := migrateSubscriptionIndexes(); err != nil {
+		logger.Error("到货通知订阅索引迁移失败", zap.Error(err))
+		return err
+	}
+
+	if err := migrateWishlistIndexes(); err != nil {
+		logger.Error("收藏夹索引迁移失败", zap.Error(err))
+		return err
+	}
+
 	logger.Info("数据库迁移完成")
 	return nil
 }
+
+// migrateSearchIndex 创建商品全文检索列与索引
+// search_vector 由钩子维护的分词列生成（名称权重 A、描述权重 B）；
+// pg_trgm 用于拼写容错，扩展不可用时仅记录警告，搜索退化为不做容错
+func migrateSearchIndex() error {
+	statements := []string{
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (
+				setweight(to_tsvector('simple', coalesce(search_name, '')), 'A') ||
+				setweight(to_tsvector('simple', coalesce(search_body, '')), 'B')
+			) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector)`,
+	}
+	for _, stmt := range statements {
+		if err := DB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := DB.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`).Error; err != nil {
+		logger.Warn("pg_trgm 扩展不可用，搜索将不支持拼写容错", zap.Error(err))
+		return nil
+	}
+	if err := DB.Exec(`CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (lower(name) gin_trgm_ops)`).Error; err != nil {
+		logger.Warn("创建商品名称三元组索引失败", zap.Error(err))
+	}
+	return nil
+}
+
+// migrateSubscriptionIndexes 删除按商品维度建立的旧订阅索引（已由含规格ID的索引取代），
+// 否则同一用户无法订阅同一商品的多个规格
+func migrateSubscriptionIndexes() error {
+	for _, stmt := range []string{
+		`DROP INDEX IF EXISTS idx_subscription_user_product`,
+		`DROP INDEX IF EXISTS idx_subscription_queue`,
+	} {
+		if err := DB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateWishlistIndexes 收藏夹内商品与规格唯一（未选规格按 0 计，NULL 不参与唯一约束）；
+// 建索引前删除并发收藏产生的重复项（保留最早的一条），可重复执行
+func migrateWishlistIndexes() error {
+	statements := []string{
+		`DELETE FROM wishlist_items a USING wishlist_items b
+			WHERE a.wishlist_id = b.wishlist_id AND a.product_id = b.product_id
+			AND COALESCE(a.variant_id, 0) = COALESCE(b.variant_id, 0) AND a.id > b.id`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_wishlist_item_unique
+			ON wishlist_items (wishlist_id, product_id, COALESCE(variant_id, 0))`,
+		`DROP INDEX IF EXISTS idx_wishlist_item`,
+	}
+	for _, stmt := range statements {
+		if err := DB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateLegacyImages 将商品、评价 Images 字段中的图片地址导入媒体表（作为外部链接），
+// 导入后清空 Images 字段，可重复执行
+func migrateLegacyImages() error {
+	var products []models.Product
+	if err := DB.Select("id", "images").
+		Where("images <> '' AND NOT EXISTS (SELECT 1 FROM product_media pm WHERE pm.product_id = products.id)").
+		Find(&products).Error; err != nil {
+		return err
+	}
+	for _, p := range products {
+		urls := parseLegacyImages(p.Images)
+		if len(urls) == 0 {
+			continue
+		}
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			for i, u := range urls {
+				media := &models.Media{URL: u}
+				if err := tx.Create(media).Error; err != nil {
+					return err
+				}
+				if err := tx.Create(&models.ProductMedia{ProductID: p.ID, MediaID: media.ID, Sort: i}).Error; err != nil {
+					return err
+				}
+			}
+			// 导入后清空，避免商品图片被全部移除后再次导入
+			return tx.Model(&models.Product{}).Where("id = ?", p.ID).UpdateColumn("images", "").Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var reviews []models.Review
+	if err := DB.Select("id", "user_id", "images").
+		Where("images <> '' AND NOT EXISTS (SELECT 1 FROM review_media rm WHERE rm.review_id = reviews.id)").
+		Find(&reviews).Error; err != nil {
+		return err
+	}
+	for _, r := range reviews {
+		urls := parseLegacyImages(r.Images)
+		if len(urls) == 0 {
+			continue
+		}
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			for i, u := range urls {
+				media := &models.Media{UploaderID: r.UserID, URL: u}
+				if err := tx.Create(media).Error; err != nil {
+					return err
+				}
+				if err := tx.Create(&models.ReviewMedia{ReviewID: r.ID, MediaID: media.ID, Sort: i}).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Model(&models.Review{}).Where("id = ?", r.ID).UpdateColumn("images", "").Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(products)+len(reviews) > 0 {
+		logger.Info("历史图片导入完成", zap.Int("products", len(products)), zap.Int("reviews", len(reviews)))
+	}
+	return nil
+}
+
+// parseLegacyImages 解析历史 Images 字段（JSON 字符串数组），无法解析时返回空
+func parseLegacyImages(images string) []string {
+	var urls []string
+	if err := json.Unmarshal([]byte(images), &urls); err != nil {
+		return nil
+	}
+	result := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if u = strings.TrimSpace(u); u != "" && len(u) <= 500 {
+			result = append(result, u)
+		}
+	}
+	return result
+}
+
+// migrateCategoryPaths 为缺少物化路径的分类（升级前的数据或 SQL 直接导入的数据）按父子关系重建路径与层级
+func migrateCategoryPaths() error {
+	var missing int64
+	if err := DB.Model(&models.Category{}).Where("path IS NULL OR path = ''").Count(&missing).Error; err != nil {
+		return err
+	}
+	if missing == 0 {
+		return nil
+	}
+
+	var categories []models.Category
+	if err := DB.Select("id", "parent_id").Order("id ASC").Find(&categories).Error; err != nil {
+		return err
+	}
+	children := make(map[uint][]uint)
+	known := make(map[uint]bool, len(categories))
+	for _, c := range categories {
+		known[c.ID] = true
+	}
+	for _, c := range categories {
+		parentID := uint(0)
+		// 父分类已删除的视为根分类
+		if c.ParentID != nil && known[*c.ParentID] {
+			parentID = *c.ParentID
+		}
+		children[parentID] = append(children[parentID], c.ID)
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		type node struct {
+			id    uint
+			path  string
+			depth int
+		}
+		queue := make([]node, 0, len(categories))
+		for _, id := range children[0] {
+			queue = append(queue, node{id: id, path: fmt.Sprintf("/%d/", id)})
+		}
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			if err := tx.Model(&models.Category{}).Where("id = ?", n.id).
+				Updates(map[string]interface{}{"path": n.path, "depth": n.depth}).Error; err != nil {
+				return err
+			}
+			for _, id := range children[n.id] {
+				queue = append(queue, node{id: id, path: fmt.Sprintf("%s%d/", n.path, id), depth: n.depth + 1})
+			}
+		}
+		logger.Info("重建分类路径", zap.Int("categories", len(categories)))
+		return nil
+	})
+}
+
+// migrateSlugs 创建 slug 唯一索引（空值不参与，兼容升级前的数据），并为缺少 slug 的商品和分类生成 slug
+func migrateSlugs() error {
+	stmts := []string{
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_products_slug ON products (slug) WHERE slug <> ''`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_slug ON categories (slug) WHERE slug <> ''`,
+	}
+	for _, stmt := range stmts {
+		if err := DB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+
+	backfill := func(entityType string, model interface{}) error {
+		var rows []struct {
+			ID   uint
+			Name string
+		}
+		if err := DB.Unscoped().Model(model).Select("id", "name").
+			Where("slug IS NULL OR slug = ''").Order("id ASC").Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			s, err := models.UniqueSlug(DB, entityType, row.Name, row.ID)
+			if err != nil {
+				return err
+			}
+			if err := DB.Unscoped().Model(model).Where("id = ?", row.ID).UpdateColumn("slug", s).Error; err != nil {
+				return err
+			}
+		}
+		if len(rows) > 0 {
+			logger.Info("生成 slug", zap.String("entity_type", entityType), zap.Int("count", len(rows)))
+		}
+		return nil
+	}
+	if err := backfill(models.SlugEntityCategory, &models.Category{}); err != nil {
+		return err
+	}
+	return backfill(models.SlugEntityProduct, &models.Product{})
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/response"
+)
+
+// AttributeHandler 商品属性处理器
+type AttributeHandler struct {
+	attributeService *service.AttributeService
+}
+
+// NewAttributeHandler 创建商品属性处理器实例
+func NewAttributeHandler() *AttributeHandler {
+	return &AttributeHandler{
+		attributeService: service.NewAttributeService(),
+	}
+}
+
+// GetCategoryAttributes 获取分类下的属性定义
+func (h *AttributeHandler) GetCategoryAttributes(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的分类ID")
+		return
+	}
+
+	attributes, err := h.attributeService.GetCategoryAttributes(uint(id))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取分类属性失败")
+		return
+	}
+
+	response.Success(c, attributes)
+}
+
+// CreateAttribute 为分类创建属性（管理员）
+func (h *AttributeHandler) CreateAttribute(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的分类ID")
+		return
+	}
+
+	var req service.AttributeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	attribute, err := h.attributeService.CreateAttribute(uint(id), &req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "创建属性失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, attribute)
+}
+
+// UpdateAttribute 更新分类属性（管理员）
+func (h *AttributeHandler) UpdateAttribute(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的分类ID")
+		return
+	}
+	attributeID, err := strconv.ParseUint(c.Param("attribute_id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的属性ID")
+		return
+	}
+
+	var req service.AttributeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	attribute, err := h.attributeService.UpdateAttribute(uint(id), uint(attributeID), &req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "更新属性失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, attribute)
+}
+
+// DeleteAttribute 删除分类属性（管理员）
+func (h *AttributeHandler) DeleteAttribute(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的分类ID")
+		return
+	}
+	attributeID, err := strconv.ParseUint(c.Param("attribute_id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的属性ID")
+		return
+	}
+
+	if err := h.attributeService.DeleteAttribute(uint(id), uint(attributeID)); err != nil {
+		response.Error(c, http.StatusBadRequest, "删除属性失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// GetProductAttributes 获取商品属性值
+func (h *AttributeHandler) GetProductAttributes(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+
+	if !ensureProductVisible(c, uint(id)) {
+		return
+	}
+
+	values, err := h.attributeService.GetProductAttributes(uint(id))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取商品属性失败")
+		return
+	}
+
+	response.Success(c, values)
+}
+
+// SetProductAttributes 设置商品属性值（管理员）
+func (h *AttributeHandler) SetProductAttributes(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+
+	var req service.ProductAttributesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	values, err := h.attributeService.SetProductAttributes(uint(id), &req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "设置商品属性失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, values)
+}
@@ -6,18 +6,22 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/shoppee/ecommerce/internal/middleware"
 	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/logger"
 	"github.com/shoppee/ecommerce/pkg/response"
+	"go.uber.org/zap"
 )
 
 // AuthHandler 认证处理器
 type AuthHandler struct {
-	authService *service.AuthService
+	authService           *service.AuthService
+	recentlyViewedService *service.RecentlyViewedService
 }
 
 // NewAuthHandler 创建认证处理器实例
 func NewAuthHandler() *AuthHandler {
 	return &AuthHandler{
-		authService: service.NewAuthService(),
+		authService:           service.NewAuthService(),
+		recentlyViewedService: service.NewRecentlyViewedService(),
 	}
 }
 
@@ -56,6 +60,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body service.LoginRequest true "登录信息"
+// @Param X-Visitor-ID header string false "匿名访客ID，登录后合并其最近浏览记录"
 // @Success 200 {object} response.Response{data=service.LoginResponse}
 // @Failure 400 {object} response.Response
 // @Router /auth/login [post]
@@ -72,6 +77,13 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// 合并登录前的匿名浏览记录
+	if visitorID := c.GetHeader(visitorIDHeader); visitorID != "" {
+		if err := h.recentlyViewedService.MergeVisitor(loginResp.User.ID, visitorID); err != nil {
+			logger.Warn("合并匿名浏览记录失败", zap.Uint("user_id", loginResp.User.ID), zap.Error(err))
+		}
+	}
+
 	response.Success(c, loginResp)
 }
 
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/response"
+)
+
+func init() {
+	// 校验错误使用 JSON 字段名，便于客户端定位
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// bindStrictJSON 绑定 JSON 请求体：未声明的字段视为错误，失败时返回逐字段的错误
+func bindStrictJSON(c *gin.Context, obj interface{}) []service.FieldError {
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(obj); err != nil {
+		return []service.FieldError{decodeFieldError(err)}
+	}
+	if decoder.More() {
+		return []service.FieldError{{Message: "请求体只能包含一个JSON对象"}}
+	}
+
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		var verrs validator.ValidationErrors
+		if !errors.As(err, &verrs) {
+			return []service.FieldError{{Message: err.Error()}}
+		}
+		fields := make([]service.FieldError, 0, len(verrs))
+		for _, fe := range verrs {
+			fields = append(fields, service.FieldError{Field: fe.Field(), Message: validationMessage(fe)})
+		}
+		return fields
+	}
+	return nil
+}
+
+// decodeFieldError JSON 解码错误转换为字段错误
+func decodeFieldError(err error) service.FieldError {
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return service.FieldError{Message: "请求体不能为空"}
+	case errors.As(err, &typeErr):
+		return service.FieldError{Field: typeErr.Field, Message: "类型错误，应为 " + typeErr.Type.String()}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return service.FieldError{Field: field, Message: "不支持的字段或该字段不可修改"}
+	default:
+		return service.FieldError{Message: "请求体不是有效的JSON"}
+	}
+}
+
+// validationMessage 校验规则对应的错误说明
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "不能为空"
+	case "gt":
+		return "必须大于 " + fe.Param()
+	case "gte":
+		return "不能小于 " + fe.Param()
+	case "min":
+		return "长度不能小于 " + fe.Param()
+	case "max":
+		return "长度不能超过 " + fe.Param()
+	case "oneof":
+		return "只能为 " + strings.ReplaceAll(fe.Param(), " ", "、")
+	default:
+		return fmt.Sprintf("不满足校验规则 %s", fe.Tag())
+	}
+}
+
+// respondFieldErrors 返回 400 及逐字段错误，消息中包含第一个出错的字段
+func respondFieldErrors(c *gin.Context, fields []service.FieldError) {
+	message := "参数错误"
+	if first := fields[0]; first.Field != "" {
+		message += ": " + first.Field + " " + first.Message
+	} else {
+		message += ": " + first.Message
+	}
+	response.ErrorWithData(c, http.StatusBadRequest, message, fields)
+}
@@ -38,6 +38,7 @@ func (h *CartHandler) AddCartItem(c *gin.Context) {
 
 	var req struct {
 		ProductID uint `json:"product_id" binding:"required"`
+		VariantID uint `json:"variant_id"` // 多规格商品必填
 		Quantity  int  `json:"quantity" binding:"required,min=1"`
 	}
 
@@ -46,7 +47,7 @@ func (h *CartHandler) AddCartItem(c *gin.Context) {
 		return
 	}
 
-	if err := h.cartService.AddCartItem(userID, req.ProductID, req.Quantity); err != nil {
+	if err := h.cartService.AddCartItem(userID, req.ProductID, req.VariantID, req.Quantity); err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
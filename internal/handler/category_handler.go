@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -48,6 +49,67 @@ func (h *CategoryHandler) GetCategoryList(c *gin.Context) {
 	})
 }
 
+// GetCategory 获取分类详情（含直接子分类、含子分类的商品数及面包屑）
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的分类ID")
+		return
+	}
+
+	category, err := h.categoryService.GetCategory(uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) {
+			response.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "获取分类失败")
+		return
+	}
+
+	response.Success(c, category)
+}
+
+// GetCategoryBySlug 根据 slug 获取分类详情，slug 已变更时返回 301 跳转提示
+func (h *CategoryHandler) GetCategoryBySlug(c *gin.Context) {
+	category, current, err := h.categoryService.GetCategoryBySlug(c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) {
+			response.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "获取分类失败")
+		return
+	}
+	if current != "" {
+		respondSlugMoved(c, current)
+		return
+	}
+
+	response.Success(c, category)
+}
+
+// GetBreadcrumb 获取分类面包屑
+func (h *CategoryHandler) GetBreadcrumb(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的分类ID")
+		return
+	}
+
+	breadcrumb, err := h.categoryService.GetBreadcrumb(uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) {
+			response.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "获取面包屑失败")
+		return
+	}
+
+	response.Success(c, breadcrumb)
+}
+
 // CreateCategory 创建分类（管理员）
 func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 	var req struct {
@@ -90,11 +152,12 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 	}
 
 	var req struct {
-		Name        string `json:"name"`
-		Description string `json:"description"`
-		Icon        string `json:"icon"`
-		Sort        int    `json:"sort"`
-		Status      string `json:"status"`
+		Name        string  `json:"name"`
+		Description string  `json:"description"`
+		Icon        string  `json:"icon"`
+		Sort        int     `json:"sort"`
+		Status      string  `json:"status"`
+		Slug        *string `json:"slug" binding:"omitempty,max=80"` // 传空字符串时由名称重新生成
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -103,6 +166,9 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 	}
 
 	updates := make(map[string]interface{})
+	if req.Slug != nil {
+		updates["slug"] = *req.Slug
+	}
 	if req.Name != "" {
 		updates["name"] = req.Name
 	}
@@ -137,9 +203,56 @@ func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 	}
 
 	if err := h.categoryService.DeleteCategory(uint(id)); err != nil {
-		response.Error(c, http.StatusInternalServerError, "删除分类失败: "+err.Error())
+		if errors.Is(err, service.ErrCategoryNotFound) {
+			response.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "删除分类失败: "+err.Error())
 		return
 	}
 
 	response.Success(c, gin.H{"message": "删除成功"})
 }
+
+// MoveCategory 移动分类及其子分类（管理员）
+func (h *CategoryHandler) MoveCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的分类ID")
+		return
+	}
+
+	var req service.MoveCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	category, err := h.categoryService.MoveCategory(uint(id), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) {
+			response.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "移动分类失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, category)
+}
+
+// ReorderCategories 同级分类批量排序（管理员）
+func (h *CategoryHandler) ReorderCategories(c *gin.Context) {
+	var req service.ReorderCategoriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := h.categoryService.ReorderCategories(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "排序失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"message": "排序成功"})
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/response"
+)
+
+// CollectionHandler 商品合集处理器
+type CollectionHandler struct {
+	collectionService *service.CollectionService
+}
+
+// NewCollectionHandler 创建商品合集处理器实例
+func NewCollectionHandler() *CollectionHandler {
+	return &CollectionHandler{
+		collectionService: service.NewCollectionService(),
+	}
+}
+
+// GetCollections 获取启用的合集；合集内的商品通过 GET /products?collection=编码 查询
+func (h *CollectionHandler) GetCollections(c *gin.Context) {
+	collections, err := h.collectionService.GetCollections(false)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取合集失败")
+		return
+	}
+
+	response.Success(c, collections)
+}
+
+// GetCollection 按编码获取合集
+func (h *CollectionHandler) GetCollection(c *gin.Context) {
+	collection, err := h.collectionService.GetCollection(c.Param("code"))
+	if err != nil {
+		response.Error(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	response.Success(c, collection)
+}
+
+// AdminGetCollections 获取全部合集，含已停用（管理员）
+func (h *CollectionHandler) AdminGetCollections(c *gin.Context) {
+	collections, err := h.collectionService.GetCollections(true)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取合集失败")
+		return
+	}
+
+	response.Success(c, collections)
+}
+
+// CreateCollection 创建合集（管理员）
+func (h *CollectionHandler) CreateCollection(c *gin.Context) {
+	var req service.CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	collection, err := h.collectionService.CreateCollection(&req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "创建合集失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, collection)
+}
+
+// UpdateCollection 更新合集（管理员）
+func (h *CollectionHandler) UpdateCollection(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的合集ID")
+		return
+	}
+
+	var req service.CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	collection, err := h.collectionService.UpdateCollection(uint(id), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrCollectionNotFound) {
+			response.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "更新合集失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, collection)
+}
+
+// DeleteCollection 删除合集（管理员）
+func (h *CollectionHandler) DeleteCollection(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的合集ID")
+		return
+	}
+
+	if err := h.collectionService.DeleteCollection(uint(id)); err != nil {
+		if errors.Is(err, service.ErrCollectionNotFound) {
+			response.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "删除合集失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// SetCollectionProducts 设置人工合集的商品及顺序（管理员）
+func (h *CollectionHandler) SetCollectionProducts(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的合集ID")
+		return
+	}
+
+	var req service.CollectionProductsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	items, err := h.collectionService.SetCollectionProducts(uint(id), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrCollectionNotFound) {
+			response.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "设置合集商品失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, items)
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shoppee/ecommerce/internal/middleware"
+	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/response"
+)
+
+// InventoryHandler 库存流水处理器
+type InventoryHandler struct {
+	inventoryService *service.InventoryService
+}
+
+// NewInventoryHandler 创建库存流水处理器实例
+func NewInventoryHandler() *InventoryHandler {
+	return &InventoryHandler{
+		inventoryService: service.NewInventoryService(),
+	}
+}
+
+// GetStockMovements 获取商品库存流水（管理员）
+func (h *InventoryHandler) GetStockMovements(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	reason := c.Query("reason")
+
+	movements, total, err := h.inventoryService.GetStockMovements(uint(id), reason, page, pageSize)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取库存流水失败")
+		return
+	}
+
+	response.Page(c, movements, total, page, pageSize)
+}
+
+// AdjustStock 人工调整库存（管理员）
+func (h *InventoryHandler) AdjustStock(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+
+	var req service.StockAdjustRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	product, err := h.inventoryService.AdjustStock(middleware.GetCurrentUserID(c), uint(id), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInsufficientStock) {
+			response.Error(c, http.StatusConflict, "调整库存失败: "+err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrVariantRequired) || errors.Is(err, service.ErrVariantNotFound) ||
+			errors.Is(err, service.ErrWarehouseRequired) {
+			response.Error(c, http.StatusBadRequest, "调整库存失败: "+err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "调整库存失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, product)
+}
+
+// ReconcileStock 根据流水对账单个商品库存（管理员）
+func (h *InventoryHandler) ReconcileStock(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+
+	result, err := h.inventoryService.ReconcileStock(uint(id))
+	if err != nil {
+		response.Error(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// ReconcileAll 全量库存对账，返回不一致的商品（管理员）
+func (h *InventoryHandler) ReconcileAll(c *gin.Context) {
+	results, err := h.inventoryService.ReconcileAll()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "库存对账失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"mismatched": results,
+		"count":      len(results),
+	})
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/response"
+)
+
+// MediaHandler 媒体处理器
+type MediaHandler struct {
+	mediaService *service.MediaService
+}
+
+// NewMediaHandler 创建媒体处理器实例
+func NewMediaHandler() *MediaHandler {
+	return &MediaHandler{
+		mediaService: service.NewMediaService(),
+	}
+}
+
+// Upload 上传图片
+// @Summary 上传图片
+// @Description multipart 表单字段 file，支持 JPEG、PNG、GIF（按文件内容识别），上传后生成各尺寸缩略图
+// @Tags 媒体
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "图片文件"
+// @Success 200 {object} response.Response{data=models.Media}
+// @Failure 413 {object} response.Response
+// @Failure 415 {object} response.Response
+// @Router /media [post]
+func (h *MediaHandler) Upload(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "请选择要上传的文件")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "读取上传文件失败")
+		return
+	}
+	defer file.Close()
+
+	media, err := h.mediaService.Upload(c.GetUint("user_id"), file, fileHeader.Size)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMediaTooLarge):
+			response.Error(c, http.StatusRequestEntityTooLarge, err.Error())
+		case errors.Is(err, service.ErrUnsupportedMediaType):
+			response.Error(c, http.StatusUnsupportedMediaType, err.Error())
+		default:
+			response.Error(c, http.StatusBadRequest, "上传失败: "+err.Error())
+		}
+		return
+	}
+
+	response.Success(c, media)
+}
+
+// GetMedia 获取媒体详情
+func (h *MediaHandler) GetMedia(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的媒体ID")
+		return
+	}
+
+	media, err := h.mediaService.GetMedia(uint(id))
+	if err != nil {
+		response.Error(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	response.Success(c, media)
+}
+
+// DeleteMedia 删除未被使用的媒体（上传者或管理员）
+func (h *MediaHandler) DeleteMedia(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的媒体ID")
+		return
+	}
+
+	isAdmin := c.GetString("role") == "admin"
+	if err := h.mediaService.DeleteMedia(uint(id), c.GetUint("user_id"), isAdmin); err != nil {
+		if errors.Is(err, service.ErrMediaInUse) {
+			response.Error(c, http.StatusConflict, err.Error())
+			return
+		}
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// GetProductMedia 获取商品图片
+func (h *MediaHandler) GetProductMedia(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+
+	if !ensureProductVisible(c, uint(id)) {
+		return
+	}
+
+	items, err := h.mediaService.GetProductMedia(uint(id))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取商品图片失败")
+		return
+	}
+
+	response.Success(c, items)
+}
+
+// SetProductMedia 设置商品图片（管理员）
+// @Summary 设置商品图片
+// @Description 按数组顺序整体替换商品图片，第一张为主图
+// @Tags 媒体
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param request body service.ProductMediaRequest true "图片列表"
+// @Success 200 {object} response.Response{data=[]models.ProductMedia}
+// @Router /products/{id}/media [put]
+func (h *MediaHandler) SetProductMedia(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+
+	var req service.ProductMediaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	items, err := h.mediaService.SetProductMedia(uint(id), &req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "设置商品图片失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, items)
+}
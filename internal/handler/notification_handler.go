@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/response"
+)
+
+// NotificationHandler 站内通知处理器
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+// NewNotificationHandler 创建站内通知处理器实例
+func NewNotificationHandler() *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: service.NewNotificationService(),
+	}
+}
+
+// GetNotifications 获取我的通知列表
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	unreadOnly := c.Query("unread") == "true"
+
+	notifications, total, err := h.notificationService.GetUserNotifications(userID, unreadOnly, page, pageSize)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取通知列表失败")
+		return
+	}
+
+	response.Page(c, notifications, total, page, pageSize)
+}
+
+// MarkRead 标记通知为已读
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的通知ID")
+		return
+	}
+
+	if err := h.notificationService.MarkRead(userID, uint(id)); err != nil {
+		response.Error(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"message": "已标记为已读"})
+}
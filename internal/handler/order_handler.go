@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -25,13 +26,7 @@ func NewOrderHandler() *OrderHandler {
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	
-	var req struct {
-		AddressID     uint   `json:"address_id" binding:"required"`
-		CartItemIDs   []uint `json:"cart_item_ids" binding:"required"`
-		PaymentMethod string `json:"payment_method" binding:"required,oneof=alipay wechat card"`
-		Remark        string `json:"remark"`
-	}
-	
+	var req service.CreateOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
 		return
@@ -39,6 +34,14 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	
 	order, err := h.orderService.CreateOrder(userID.(uint), &req)
 	if err != nil {
+		if errors.Is(err, service.ErrInsufficientStock) {
+			response.Error(c, http.StatusConflict, "创建订单失败: "+err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrVariantRequired) {
+			response.Error(c, http.StatusBadRequest, "创建订单失败: "+err.Error())
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "创建订单失败: "+err.Error())
 		return
 	}
@@ -60,7 +63,7 @@ func (h *OrderHandler) GetOrderList(c *gin.Context) {
 		return
 	}
 	
-	response.SuccessWithPagination(c, orders, total, page, pageSize)
+	response.Page(c, orders, total, page, pageSize)
 }
 
 // GetOrder 获取订单详情
@@ -130,7 +133,7 @@ func (h *OrderHandler) AdminGetOrderList(c *gin.Context) {
 		return
 	}
 	
-	response.SuccessWithPagination(c, orders, total, page, pageSize)
+	response.Page(c, orders, total, page, pageSize)
 }
 
 // AdminUpdateOrderStatus 管理员更新订单状态
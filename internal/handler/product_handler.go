@@ -1,23 +1,37 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"path"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shoppee/ecommerce/internal/middleware"
+	"github.com/shoppee/ecommerce/internal/models"
 	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/logger"
 	"github.com/shoppee/ecommerce/pkg/response"
+	"go.uber.org/zap"
 )
 
 // ProductHandler 商品处理器
 type ProductHandler struct {
-	productService *service.ProductService
+	productService        *service.ProductService
+	searchService         *service.SearchService
+	recentlyViewedService *service.RecentlyViewedService
 }
 
 // NewProductHandler 创建商品处理器实例
 func NewProductHandler() *ProductHandler {
 	return &ProductHandler{
-		productService: service.NewProductService(),
+		productService:        service.NewProductService(),
+		searchService:         service.NewSearchService(),
+		recentlyViewedService: service.NewRecentlyViewedService(),
 	}
 }
 
@@ -32,7 +46,14 @@ func NewProductHandler() *ProductHandler {
 // @Param category_id query int false "分类ID"
 // @Param keyword query string false "搜索关键词"
 // @Param sort query string false "排序方式" Enums(price_asc, price_desc, sale_desc, new)
-// @Success 200 {object} response.Response{data=response.PageData}
+// @Param min_price query number false "最低价格"
+// @Param max_price query number false "最高价格"
+// @Param in_stock query bool false "仅显示有货"
+// @Param status query string false "商品状态，draft、inactive 仅管理员可用"
+// @Param attr[code] query string false "属性筛选，如 attr[brand]=Apple,Huawei 或 attr[screen_size]=6-6.8"
+// @Param tags query string false "标签编码，多个以逗号分隔，如 new-arrival,eco"
+// @Param collection query string false "合集编码，未指定 sort 时按合集顺序排列"
+// @Success 200 {object} response.Response{data=response.PageData{facets=service.ProductFacets}}
 // @Router /products [get]
 func (h *ProductHandler) GetProductList(c *gin.Context) {
 	var req service.ProductListRequest
@@ -40,24 +61,40 @@ func (h *ProductHandler) GetProductList(c *gin.Context) {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
+	req.Attributes = c.QueryMap("attr")
+	req.ShowUnpublished = c.GetString("role") == "admin"
 
 	products, total, err := h.productService.GetProductList(&req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidFilter) {
+			response.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "获取商品列表失败")
 		return
 	}
 
-	response.Page(c, products, total, req.Page, req.PageSize)
+	facets, err := h.productService.GetProductFacets(&req)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取筛选项失败")
+		return
+	}
+
+	if req.Keyword != "" && req.Page == 1 {
+		h.setSearchID(c, h.searchService.LogSearch(req.Keyword, models.SearchSourceList, total, c.GetUint("user_id")))
+	}
+
+	response.PageWithFacets(c, products, total, req.Page, req.PageSize, facets)
 }
 
 // GetProductByID 获取商品详情
 // @Summary 获取商品详情
-// @Description 根据ID获取商品详细信息
+// @Description 根据ID获取商品详细信息，草稿和已下架商品仅管理员可见（预览）
 // @Tags 商品
 // @Accept json
 // @Produce json
 // @Param id path int true "商品ID"
-// @Success 200 {object} response.Response{data=models.Product}
+// @Success 200 {object} response.Response{data=service.ProductDetail}
 // @Failure 404 {object} response.Response
 // @Router /products/{id} [get]
 func (h *ProductHandler) GetProductByID(c *gin.Context) {
@@ -69,24 +106,105 @@ func (h *ProductHandler) GetProductByID(c *gin.Context) {
 	}
 
 	product, err := h.productService.GetProductByID(uint(id))
+	if err != nil || !canView(c, &product.Product) {
+		response.Error(c, http.StatusNotFound, "商品不存在")
+		return
+	}
+
+	h.recordView(c, &product.Product)
+
+	setProductETag(c, &product.Product)
+	response.Success(c, product)
+}
+
+// GetProductBySlug 根据 slug 获取商品详情
+// @Summary 根据 slug 获取商品详情
+// @Description slug 已变更时返回 301，Location 为当前 slug 的地址，响应数据中的 slug 为当前 slug
+// @Tags 商品
+// @Produce json
+// @Param slug path string true "商品 slug"
+// @Success 200 {object} response.Response{data=service.ProductDetail}
+// @Failure 301 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /products/by-slug/{slug} [get]
+func (h *ProductHandler) GetProductBySlug(c *gin.Context) {
+	product, current, err := h.productService.GetProductBySlug(c.Param("slug"))
 	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			response.Error(c, http.StatusNotFound, "商品不存在")
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "获取商品失败")
+		return
+	}
+	if current != "" {
+		respondSlugMoved(c, current)
+		return
+	}
+	if !canView(c, &product.Product) {
 		response.Error(c, http.StatusNotFound, "商品不存在")
 		return
 	}
 
+	h.recordView(c, &product.Product)
+
+	setProductETag(c, &product.Product)
 	response.Success(c, product)
 }
 
+// canView 草稿和已下架的商品只对管理员可见（预览）
+func canView(c *gin.Context, product *models.Product) bool {
+	return service.IsPublished(product.Status) || c.GetString("role") == "admin"
+}
+
+// recordView 记录浏览量和最近浏览（登录用户按用户，匿名访客按 X-Visitor-ID）；
+// 只统计已上架商品，管理员预览草稿和已下架商品不计入
+func (h *ProductHandler) recordView(c *gin.Context, product *models.Product) {
+	if !service.IsPublished(product.Status) {
+		return
+	}
+	h.productService.RecordView(product.ID)
+	userID, visitorID := c.GetUint("user_id"), c.GetHeader(visitorIDHeader)
+	go h.recentlyViewedService.Record(userID, visitorID, product.ID)
+}
+
+// ensureProductVisible 商品的规格、属性、图片等公开子资源与详情一致：商品不存在或对当前用户不可见时响应 404
+func ensureProductVisible(c *gin.Context, productID uint) bool {
+	err := service.NewProductService().CheckVisible(productID, c.GetString("role") == "admin")
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, service.ErrProductNotFound) {
+		response.Error(c, http.StatusNotFound, "商品不存在")
+	} else {
+		response.Error(c, http.StatusInternalServerError, "获取商品失败")
+	}
+	return false
+}
+
+// respondSlugMoved 旧 slug 的跳转提示：301 并在 Location 及响应数据中给出当前 slug
+func respondSlugMoved(c *gin.Context, current string) {
+	location := path.Join(path.Dir(c.Request.URL.Path), current)
+	if c.Request.URL.RawQuery != "" {
+		location += "?" + c.Request.URL.RawQuery
+	}
+	c.Header("Location", location)
+	response.ErrorWithData(c, http.StatusMovedPermanently, "链接已变更", gin.H{
+		"slug":     current,
+		"location": location,
+	})
+}
+
 // SearchProducts 搜索商品
 // @Summary 搜索商品
-// @Description 根据关键词搜索商品
+// @Description 全文搜索商品，按相关度结合销量排序，支持同义词与拼写容错，返回命中片段；无结果时返回纠错建议 did_you_mean
 // @Tags 商品
 // @Accept json
 // @Produce json
 // @Param keyword query string true "搜索关键词"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
-// @Success 200 {object} response.Response{data=response.PageData}
+// @Success 200 {object} response.Response{data=response.PageData{list=[]service.ProductSearchResult}}
 // @Router /products/search [get]
 func (h *ProductHandler) SearchProducts(c *gin.Context) {
 	keyword := c.Query("keyword")
@@ -99,31 +217,459 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 		return
 	}
 
+	if page <= 1 {
+		h.setSearchID(c, h.searchService.LogSearch(keyword, models.SearchSourceSearch, total, c.GetUint("user_id")))
+	}
+
+	if total == 0 {
+		response.PageWithCorrections(c, products, total, page, pageSize, h.searchService.DidYouMean(keyword))
+		return
+	}
+
 	response.Page(c, products, total, page, pageSize)
 }
 
+// setSearchID 通过响应头返回搜索日志ID，客户端点击搜索结果时据此上报
+func (h *ProductHandler) setSearchID(c *gin.Context, searchID uint) {
+	if searchID > 0 {
+		c.Header("X-Search-ID", strconv.FormatUint(uint64(searchID), 10))
+	}
+}
+
+// CompareProducts 商品对比
+// @Summary 商品对比
+// @Description 一次返回 2~4 个商品的价格区间、评分、库存状态、规格，以及按属性编码对齐的属性对比行
+// @Tags 商品
+// @Produce json
+// @Param ids query string true "商品ID，逗号分隔，如 1,2,3"
+// @Success 200 {object} response.Response{data=service.ProductComparison}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /products/compare [get]
+func (h *ProductHandler) CompareProducts(c *gin.Context) {
+	var ids []uint
+	for _, raw := range strings.Split(c.Query("ids"), ",") {
+		if raw = strings.TrimSpace(raw); raw == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || id == 0 {
+			response.Error(c, http.StatusBadRequest, "无效的商品ID: "+raw)
+			return
+		}
+		ids = append(ids, uint(id))
+	}
+
+	comparison, err := h.productService.CompareProducts(ids)
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			response.Error(c, http.StatusNotFound, "部分商品不存在或已下架")
+			return
+		}
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.Success(c, comparison)
+}
+
 // BatchUpdateStock 批量更新库存
 // @Summary 批量更新库存
-// @Description 批量更新商品库存（需要管理员权限）
+// @Description 批量更新商品库存（需要管理员权限）。atomic 模式整批成功或整批回滚，best_effort 模式逐项执行；均返回逐项结果
 // @Tags 商品
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param updates body map[uint]int true "库存更新映射"
-// @Success 200 {object} response.Response
+// @Param request body service.BatchStockRequest true "库存更新列表"
+// @Success 200 {object} response.Response{data=[]service.StockUpdateResult}
 // @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response{data=[]service.StockUpdateResult}
 // @Router /products/batch-stock [post]
 func (h *ProductHandler) BatchUpdateStock(c *gin.Context) {
-	var updates map[uint]int
-	if err := c.ShouldBindJSON(&updates); err != nil {
+	var req service.BatchStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if err := h.productService.BatchUpdateStock(updates); err != nil {
+	seen := make(map[[3]uint]bool, len(req.Updates))
+	for _, item := range req.Updates {
+		key := [3]uint{item.ProductID, item.VariantID, item.WarehouseID}
+		if seen[key] {
+			response.Error(c, http.StatusBadRequest, fmt.Sprintf("商品 %d 规格 %d 仓库 %d 重复出现", item.ProductID, item.VariantID, item.WarehouseID))
+			return
+		}
+		seen[key] = true
+	}
+
+	results, err := h.productService.BatchUpdateStock(middleware.GetCurrentUserID(c), req.Mode, req.Updates)
+	switch {
+	case err == nil:
+		response.SuccessWithMessage(c, "批量更新库存成功", results)
+	case errors.Is(err, service.ErrPartialStockUpdate):
+		response.SuccessWithMessage(c, err.Error(), results)
+	case errors.Is(err, service.ErrBatchStockRolledBack):
+		response.ErrorWithData(c, http.StatusConflict, err.Error(), results)
+	default:
 		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// ImportProducts 批量导入商品
+// @Summary 批量导入商品
+// @Description 上传 CSV 或 JSON 文件按 SKU 新建或更新商品，分类可填名称或完整路径（如 数码/手机），dry_run=true 时只校验不写入，返回逐行报告
+// @Tags 商品
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "CSV 或 JSON 文件"
+// @Param format query string false "文件格式，默认按扩展名判断" Enums(csv, json)
+// @Param dry_run query bool false "仅校验不写入"
+// @Success 200 {object} response.Response{data=service.ImportReport}
+// @Failure 400 {object} response.Response
+// @Router /products/import [post]
+func (h *ProductHandler) ImportProducts(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "请选择要导入的文件")
+		return
+	}
+
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "读取上传文件失败")
+		return
+	}
+	defer file.Close()
+
+	report, err := h.productService.ImportProducts(middleware.GetCurrentUserID(c), format, file, dryRun)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	message := "导入完成"
+	if dryRun {
+		message = "校验完成"
+	}
+	response.SuccessWithMessage(c, message, report)
+}
+
+// ExportProducts 导出商品
+// @Summary 导出商品
+// @Description 按导入格式导出商品，导出文件可直接重新导入
+// @Tags 商品
+// @Produce text/csv,application/json
+// @Security BearerAuth
+// @Param format query string false "文件格式" Enums(csv, json) default(csv)
+// @Param category_id query int false "分类ID"
+// @Param status query string false "商品状态"
+// @Success 200 {file} file
+// @Router /products/export [get]
+func (h *ProductHandler) ExportProducts(c *gin.Context) {
+	var req service.ProductExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Format == "" {
+		req.Format = service.ProductFileCSV
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if req.Format == service.ProductFileJSON {
+		contentType = "application/json; charset=utf-8"
+	}
+	filename := fmt.Sprintf("products-%s.%s", time.Now().Format("20060102150405"), req.Format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	// 响应头已写出，流式导出中途失败只能记录日志
+	if err := h.productService.ExportProducts(&req, c.Writer); err != nil {
+		logger.Error("导出商品失败", zap.Error(err))
+	}
+}
+
+// CreateProduct 创建商品
+// @Summary 创建商品
+// @Description 创建商品（需要管理员权限），请求体只接受声明的字段，校验失败时返回出错字段
+// @Tags 商品
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.CreateProductRequest true "商品信息"
+// @Success 200 {object} response.Response{data=models.Product}
+// @Header 200 {string} ETag "商品版本"
+// @Failure 400 {object} response.Response{data=[]service.FieldError}
+// @Router /products [post]
+func (h *ProductHandler) CreateProduct(c *gin.Context) {
+	var req service.CreateProductRequest
+	if fields := bindStrictJSON(c, &req); fields != nil {
+		respondFieldErrors(c, fields)
+		return
+	}
+
+	product, err := h.productService.CreateProduct(&req)
+	if err != nil {
+		h.respondEditError(c, err)
+		return
+	}
+
+	setProductETag(c, product)
+	response.SuccessWithMessage(c, "创建商品成功", product)
+}
+
+// UpdateProduct 整体更新商品
+// @Summary 整体更新商品
+// @Description 替换商品的全部可编辑字段（需要管理员权限）。库存、销量、浏览量不可通过此接口修改；通过 If-Match 请求头或 version 字段传入版本号可防止覆盖他人的修改
+// @Tags 商品
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param If-Match header string false "GET 商品详情时返回的 ETag"
+// @Param request body service.UpdateProductRequest true "商品信息"
+// @Success 200 {object} response.Response{data=models.Product}
+// @Header 200 {string} ETag "新的商品版本"
+// @Failure 400 {object} response.Response{data=[]service.FieldError}
+// @Failure 404 {object} response.Response
+// @Failure 412 {object} response.Response
+// @Router /products/{id} [put]
+func (h *ProductHandler) UpdateProduct(c *gin.Context) {
+	id, version, ok := h.editTarget(c)
+	if !ok {
+		return
+	}
+
+	var req service.UpdateProductRequest
+	if fields := bindStrictJSON(c, &req); fields != nil {
+		respondFieldErrors(c, fields)
+		return
+	}
+	if version == 0 {
+		version = req.Version
+	}
+
+	product, err := h.productService.UpdateProduct(id, version, &req)
+	if err != nil {
+		h.respondEditError(c, err)
+		return
+	}
+
+	setProductETag(c, product)
+	response.SuccessWithMessage(c, "更新商品成功", product)
+}
+
+// PatchProduct 部分更新商品
+// @Summary 部分更新商品
+// @Description 只修改请求体中出现的可编辑字段（需要管理员权限），版本控制同整体更新
+// @Tags 商品
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param If-Match header string false "GET 商品详情时返回的 ETag"
+// @Param request body service.PatchProductRequest true "要修改的字段"
+// @Success 200 {object} response.Response{data=models.Product}
+// @Header 200 {string} ETag "新的商品版本"
+// @Failure 400 {object} response.Response{data=[]service.FieldError}
+// @Failure 404 {object} response.Response
+// @Failure 412 {object} response.Response
+// @Router /products/{id} [patch]
+func (h *ProductHandler) PatchProduct(c *gin.Context) {
+	id, version, ok := h.editTarget(c)
+	if !ok {
+		return
+	}
+
+	var req service.PatchProductRequest
+	if fields := bindStrictJSON(c, &req); fields != nil {
+		respondFieldErrors(c, fields)
+		return
+	}
+	if version == 0 {
+		version = req.Version
+	}
+
+	product, err := h.productService.PatchProduct(id, version, &req)
+	if err != nil {
+		h.respondEditError(c, err)
+		return
+	}
+
+	setProductETag(c, product)
+	response.SuccessWithMessage(c, "更新商品成功", product)
+}
+
+// UpdateProductStatus 商品上下架
+// @Summary 商品上下架
+// @Description 修改商品上下架状态（需要管理员权限），上架但无库存的商品记为缺货
+// @Tags 商品
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param If-Match header string false "GET 商品详情时返回的 ETag"
+// @Param request body service.UpdateProductStatusRequest true "状态"
+// @Success 200 {object} response.Response{data=models.Product}
+// @Failure 400 {object} response.Response{data=[]service.FieldError}
+// @Failure 412 {object} response.Response
+// @Router /products/{id}/status [patch]
+func (h *ProductHandler) UpdateProductStatus(c *gin.Context) {
+	id, version, ok := h.editTarget(c)
+	if !ok {
+		return
+	}
+
+	var req service.UpdateProductStatusRequest
+	if fields := bindStrictJSON(c, &req); fields != nil {
+		respondFieldErrors(c, fields)
+		return
+	}
+	if version == 0 {
+		version = req.Version
+	}
+
+	product, err := h.productService.UpdateProductStatus(id, version, req.Status)
+	if err != nil {
+		h.respondEditError(c, err)
+		return
+	}
+
+	setProductETag(c, product)
+	response.SuccessWithMessage(c, "更新商品状态成功", product)
+}
+
+// ScheduleProduct 设置定时上下架
+// @Summary 设置定时上下架
+// @Description 设置草稿或已下架商品的上架时间、商品的下架时间（需要管理员权限），到期由调度器执行，时间为空表示取消
+// @Tags 商品
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param If-Match header string false "GET 商品详情时返回的 ETag"
+// @Param request body service.ScheduleProductRequest true "定时上下架时间"
+// @Success 200 {object} response.Response{data=models.Product}
+// @Failure 400 {object} response.Response{data=[]service.FieldError}
+// @Failure 412 {object} response.Response
+// @Router /products/{id}/schedule [put]
+func (h *ProductHandler) ScheduleProduct(c *gin.Context) {
+	id, version, ok := h.editTarget(c)
+	if !ok {
+		return
+	}
+
+	var req service.ScheduleProductRequest
+	if fields := bindStrictJSON(c, &req); fields != nil {
+		respondFieldErrors(c, fields)
+		return
+	}
+	if version == 0 {
+		version = req.Version
+	}
+
+	product, err := h.productService.ScheduleProduct(id, version, &req)
+	if err != nil {
+		h.respondEditError(c, err)
+		return
+	}
+
+	setProductETag(c, product)
+	response.SuccessWithMessage(c, "设置定时上下架成功", product)
+}
+
+// DeleteProduct 删除商品
+// @Summary 删除商品
+// @Description 软删除商品（需要管理员权限）
+// @Tags 商品
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /products/{id} [delete]
+func (h *ProductHandler) DeleteProduct(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
 		return
 	}
 
-	response.SuccessWithMessage(c, "批量更新库存成功", nil)
+	if err := h.productService.DeleteProduct(uint(id)); err != nil {
+		response.Error(c, http.StatusNotFound, "商品不存在")
+		return
+	}
+
+	response.SuccessWithMessage(c, "删除商品成功", nil)
+}
+
+// editTarget 解析待编辑的商品ID及 If-Match 中的版本号（未传时版本为 0）
+func (h *ProductHandler) editTarget(c *gin.Context) (uint, int, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return 0, 0, false
+	}
+
+	version, err := parseProductETag(c.GetHeader("If-Match"), uint(id))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return 0, 0, false
+	}
+	return uint(id), version, true
+}
+
+// respondEditError 商品编辑错误响应
+func (h *ProductHandler) respondEditError(c *gin.Context, err error) {
+	var fieldErr *service.FieldError
+	switch {
+	case errors.As(err, &fieldErr):
+		respondFieldErrors(c, []service.FieldError{*fieldErr})
+	case errors.Is(err, service.ErrProductNotFound):
+		response.Error(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrProductVersionConflict):
+		response.Error(c, http.StatusPreconditionFailed, err.Error())
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// setProductETag 以商品ID和版本号生成 ETag
+func setProductETag(c *gin.Context, product *models.Product) {
+	c.Header("ETag", fmt.Sprintf(`"%d-%d"`, product.ID, product.Version))
+}
+
+// parseProductETag 从 If-Match 请求头解析版本号；未传或为 * 时返回 0（不校验版本）
+func parseProductETag(header string, productID uint) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	invalid := errors.New("无效的 If-Match 请求头")
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	idPart, versionPart, found := strings.Cut(tag, "-")
+	if !found {
+		return 0, invalid
+	}
+	id, err := strconv.ParseUint(idPart, 10, 32)
+	if err != nil {
+		return 0, invalid
+	}
+	version, err := strconv.Atoi(versionPart)
+	if err != nil || version <= 0 {
+		return 0, invalid
+	}
+	if uint(id) != productID {
+		return 0, errors.New("If-Match 与商品ID不匹配")
+	}
+	return version, nil
 }
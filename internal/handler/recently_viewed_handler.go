@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shoppee/ecommerce/internal/middleware"
+	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/response"
+)
+
+// visitorIDHeader 匿名访客ID请求头，客户端首次访问时生成并持久保存，登录时用于合并浏览记录
+const visitorIDHeader = "X-Visitor-ID"
+
+// RecentlyViewedHandler 最近浏览处理器
+type RecentlyViewedHandler struct {
+	recentlyViewedService *service.RecentlyViewedService
+}
+
+// NewRecentlyViewedHandler 创建最近浏览处理器实例
+func NewRecentlyViewedHandler() *RecentlyViewedHandler {
+	return &RecentlyViewedHandler{
+		recentlyViewedService: service.NewRecentlyViewedService(),
+	}
+}
+
+// GetRecentlyViewed 获取当前用户最近浏览的商品
+// @Summary 最近浏览
+// @Description 按浏览时间倒序返回最近浏览的商品（去重，最多 50 个）
+// @Tags 个人中心
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "数量" default(20)
+// @Success 200 {object} response.Response{data=[]service.RecentlyViewedItem}
+// @Router /me/recently-viewed [get]
+func (h *RecentlyViewedHandler) GetRecentlyViewed(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	items, err := h.recentlyViewedService.List(middleware.GetCurrentUserID(c), limit)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取最近浏览失败")
+		return
+	}
+
+	response.Success(c, items)
+}
+
+// ClearRecentlyViewed 清空当前用户的最近浏览
+// @Summary 清空最近浏览
+// @Tags 个人中心
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Router /me/recently-viewed [delete]
+func (h *RecentlyViewedHandler) ClearRecentlyViewed(c *gin.Context) {
+	if err := h.recentlyViewedService.Clear(middleware.GetCurrentUserID(c)); err != nil {
+		response.Error(c, http.StatusInternalServerError, "清空最近浏览失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "已清空", nil)
+}
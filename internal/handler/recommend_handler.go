@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/response"
+)
+
+// RecommendHandler 商品推荐处理器
+type RecommendHandler struct {
+	recommendService *service.RecommendService
+}
+
+// NewRecommendHandler 创建商品推荐处理器实例
+func NewRecommendHandler() *RecommendHandler {
+	return &RecommendHandler{
+		recommendService: service.NewRecommendService(),
+	}
+}
+
+// GetRelatedProducts 获取经常一起购买的商品
+// @Summary 经常一起购买
+// @Description 返回与该商品经常出现在同一订单中的商品（离线定期计算），数据不足时以同分类热销商品补足
+// @Tags 商品
+// @Produce json
+// @Param id path int true "商品ID"
+// @Param limit query int false "数量" default(10)
+// @Success 200 {object} response.Response{data=[]service.RelatedProduct}
+// @Failure 404 {object} response.Response
+// @Router /products/{id}/related [get]
+func (h *RecommendHandler) GetRelatedProducts(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	products, err := h.recommendService.GetRelatedProducts(uint(id), limit, c.GetString("role") == "admin")
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			response.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "获取关联商品失败")
+		return
+	}
+
+	response.Success(c, products)
+}
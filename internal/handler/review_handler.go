@@ -25,13 +25,7 @@ func NewReviewHandler() *ReviewHandler {
 func (h *ReviewHandler) CreateReview(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	
-	var req struct {
-		ProductID uint   `json:"product_id" binding:"required"`
-		OrderID   uint   `json:"order_id"`
-		Rating    int    `json:"rating" binding:"required,gte=1,lte=5"`
-		Content   string `json:"content"`
-		Images    string `json:"images"`
-	}
+	var req service.CreateReviewRequest
 	
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
@@ -65,7 +59,7 @@ func (h *ReviewHandler) GetProductReviews(c *gin.Context) {
 		return
 	}
 	
-	response.SuccessWithPagination(c, reviews, total, page, pageSize)
+	response.Page(c, reviews, total, page, pageSize)
 }
 
 // GetMyReviews 获取我的评价
@@ -81,7 +75,7 @@ func (h *ReviewHandler) GetMyReviews(c *gin.Context) {
 		return
 	}
 	
-	response.SuccessWithPagination(c, reviews, total, page, pageSize)
+	response.Page(c, reviews, total, page, pageSize)
 }
 
 // DeleteReview 删除评价
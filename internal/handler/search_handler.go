@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/response"
+)
+
+// SearchHandler 搜索管理处理器
+type SearchHandler struct {
+	searchService *service.SearchService
+}
+
+// NewSearchHandler 创建搜索管理处理器实例
+func NewSearchHandler() *SearchHandler {
+	return &SearchHandler{
+		searchService: service.NewSearchService(),
+	}
+}
+
+// Suggest 搜索联想
+// @Summary 搜索联想
+// @Description 按前缀联想商品名称、分类及热门搜索词，按近期搜索频次排序；无联想结果时返回纠错建议
+// @Tags 商品
+// @Produce json
+// @Param q query string true "输入的前缀"
+// @Param limit query int false "返回数量" default(10)
+// @Success 200 {object} response.Response{data=service.SuggestResult}
+// @Router /products/suggest [get]
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	result, err := h.searchService.Suggest(c.Query("q"), limit)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取搜索联想失败")
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// RecordClick 上报搜索结果点击
+// @Summary 上报搜索结果点击
+// @Description 搜索或关键词筛选的响应头 X-Search-ID 为本次搜索ID，点击结果中的商品时上报；登录用户的点击会用于下单归因
+// @Tags 搜索
+// @Accept json
+// @Produce json
+// @Param id path int true "搜索ID"
+// @Param request body service.SearchClickRequest true "点击的商品"
+// @Success 200 {object} response.Response
+// @Router /search/logs/{id}/click [post]
+func (h *SearchHandler) RecordClick(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的搜索ID")
+		return
+	}
+
+	var req service.SearchClickRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := h.searchService.RecordSearchClick(uint(id), req.ProductID, c.GetUint("user_id")); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "已记录", nil)
+}
+
+// GetQueryReport 搜索词报表（管理员）
+// @Summary 搜索词报表
+// @Description 统计最近 days 天的搜索词：默认按搜索次数排序，sort=click_rate/conversion 时按点击率/转化率排序
+// @Tags 搜索
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "统计天数" default(7)
+// @Param limit query int false "返回数量" default(50)
+// @Param sort query string false "排序: searches, click_rate, conversion"
+// @Param min_searches query int false "按比率排序时的最少搜索次数" default(5)
+// @Success 200 {object} response.Response{data=service.SearchReport}
+// @Router /search/reports/queries [get]
+func (h *SearchHandler) GetQueryReport(c *gin.Context) {
+	var req service.SearchReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	report, err := h.searchService.GetQueryReport(&req)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取搜索报表失败")
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// GetZeroResultReport 无结果搜索词报表（管理员）
+// @Summary 无结果搜索词报表
+// @Description 统计最近 days 天内出现过无结果的搜索词，按无结果次数排序
+// @Tags 搜索
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "统计天数" default(7)
+// @Param limit query int false "返回数量" default(50)
+// @Success 200 {object} response.Response{data=service.SearchReport}
+// @Router /search/reports/zero-results [get]
+func (h *SearchHandler) GetZeroResultReport(c *gin.Context) {
+	var req service.SearchReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	report, err := h.searchService.GetZeroResultReport(&req)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取搜索报表失败")
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// GetSynonyms 获取同义词组列表（管理员）
+func (h *SearchHandler) GetSynonyms(c *gin.Context) {
+	synonyms, err := h.searchService.GetSynonyms()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取同义词失败")
+		return
+	}
+
+	response.Success(c, synonyms)
+}
+
+// CreateSynonym 创建同义词组（管理员）
+func (h *SearchHandler) CreateSynonym(c *gin.Context) {
+	var req service.SynonymRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	synonym, err := h.searchService.CreateSynonym(&req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "创建同义词失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, synonym)
+}
+
+// DeleteSynonym 删除同义词组（管理员）
+func (h *SearchHandler) DeleteSynonym(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的同义词ID")
+		return
+	}
+
+	if err := h.searchService.DeleteSynonym(uint(id)); err != nil {
+		response.Error(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// ReindexProducts 全量重建搜索索引（管理员）
+func (h *SearchHandler) ReindexProducts(c *gin.Context) {
+	count, err := h.searchService.ReindexProducts()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "重建索引失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"count": count})
+}
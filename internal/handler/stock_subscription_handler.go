@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/response"
+)
+
+// StockSubscriptionHandler 到货通知订阅处理器
+type StockSubscriptionHandler struct {
+	subscriptionService *service.StockSubscriptionService
+}
+
+// NewStockSubscriptionHandler 创建到货通知订阅处理器实例
+func NewStockSubscriptionHandler() *StockSubscriptionHandler {
+	return &StockSubscriptionHandler{
+		subscriptionService: service.NewStockSubscriptionService(),
+	}
+}
+
+// Subscribe 订阅缺货商品的到货通知，可通过 variant_id 订阅售罄的规格
+func (h *StockSubscriptionHandler) Subscribe(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+	variantID, err := strconv.ParseUint(c.DefaultQuery("variant_id", "0"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的规格ID")
+		return
+	}
+
+	sub, err := h.subscriptionService.Subscribe(userID, uint(id), uint(variantID))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "订阅成功，到货后将第一时间通知您", sub)
+}
+
+// Unsubscribe 取消到货通知订阅
+func (h *StockSubscriptionHandler) Unsubscribe(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+	variantID, err := strconv.ParseUint(c.DefaultQuery("variant_id", "0"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的规格ID")
+		return
+	}
+
+	if err := h.subscriptionService.Unsubscribe(userID, uint(id), uint(variantID)); err != nil {
+		response.Error(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"message": "已取消订阅"})
+}
+
+// GetMySubscriptions 获取我的到货通知订阅
+func (h *StockSubscriptionHandler) GetMySubscriptions(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	subs, err := h.subscriptionService.GetUserSubscriptions(userID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取订阅列表失败")
+		return
+	}
+
+	response.Success(c, subs)
+}
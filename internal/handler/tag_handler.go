@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/response"
+)
+
+// TagHandler 商品标签处理器
+type TagHandler struct {
+	tagService *service.TagService
+}
+
+// NewTagHandler 创建商品标签处理器实例
+func NewTagHandler() *TagHandler {
+	return &TagHandler{
+		tagService: service.NewTagService(),
+	}
+}
+
+// GetTags 获取全部标签
+func (h *TagHandler) GetTags(c *gin.Context) {
+	tags, err := h.tagService.GetTags()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取标签失败")
+		return
+	}
+
+	response.Success(c, tags)
+}
+
+// CreateTag 创建标签（管理员）
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	var req service.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	tag, err := h.tagService.CreateTag(&req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "创建标签失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, tag)
+}
+
+// UpdateTag 更新标签（管理员）
+func (h *TagHandler) UpdateTag(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的标签ID")
+		return
+	}
+
+	var req service.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	tag, err := h.tagService.UpdateTag(uint(id), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrTagNotFound) {
+			response.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "更新标签失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, tag)
+}
+
+// DeleteTag 删除标签（管理员）
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的标签ID")
+		return
+	}
+
+	if err := h.tagService.DeleteTag(uint(id)); err != nil {
+		if errors.Is(err, service.ErrTagNotFound) {
+			response.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "删除标签失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// SetProductTags 替换商品的标签（管理员）
+func (h *TagHandler) SetProductTags(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+
+	var req service.ProductTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	tags, err := h.tagService.SetProductTags(uint(id), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			response.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "设置商品标签失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, tags)
+}
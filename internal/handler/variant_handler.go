@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shoppee/ecommerce/internal/middleware"
+	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/response"
+)
+
+// VariantHandler 商品规格处理器
+type VariantHandler struct {
+	variantService *service.VariantService
+}
+
+// NewVariantHandler 创建商品规格处理器实例
+func NewVariantHandler() *VariantHandler {
+	return &VariantHandler{
+		variantService: service.NewVariantService(),
+	}
+}
+
+// GetOptions 获取商品的规格类型
+func (h *VariantHandler) GetOptions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+
+	if !ensureProductVisible(c, uint(id)) {
+		return
+	}
+
+	options, err := h.variantService.GetOptions(uint(id))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取规格类型失败")
+		return
+	}
+
+	response.Success(c, options)
+}
+
+// CreateOption 新增规格类型或追加规格值（管理员）
+func (h *VariantHandler) CreateOption(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+
+	var req service.ProductOptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	option, err := h.variantService.CreateOption(uint(id), &req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "保存规格类型失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, option)
+}
+
+// GetVariants 获取商品的规格组合（已停用的规格仅管理员可见）
+func (h *VariantHandler) GetVariants(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+
+	if !ensureProductVisible(c, uint(id)) {
+		return
+	}
+
+	variants, err := h.variantService.GetVariants(uint(id), c.GetString("role") == "admin")
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取规格组合失败")
+		return
+	}
+
+	response.Success(c, variants)
+}
+
+// CreateVariant 创建规格组合（管理员）
+func (h *VariantHandler) CreateVariant(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+
+	var req service.CreateVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	variant, err := h.variantService.CreateVariant(middleware.GetCurrentUserID(c), uint(id), &req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "创建规格组合失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, variant)
+}
+
+// UpdateVariant 更新规格组合的价格、图片或状态（管理员）
+func (h *VariantHandler) UpdateVariant(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+	variantID, err := strconv.ParseUint(c.Param("variant_id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的规格ID")
+		return
+	}
+
+	var req service.UpdateVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	variant, err := h.variantService.UpdateVariant(uint(id), uint(variantID), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrVariantNotFound) {
+			response.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "更新规格组合失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, variant)
+}
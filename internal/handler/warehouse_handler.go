@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shoppee/ecommerce/internal/middleware"
+	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/response"
+)
+
+// WarehouseHandler 仓库处理器
+type WarehouseHandler struct {
+	warehouseService *service.WarehouseService
+}
+
+// NewWarehouseHandler 创建仓库处理器实例
+func NewWarehouseHandler() *WarehouseHandler {
+	return &WarehouseHandler{
+		warehouseService: service.NewWarehouseService(),
+	}
+}
+
+// GetWarehouseList 获取仓库列表（管理员）
+func (h *WarehouseHandler) GetWarehouseList(c *gin.Context) {
+	warehouses, err := h.warehouseService.GetWarehouses()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取仓库列表失败")
+		return
+	}
+
+	response.Success(c, warehouses)
+}
+
+// CreateWarehouse 创建仓库（管理员）
+func (h *WarehouseHandler) CreateWarehouse(c *gin.Context) {
+	var req service.WarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	warehouse, err := h.warehouseService.CreateWarehouse(&req)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "创建仓库失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, warehouse)
+}
+
+// UpdateWarehouse 更新仓库（管理员）
+func (h *WarehouseHandler) UpdateWarehouse(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的仓库ID")
+		return
+	}
+
+	var req service.WarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	warehouse, err := h.warehouseService.UpdateWarehouse(uint(id), &req)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "更新仓库失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, warehouse)
+}
+
+// GetWarehouseStocks 获取仓库库存（管理员）
+func (h *WarehouseHandler) GetWarehouseStocks(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的仓库ID")
+		return
+	}
+
+	stocks, err := h.warehouseService.GetWarehouseStocks(uint(id))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取仓库库存失败")
+		return
+	}
+
+	response.Success(c, stocks)
+}
+
+// SetWarehouseStock 设置仓库中某商品的库存（管理员）
+func (h *WarehouseHandler) SetWarehouseStock(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的仓库ID")
+		return
+	}
+
+	var req struct {
+		ProductID uint `json:"product_id" binding:"required"`
+		Stock     *int `json:"stock" binding:"required,gte=0"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	stock, err := h.warehouseService.SetWarehouseStock(middleware.GetCurrentUserID(c), uint(id), req.ProductID, *req.Stock)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "设置仓库库存失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, stock)
+}
+
+// GetProductStocks 获取商品在各仓库的库存分布（管理员）
+func (h *WarehouseHandler) GetProductStocks(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的商品ID")
+		return
+	}
+
+	stocks, err := h.warehouseService.GetProductStocks(uint(id))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取商品库存分布失败")
+		return
+	}
+
+	response.Success(c, stocks)
+}
+
+// TransferStock 仓库间调拨库存（管理员）
+func (h *WarehouseHandler) TransferStock(c *gin.Context) {
+	var req service.StockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	transfer, err := h.warehouseService.TransferStock(middleware.GetCurrentUserID(c), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInsufficientStock) {
+			response.Error(c, http.StatusConflict, "调拨失败: "+err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "调拨失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, transfer)
+}
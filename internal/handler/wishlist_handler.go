@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shoppee/ecommerce/internal/service"
+	"github.com/shoppee/ecommerce/pkg/response"
+)
+
+// WishlistHandler 收藏夹处理器
+type WishlistHandler struct {
+	wishlistService *service.WishlistService
+}
+
+// NewWishlistHandler 创建收藏夹处理器实例
+func NewWishlistHandler() *WishlistHandler {
+	return &WishlistHandler{
+		wishlistService: service.NewWishlistService(),
+	}
+}
+
+// GetWishlists 获取当前用户的收藏夹列表
+func (h *WishlistHandler) GetWishlists(c *gin.Context) {
+	wishlists, err := h.wishlistService.GetWishlists(c.GetUint("user_id"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取收藏夹失败")
+		return
+	}
+
+	response.Success(c, wishlists)
+}
+
+// GetWishlist 获取收藏夹详情（含商品及降价标记）
+func (h *WishlistHandler) GetWishlist(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的收藏夹ID")
+		return
+	}
+
+	wishlist, err := h.wishlistService.GetWishlist(c.GetUint("user_id"), uint(id))
+	if err != nil {
+		respondWishlistError(c, err)
+		return
+	}
+
+	response.Success(c, wishlist)
+}
+
+// CreateWishlist 创建收藏夹
+func (h *WishlistHandler) CreateWishlist(c *gin.Context) {
+	var req service.WishlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	wishlist, err := h.wishlistService.CreateWishlist(c.GetUint("user_id"), &req)
+	if err != nil {
+		respondWishlistError(c, err)
+		return
+	}
+
+	response.Success(c, wishlist)
+}
+
+// RenameWishlist 重命名收藏夹
+func (h *WishlistHandler) RenameWishlist(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的收藏夹ID")
+		return
+	}
+
+	var req service.WishlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	wishlist, err := h.wishlistService.RenameWishlist(c.GetUint("user_id"), uint(id), &req)
+	if err != nil {
+		respondWishlistError(c, err)
+		return
+	}
+
+	response.Success(c, wishlist)
+}
+
+// DeleteWishlist 删除收藏夹
+func (h *WishlistHandler) DeleteWishlist(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的收藏夹ID")
+		return
+	}
+
+	if err := h.wishlistService.DeleteWishlist(c.GetUint("user_id"), uint(id)); err != nil {
+		respondWishlistError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// AddItem 收藏商品
+func (h *WishlistHandler) AddItem(c *gin.Context) {
+	var req service.AddWishlistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	item, err := h.wishlistService.AddItem(c.GetUint("user_id"), &req)
+	if err != nil {
+		respondWishlistError(c, err)
+		return
+	}
+
+	response.Success(c, item)
+}
+
+// RemoveItem 取消收藏
+func (h *WishlistHandler) RemoveItem(c *gin.Context) {
+	wishlistID, itemID, ok := parseWishlistItemParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.wishlistService.RemoveItem(c.GetUint("user_id"), wishlistID, itemID); err != nil {
+		respondWishlistError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "已取消收藏", nil)
+}
+
+// MoveToCart 收藏商品加入购物车
+func (h *WishlistHandler) MoveToCart(c *gin.Context) {
+	wishlistID, itemID, ok := parseWishlistItemParams(c)
+	if !ok {
+		return
+	}
+
+	var req service.MoveToCartRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.Error(c, http.StatusBadRequest, "参数错误: "+err.Error())
+			return
+		}
+	}
+
+	if err := h.wishlistService.MoveToCart(c.GetUint("user_id"), wishlistID, itemID, &req); err != nil {
+		respondWishlistError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "已加入购物车", nil)
+}
+
+// parseWishlistItemParams 解析收藏夹ID与收藏项ID
+func parseWishlistItemParams(c *gin.Context) (uint, uint, bool) {
+	wishlistID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的收藏夹ID")
+		return 0, 0, false
+	}
+	itemID, err := strconv.ParseUint(c.Param("item_id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的收藏项ID")
+		return 0, 0, false
+	}
+	return uint(wishlistID), uint(itemID), true
+}
+
+// respondWishlistError 输出收藏夹操作错误
+func respondWishlistError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrWishlistNotFound),
+		errors.Is(err, service.ErrWishlistItemNotFound),
+		errors.Is(err, service.ErrProductNotFound):
+		response.Error(c, http.StatusNotFound, err.Error())
+	default:
+		response.Error(c, http.StatusBadRequest, err.Error())
+	}
+}
@@ -45,6 +45,22 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// OptionalAuthMiddleware 可选认证中间件：携带有效token时写入用户信息，未携带或无效时按匿名用户继续处理
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if claims, err := jwt.ParseToken(parts[1]); err == nil {
+				c.Set("user_id", claims.UserID)
+				c.Set("username", claims.Username)
+				c.Set("role", claims.Role)
+			}
+		}
+
+		c.Next()
+	}
+}
+
 // AdminMiddleware 管理员权限中间件（需配合AuthMiddleware使用）
 func AdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+// 商品属性类型
+const (
+	AttributeTypeString  = "string"  // 文本，如品牌、材质
+	AttributeTypeNumber  = "number"  // 数值，如屏幕尺寸，支持区间筛选
+	AttributeTypeBoolean = "boolean" // 是/否，如是否支持5G
+)
+
+// Attribute 分类下定义的商品属性
+type Attribute struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	CategoryID uint   `gorm:"uniqueIndex:idx_category_attribute_code;not null" json:"category_id"`
+	Code       string `gorm:"uniqueIndex:idx_category_attribute_code;size:50;not null" json:"code"` // 筛选参数名，如 brand
+	Name       string `gorm:"size:100;not null" json:"name"`
+	Type       string `gorm:"size:20;not null;default:'string'" json:"type"` // string, number, boolean
+	Unit       string `gorm:"size:20" json:"unit"`                           // 数值单位，如「英寸」
+	Filterable bool   `gorm:"default:false" json:"filterable"`               // 是否出现在筛选栏
+	Sort       int    `gorm:"default:0" json:"sort"`
+}
+
+// TableName 指定表名
+func (Attribute) TableName() string {
+	return "attributes"
+}
+
+// ProductAttributeValue 商品的属性值
+type ProductAttributeValue struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ProductID   uint     `gorm:"uniqueIndex:idx_product_attribute;not null" json:"product_id"`
+	AttributeID uint     `gorm:"uniqueIndex:idx_product_attribute;index:idx_attribute_value,priority:1;not null" json:"attribute_id"`
+	Value       string   `gorm:"size:255;index:idx_attribute_value,priority:2" json:"value"` // 文本形式的值（数值、布尔也保存其文本形式）
+	NumberValue *float64 `gorm:"type:decimal(12,4)" json:"number_value,omitempty"`           // 数值类型属性的值，用于区间筛选
+
+	// 关联
+	Attribute *Attribute `gorm:"foreignKey:AttributeID" json:"attribute,omitempty"`
+}
+
+// TableName 指定表名
+func (ProductAttributeValue) TableName() string {
+	return "product_attribute_values"
+}
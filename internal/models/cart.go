@@ -34,12 +34,14 @@ type CartItem struct {
 
 	CartID    uint `gorm:"index;not null" json:"cart_id"`
 	ProductID uint `gorm:"index;not null" json:"product_id"`
+	VariantID *uint `gorm:"index" json:"variant_id"` // 多规格商品的规格ID
 	Quantity  int  `gorm:"not null;default:1" json:"quantity"`
 	Selected  bool `gorm:"default:true" json:"selected"` // 是否选中（用于结算）
 	
 	// 关联
 	Cart    *Cart    `gorm:"foreignKey:CartID" json:"cart,omitempty"`
 	Product *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Variant *ProductVariant `gorm:"foreignKey:VariantID" json:"variant,omitempty"`
 }
 
 // TableName 指定表名
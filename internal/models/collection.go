@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+)
+
+// 合集类型
+const (
+	CollectionTypeManual = "manual" // 人工挑选商品并排序
+	CollectionTypeRule   = "rule"   // 按规则自动匹配在售商品
+)
+
+// Tag 商品标签（营销用途，如「新品」「环保」），与商品多对多
+type Tag struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Code  string `gorm:"uniqueIndex;size:50;not null" json:"code"` // 筛选参数，如 new-arrival
+	Name  string `gorm:"uniqueIndex;size:50;not null" json:"name"`
+	Color string `gorm:"size:20" json:"color"` // 前端展示色，如 #ff4d4f
+}
+
+// TableName 指定表名
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// CollectionRule 规则合集的匹配条件，已设置的条件需同时满足
+type CollectionRule struct {
+	CategoryID *uint    `json:"category_id,omitempty"` // 包含子分类的商品
+	TagID      *uint    `json:"tag_id,omitempty"`
+	MinPrice   *float64 `gorm:"type:decimal(10,2)" json:"min_price,omitempty" binding:"omitempty,gte=0"`
+	MaxPrice   *float64 `gorm:"type:decimal(10,2)" json:"max_price,omitempty" binding:"omitempty,gte=0"`
+	InStock    bool     `json:"in_stock"`
+	Sort       string   `gorm:"size:20" json:"sort,omitempty" binding:"omitempty,oneof=price_asc price_desc sale_desc new"` // 商品默认排序
+}
+
+// IsEmpty 是否未设置任何匹配条件
+func (r CollectionRule) IsEmpty() bool {
+	return r.CategoryID == nil && r.TagID == nil && r.MinPrice == nil && r.MaxPrice == nil && !r.InStock
+}
+
+// Collection 商品合集（如「99元以下」「夏季大促」）
+type Collection struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Code        string         `gorm:"uniqueIndex;size:50;not null" json:"code"` // 商品列表筛选参数，如 summer-sale
+	Name        string         `gorm:"size:100;not null" json:"name"`
+	Description string         `gorm:"type:text" json:"description"`
+	Type        string         `gorm:"size:20;not null;default:'manual'" json:"type"` // manual, rule
+	Status      string         `gorm:"size:20;default:'active'" json:"status"`        // active, inactive
+	Sort        int            `gorm:"default:0" json:"sort"`
+	Rule        CollectionRule `gorm:"embedded;embeddedPrefix:rule_" json:"rule"` // 仅规则合集使用
+
+	// 关联
+	Products []CollectionProduct `gorm:"foreignKey:CollectionID" json:"products,omitempty"`
+}
+
+// TableName 指定表名
+func (Collection) TableName() string {
+	return "collections"
+}
+
+// CollectionProduct 人工合集中的商品及顺序
+type CollectionProduct struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	CollectionID uint `gorm:"uniqueIndex:idx_collection_product;not null" json:"collection_id"`
+	ProductID    uint `gorm:"uniqueIndex:idx_collection_product;index;not null" json:"product_id"`
+	Sort         int  `gorm:"default:0" json:"sort"`
+
+	// 关联
+	Product *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+// TableName 指定表名
+func (CollectionProduct) TableName() string {
+	return "collection_products"
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Media 媒体文件（商品图片、评价晒图）
+type Media struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	UploaderID  uint   `gorm:"index;uniqueIndex:idx_media_uploader_checksum,where:checksum <> '' AND deleted_at IS NULL" json:"uploader_id"`
+	StorageKey  string `gorm:"size:255;index" json:"-"` // 存储中的对象键，为空表示外部链接（历史数据）
+	URL         string `gorm:"size:500;not null" json:"url"`
+	ContentType string `gorm:"size:50" json:"content_type"`
+	Size        int64  `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Checksum    string `gorm:"size:64;index;uniqueIndex:idx_media_uploader_checksum" json:"-"` // 原图 SHA256，同一用户重复上传时复用（外部链接为空）
+
+	Thumbnails []MediaThumbnail `gorm:"foreignKey:MediaID" json:"thumbnails,omitempty"`
+}
+
+// TableName 指定表名
+func (Media) TableName() string {
+	return "media"
+}
+
+// MediaThumbnail 媒体缩略图
+type MediaThumbnail struct {
+	ID      uint `gorm:"primarykey" json:"-"`
+	MediaID uint `gorm:"uniqueIndex:idx_media_thumbnail;not null" json:"-"`
+
+	Name       string `gorm:"uniqueIndex:idx_media_thumbnail;size:20;not null" json:"name"` // 尺寸名称，如 small, medium, large
+	StorageKey string `gorm:"size:255" json:"-"`
+	URL        string `gorm:"size:500;not null" json:"url"`
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+}
+
+// TableName 指定表名
+func (MediaThumbnail) TableName() string {
+	return "media_thumbnails"
+}
+
+// ProductMedia 商品图片
+type ProductMedia struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ProductID uint   `gorm:"uniqueIndex:idx_product_media;not null" json:"product_id"`
+	MediaID   uint   `gorm:"uniqueIndex:idx_product_media;index;not null" json:"media_id"`
+	Sort      int    `gorm:"not null;default:0" json:"sort"` // 第一张为主图
+	AltText   string `gorm:"size:255" json:"alt_text"`
+
+	Media *Media `gorm:"foreignKey:MediaID" json:"media,omitempty"`
+}
+
+// TableName 指定表名
+func (ProductMedia) TableName() string {
+	return "product_media"
+}
+
+// ReviewMedia 评价晒图
+type ReviewMedia struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ReviewID uint   `gorm:"uniqueIndex:idx_review_media;not null" json:"review_id"`
+	MediaID  uint   `gorm:"uniqueIndex:idx_review_media;index;not null" json:"media_id"`
+	Sort     int    `gorm:"not null;default:0" json:"sort"`
+	AltText  string `gorm:"size:255" json:"alt_text"`
+
+	Media *Media `gorm:"foreignKey:MediaID" json:"media,omitempty"`
+}
+
+// TableName 指定表名
+func (ReviewMedia) TableName() string {
+	return "review_media"
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// Notification 站内通知（用户离线时保存，上线后补发）
+type Notification struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID      uint       `gorm:"index;not null" json:"user_id"`
+	Type        string     `gorm:"size:30;not null;index" json:"type"` // stock_alert, back_in_stock
+	Title       string     `gorm:"size:200" json:"title"`
+	Content     string     `gorm:"type:text" json:"content"`  // JSON对象字符串
+	DeliveredAt *time.Time `gorm:"index" json:"delivered_at"` // 通过WebSocket送达的时间，为空表示待补发
+	ReadAt      *time.Time `json:"read_at"`
+}
+
+// TableName 指定表名
+func (Notification) TableName() string {
+	return "notifications"
+}
@@ -36,6 +36,7 @@ type Order struct {
 	User       *User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	OrderItems []OrderItem `gorm:"foreignKey:OrderID" json:"order_items,omitempty"`
 	Payment    *Payment    `gorm:"foreignKey:OrderID" json:"payment,omitempty"`
+	Shipments  []Shipment  `gorm:"foreignKey:OrderID" json:"shipments,omitempty"`
 }
 
 // TableName 指定表名
@@ -52,6 +53,7 @@ type OrderItem struct {
 
 	OrderID   uint    `gorm:"index;not null" json:"order_id"`
 	ProductID uint    `gorm:"index;not null" json:"product_id"`
+	VariantID *uint   `gorm:"index" json:"variant_id"`
 	Quantity  int     `gorm:"not null" json:"quantity"`
 	Price     float64 `gorm:"type:decimal(10,2);not null" json:"price"`
 	SubTotal  float64 `gorm:"type:decimal(10,2);not null" json:"sub_total"`
@@ -60,10 +62,12 @@ type OrderItem struct {
 	ProductName  string `gorm:"size:200" json:"product_name"`
 	ProductImage string `gorm:"size:255" json:"product_image"`
 	ProductSKU   string `gorm:"size:100" json:"product_sku"`
+	VariantName  string `gorm:"size:200" json:"variant_name"`
 	
 	// 关联
 	Order   *Order   `gorm:"foreignKey:OrderID" json:"order,omitempty"`
 	Product *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Variant *ProductVariant `gorm:"foreignKey:VariantID" json:"variant,omitempty"`
 }
 
 // TableName 指定表名
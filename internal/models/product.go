@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/shoppee/ecommerce/pkg/tokenizer"
 	"gorm.io/gorm"
 )
 
@@ -13,24 +14,49 @@ type Product struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
-	Name        string  `gorm:"size:200;not null;index" json:"name" binding:"required"`
-	Description string  `gorm:"type:text" json:"description"`
-	Price       float64 `gorm:"type:decimal(10,2);not null" json:"price" binding:"required,gt=0"`
-	OrigPrice   float64 `gorm:"type:decimal(10,2)" json:"orig_price"` // 原价
-	Stock       int     `gorm:"not null;default:0" json:"stock" binding:"gte=0"`
-	SKU         string  `gorm:"uniqueIndex;size:100" json:"sku"`
-	Images      string  `gorm:"type:text" json:"images"` // JSON数组字符串
-	Status      string  `gorm:"size:20;default:'active'" json:"status"` // active, inactive, out_of_stock
-	ViewCount   int     `gorm:"default:0" json:"view_count"`
-	SaleCount   int     `gorm:"default:0" json:"sale_count"`
+	Name             string  `gorm:"size:200;not null;index" json:"name" binding:"required"`
+	Description      string  `gorm:"type:text" json:"description"`
+	Price            float64 `gorm:"type:decimal(10,2);not null" json:"price" binding:"required,gt=0"`
+	OrigPrice        float64 `gorm:"type:decimal(10,2)" json:"orig_price"` // 原价
+	Stock            int     `gorm:"not null;default:0" json:"stock" binding:"gte=0"`
+	ReorderThreshold int     `gorm:"not null;default:0" json:"reorder_threshold"` // 补货阈值，库存低于该值时预警，0 表示不预警
+	SKU              string  `gorm:"uniqueIndex;size:100" json:"sku"`
+	Slug             string  `gorm:"size:100" json:"slug"`                   // SEO 链接标识，默认由名称生成，非空时唯一
+	Images           string  `gorm:"type:text" json:"images"`                // JSON数组字符串（已废弃，迁移时导入 Media，新数据使用 Media）
+	Status           string  `gorm:"size:20;default:'active'" json:"status"` // draft, active, inactive, out_of_stock
+	ViewCount        int     `gorm:"default:0" json:"view_count"`
+	SaleCount        int     `gorm:"default:0" json:"sale_count"`
+	FavoriteCount    int     `gorm:"default:0" json:"favorite_count"`   // 收藏该商品的用户数
+	Version          int     `gorm:"not null;default:1" json:"version"` // 编辑版本号，每次修改商品信息时递增，用于乐观锁（ETag）
+
+	// 定时上下架（由调度器到期执行后清空）
+	PublishAt   *time.Time `gorm:"index" json:"publish_at"`   // 草稿或已下架商品的定时上架时间
+	UnpublishAt *time.Time `gorm:"index" json:"unpublish_at"` // 定时下架时间
+
+	// 全文检索分词（由钩子维护，数据库据此生成加权 tsvector 列 search_vector）
+	SearchName string `gorm:"type:text" json:"-"` // 名称、SKU 分词，权重 A
+	SearchBody string `gorm:"type:text" json:"-"` // 描述分词，权重 B
 
 	// 外键
 	CategoryID uint      `gorm:"index" json:"category_id"`
 	Category   *Category `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
 
+	// 规格
+	Options  []ProductOption  `gorm:"foreignKey:ProductID" json:"options,omitempty"`
+	Variants []ProductVariant `gorm:"foreignKey:ProductID" json:"variants,omitempty"`
+
+	// 属性
+	Attributes []ProductAttributeValue `gorm:"foreignKey:ProductID" json:"attributes,omitempty"`
+
+	// 营销标签
+	Tags []Tag `gorm:"many2many:product_tags" json:"tags,omitempty"`
+
+	// 图片（按 Sort 排序）
+	Media []ProductMedia `gorm:"foreignKey:ProductID" json:"media,omitempty"`
+
 	// 关联
-	Reviews   []Review    `gorm:"foreignKey:ProductID" json:"reviews,omitempty"`
-	CartItems []CartItem  `gorm:"foreignKey:ProductID" json:"-"`
+	Reviews    []Review    `gorm:"foreignKey:ProductID" json:"reviews,omitempty"`
+	CartItems  []CartItem  `gorm:"foreignKey:ProductID" json:"-"`
 	OrderItems []OrderItem `gorm:"foreignKey:ProductID" json:"-"`
 }
 
@@ -39,6 +65,68 @@ func (Product) TableName() string {
 	return "products"
 }
 
+// BuildSearchText 根据名称、SKU、描述重建检索分词
+func (p *Product) BuildSearchText() {
+	p.SearchName = tokenizer.Join(p.Name + " " + p.SKU)
+	p.SearchBody = tokenizer.Join(p.Description)
+}
+
+// BeforeCreate GORM钩子：创建前生成检索分词
+func (p *Product) BeforeCreate(tx *gorm.DB) error {
+	p.BuildSearchText()
+	return nil
+}
+
+// AfterCreate GORM钩子：未指定 slug 时由名称生成
+func (p *Product) AfterCreate(tx *gorm.DB) error {
+	if p.Slug != "" {
+		return nil
+	}
+	s, err := assignSlug(tx, &Product{}, SlugEntityProduct, p.Name, p.ID)
+	if err != nil {
+		return err
+	}
+	p.Slug = s
+	return nil
+}
+
+// BeforeUpdate GORM钩子：名称、SKU或描述变更时同步更新检索分词
+func (p *Product) BeforeUpdate(tx *gorm.DB) error {
+	if !tx.Statement.Changed("Name", "SKU", "Description") {
+		return nil
+	}
+
+	// 变更后的值可能来自结构体或map，未变更的字段沿用当前值
+	next := *p
+	switch dest := tx.Statement.Dest.(type) {
+	case map[string]interface{}:
+		if v, ok := dest["name"].(string); ok {
+			next.Name = v
+		}
+		if v, ok := dest["sku"].(string); ok {
+			next.SKU = v
+		}
+		if v, ok := dest["description"].(string); ok {
+			next.Description = v
+		}
+	case *Product:
+		if dest.Name != "" {
+			next.Name = dest.Name
+		}
+		if dest.SKU != "" {
+			next.SKU = dest.SKU
+		}
+		if dest.Description != "" {
+			next.Description = dest.Description
+		}
+	}
+	next.BuildSearchText()
+
+	tx.Statement.SetColumn("SearchName", next.SearchName)
+	tx.Statement.SetColumn("SearchBody", next.SearchBody)
+	return nil
+}
+
 // Category 商品分类模型
 type Category struct {
 	ID        uint           `gorm:"primarykey" json:"id"`
@@ -47,6 +135,7 @@ type Category struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	Name        string `gorm:"size:100;not null;index" json:"name" binding:"required"`
+	Slug        string `gorm:"size:100" json:"slug"` // SEO 链接标识，默认由名称生成，非空时唯一
 	Description string `gorm:"type:text" json:"description"`
 	Icon        string `gorm:"size:255" json:"icon"`
 	Sort        int    `gorm:"default:0" json:"sort"`
@@ -54,9 +143,13 @@ type Category struct {
 
 	// 父子分类
 	ParentID *uint      `gorm:"index" json:"parent_id"`
+	Path     string     `gorm:"size:255;index" json:"path"` // 物化路径，祖先及自身ID，如 /1/5/12/，用于查询子树与面包屑
+	Depth    int        `gorm:"default:0" json:"depth"`     // 层级，根分类为 0
 	Parent   *Category  `gorm:"foreignKey:ParentID" json:"parent,omitempty"`
 	Children []Category `gorm:"foreignKey:ParentID" json:"children,omitempty"`
 
+	ProductCount int64 `gorm:"-" json:"product_count"` // 含子分类的已上架商品数
+
 	// 关联
 	Products []Product `gorm:"foreignKey:CategoryID" json:"products,omitempty"`
 }
@@ -65,3 +158,16 @@ type Category struct {
 func (Category) TableName() string {
 	return "categories"
 }
+
+// AfterCreate GORM钩子：未指定 slug 时由名称生成
+func (c *Category) AfterCreate(tx *gorm.DB) error {
+	if c.Slug != "" {
+		return nil
+	}
+	s, err := assignSlug(tx, &Category{}, SlugEntityCategory, c.Name, c.ID)
+	if err != nil {
+		return err
+	}
+	c.Slug = s
+	return nil
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// ProductRelation 商品关联（经常一起购买），由后台任务根据订单项共现离线计算
+type ProductRelation struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ProductID        uint    `gorm:"uniqueIndex:idx_product_relation,priority:1;index:idx_product_relation_score,priority:1;not null" json:"product_id"`
+	RelatedProductID uint    `gorm:"uniqueIndex:idx_product_relation,priority:2;not null" json:"related_product_id"`
+	Support          int     `gorm:"not null" json:"support"`                                                     // 同时出现的订单数
+	Score            float64 `gorm:"not null;index:idx_product_relation_score,priority:2,sort:desc" json:"score"` // 余弦相似度：共现订单数 / √(两商品各自订单数之积)
+
+	// 关联
+	RelatedProduct *Product `gorm:"foreignKey:RelatedProductID" json:"related_product,omitempty"`
+}
+
+// TableName 指定表名
+func (ProductRelation) TableName() string {
+	return "product_relations"
+}
@@ -15,19 +15,22 @@ type Review struct {
 
 	UserID    uint   `gorm:"index;not null" json:"user_id"`
 	ProductID uint   `gorm:"index;not null" json:"product_id"`
+	VariantID *uint  `gorm:"index" json:"variant_id"` // 评价的具体规格
 	OrderID   uint   `gorm:"index" json:"order_id"`
 	Rating    int    `gorm:"not null" json:"rating" binding:"required,gte=1,lte=5"`
 	Content   string `gorm:"type:text" json:"content"`
-	Images    string `gorm:"type:text" json:"images"` // JSON数组字符串
+	Images    string `gorm:"type:text" json:"images"`                   // JSON数组字符串（已废弃，迁移时导入 Media，新数据使用 Media）
 	Status    string `gorm:"size:20;default:'published'" json:"status"` // published, hidden
-	
+
 	// 商家回复
 	Reply     string     `gorm:"type:text" json:"reply"`
 	RepliedAt *time.Time `json:"replied_at"`
-	
+
 	// 关联
-	User    *User    `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Product *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	User    *User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Product *Product        `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Variant *ProductVariant `gorm:"foreignKey:VariantID" json:"variant,omitempty"`
+	Media   []ReviewMedia   `gorm:"foreignKey:ReviewID" json:"media,omitempty"`
 }
 
 // TableName 指定表名
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// SearchSynonym 搜索同义词组，组内任一词都会扩展为整组词检索
+type SearchSynonym struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Terms string `gorm:"size:500;not null" json:"terms"` // 逗号分隔，如「手机,移动电话,mobile」
+}
+
+// TableName 指定表名
+func (SearchSynonym) TableName() string {
+	return "search_synonyms"
+}
+
+// 搜索来源
+const (
+	SearchSourceSearch = "search" // 搜索接口
+	SearchSourceList   = "list"   // 商品列表的关键词筛选
+)
+
+// SearchLog 搜索日志，记录搜索词、结果数及后续的点击与购买，用于搜索分析
+type SearchLog struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Keyword     string `gorm:"size:100;not null;index" json:"keyword"` // 规范化后的搜索词
+	Source      string `gorm:"size:20;not null" json:"source"`         // search, list
+	ResultCount int64  `gorm:"not null;default:0" json:"result_count"`
+	UserID      *uint  `gorm:"index" json:"user_id"`
+
+	Clicked          bool  `gorm:"not null;default:false" json:"clicked"`
+	ClickedProductID *uint `gorm:"index" json:"clicked_product_id"` // 最近一次点击的商品
+	Purchased        bool  `gorm:"not null;default:false" json:"purchased"`
+	OrderID          *uint `json:"order_id"`
+}
+
+// TableName 指定表名
+func (SearchLog) TableName() string {
+	return "search_logs"
+}
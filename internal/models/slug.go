@@ -0,0 +1,110 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shoppee/ecommerce/pkg/slug"
+	"gorm.io/gorm"
+)
+
+// slug 所属实体类型
+const (
+	SlugEntityProduct  = "product"
+	SlugEntityCategory = "category"
+)
+
+// slugTables 实体类型对应的表
+var slugTables = map[string]string{
+	SlugEntityProduct:  "products",
+	SlugEntityCategory: "categories",
+}
+
+// SlugRedirect 历史 slug，访问旧链接时据此提示跳转到当前 slug
+type SlugRedirect struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	EntityType string `gorm:"uniqueIndex:idx_slug_redirect;size:20;not null" json:"entity_type"` // product, category
+	Slug       string `gorm:"uniqueIndex:idx_slug_redirect;size:100;not null" json:"slug"`
+	EntityID   uint   `gorm:"index;not null" json:"entity_id"`
+}
+
+// TableName 指定表名
+func (SlugRedirect) TableName() string {
+	return "slug_redirects"
+}
+
+// SlugTaken 判断 slug 是否已被同类型的其他记录使用（含已删除的记录，与唯一索引一致）
+func SlugTaken(tx *gorm.DB, entityType, s string, id uint) (bool, error) {
+	var count int64
+	if err := tx.Session(&gorm.Session{NewDB: true}).Unscoped().Table(slugTables[entityType]).
+		Where("slug = ? AND id <> ?", s, id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// UniqueSlug 由名称生成唯一 slug，重名时追加记录ID；名称无法转写时使用「类型-ID」
+func UniqueSlug(tx *gorm.DB, entityType, name string, id uint) (string, error) {
+	for _, candidate := range slugCandidates(entityType, name, id) {
+		taken, err := SlugTaken(tx, entityType, candidate, id)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("无法为 %s %d 生成唯一 slug", entityType, id)
+}
+
+// slugCandidates 按优先级排列的候选 slug，最后一个带记录ID
+func slugCandidates(entityType, name string, id uint) []string {
+	base := slug.Make(name)
+	if base == "" {
+		return []string{fmt.Sprintf("%s-%d", entityType, id)}
+	}
+	return []string{base, fmt.Sprintf("%s-%d", base, id)}
+}
+
+// assignSlug 新建记录未指定 slug 时生成并保存。
+// 并发创建同名记录时各自检查都可能选中同一 slug，后写入的一方触发唯一索引冲突，
+// 此时回滚到保存点改用带记录ID的 slug，而不是让整个创建失败
+func assignSlug(tx *gorm.DB, model interface{}, entityType, name string, id uint) (string, error) {
+	s, err := UniqueSlug(tx, entityType, name, id)
+	if err != nil {
+		return "", err
+	}
+
+	db := tx.Session(&gorm.Session{NewDB: true})
+	if err := db.SavePoint("assign_slug").Error; err != nil {
+		return "", err
+	}
+	err = db.Model(model).Where("id = ?", id).UpdateColumn("slug", s).Error
+	if err == nil {
+		return s, nil
+	}
+	if !isDuplicateKey(tx, err) {
+		return "", err
+	}
+	if err := db.RollbackTo("assign_slug").Error; err != nil {
+		return "", err
+	}
+
+	candidates := slugCandidates(entityType, name, id)
+	s = candidates[len(candidates)-1]
+	if err := db.Model(model).Where("id = ?", id).UpdateColumn("slug", s).Error; err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// isDuplicateKey 是否为唯一索引冲突
+func isDuplicateKey(tx *gorm.DB, err error) bool {
+	if translator, ok := tx.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
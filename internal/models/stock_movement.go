@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 库存变动原因
+const (
+	StockReasonSale       = "sale"       // 下单扣减
+	StockReasonCancel     = "cancel"     // 取消订单回补
+	StockReasonRestock    = "restock"    // 入库补货
+	StockReasonAdjustment = "adjustment" // 盘点/人工调整
+	StockReasonReturn     = "return"     // 退货入库
+)
+
+// ErrStockMovementImmutable 库存流水只允许追加
+var ErrStockMovementImmutable = errors.New("库存流水不可修改或删除")
+
+// StockMovement 库存变动流水（只追加，不修改、不删除）
+type StockMovement struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	ProductID   uint   `gorm:"index;not null" json:"product_id"`
+	WarehouseID uint   `gorm:"index" json:"warehouse_id,omitempty"` // 涉及具体仓库时记录
+	VariantID   uint   `gorm:"index" json:"variant_id,omitempty"`   // 多规格商品记录变动的规格
+	Reason      string `gorm:"size:20;not null;index" json:"reason"`
+	RefID       string `gorm:"size:64;index" json:"ref_id"`  // 关联单号（订单号、批次号等）
+	ActorID     uint   `gorm:"index" json:"actor_id"`        // 操作人，0 表示系统
+	Quantity    int    `gorm:"not null" json:"quantity"`     // 变动量，正数入库、负数出库
+	BeforeStock int    `gorm:"not null" json:"before_stock"` // 变动前库存
+	AfterStock  int    `gorm:"not null" json:"after_stock"`  // 变动后库存
+	Remark      string `gorm:"size:255" json:"remark"`
+}
+
+// TableName 指定表名
+func (StockMovement) TableName() string {
+	return "stock_movements"
+}
+
+// BeforeUpdate GORM钩子：禁止修改流水
+func (m *StockMovement) BeforeUpdate(tx *gorm.DB) error {
+	return ErrStockMovementImmutable
+}
+
+// BeforeDelete GORM钩子：禁止删除流水
+func (m *StockMovement) BeforeDelete(tx *gorm.DB) error {
+	return ErrStockMovementImmutable
+}
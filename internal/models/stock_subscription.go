@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// StockSubscription 到货通知订阅
+type StockSubscription struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID       uint       `gorm:"uniqueIndex:idx_subscription_user_variant;not null" json:"user_id"`
+	ProductID    uint       `gorm:"uniqueIndex:idx_subscription_user_variant;index:idx_subscription_variant_queue,priority:1;not null" json:"product_id"`
+	VariantID    uint       `gorm:"uniqueIndex:idx_subscription_user_variant;index:idx_subscription_variant_queue,priority:2;not null;default:0" json:"variant_id"` // 订阅的规格，0 表示整个商品
+	Status       string     `gorm:"size:20;default:'pending';index:idx_subscription_variant_queue,priority:3" json:"status"`                                        // pending, notified, cancelled
+	SubscribedAt time.Time  `gorm:"index:idx_subscription_variant_queue,priority:4" json:"subscribed_at"`                                                           // 排队时间（按先到先通知）
+	NotifiedAt   *time.Time `json:"notified_at"`
+
+	// 关联
+	Product *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+// TableName 指定表名
+func (StockSubscription) TableName() string {
+	return "stock_subscriptions"
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+)
+
+// ProductOption 商品规格类型（如尺码、颜色）
+type ProductOption struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ProductID uint   `gorm:"uniqueIndex:idx_product_option_name;not null" json:"product_id"`
+	Name      string `gorm:"uniqueIndex:idx_product_option_name;size:50;not null" json:"name"`
+	Sort      int    `gorm:"default:0" json:"sort"`
+
+	// 关联
+	Values []ProductOptionValue `gorm:"foreignKey:OptionID" json:"values,omitempty"`
+}
+
+// TableName 指定表名
+func (ProductOption) TableName() string {
+	return "product_options"
+}
+
+// ProductOptionValue 规格值（如 M、红色）
+type ProductOptionValue struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	OptionID uint   `gorm:"uniqueIndex:idx_option_value;not null" json:"option_id"`
+	Value    string `gorm:"uniqueIndex:idx_option_value;size:50;not null" json:"value"`
+	Sort     int    `gorm:"default:0" json:"sort"`
+}
+
+// TableName 指定表名
+func (ProductOptionValue) TableName() string {
+	return "product_option_values"
+}
+
+// ProductVariant 商品规格组合（SKU），拥有独立的价格、库存和图片
+// 多规格商品的 Product.Stock 为各规格库存之和
+type ProductVariant struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ProductID uint    `gorm:"uniqueIndex:idx_product_variant_options;not null" json:"product_id"`
+	OptionKey string  `gorm:"uniqueIndex:idx_product_variant_options;size:100;not null" json:"-"` // 规格值ID升序拼接，保证同一组合唯一
+	SKU       string  `gorm:"uniqueIndex;size:100;not null" json:"sku"`
+	Name      string  `gorm:"size:200" json:"name"` // 规格组合名称，如「红色 / M」
+	Price     float64 `gorm:"type:decimal(10,2);not null" json:"price"`
+	Stock     int     `gorm:"not null;default:0" json:"stock"`
+	Image     string  `gorm:"size:255" json:"image"`
+	Status    string  `gorm:"size:20;default:'active'" json:"status"` // active, inactive
+
+	// 关联
+	Product      *Product             `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	OptionValues []ProductOptionValue `gorm:"many2many:product_variant_values" json:"option_values,omitempty"`
+}
+
+// TableName 指定表名
+func (ProductVariant) TableName() string {
+	return "product_variants"
+}
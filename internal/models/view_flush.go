@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// ViewFlushBatch 已写回数据库的浏览量批次，与浏览量更新在同一事务内写入，同一批次重复写回时跳过
+type ViewFlushBatch struct {
+	ID        string    `gorm:"primarykey;size:32" json:"id"` // 批次号，取出待写哈希时随机生成，Redis 数据丢失后也不会与历史批次重复
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName 指定表名
+func (ViewFlushBatch) TableName() string {
+	return "view_flush_batches"
+}
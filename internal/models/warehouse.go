@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Warehouse 仓库模型
+type Warehouse struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Code     string `gorm:"uniqueIndex;size:50;not null" json:"code"`
+	Name     string `gorm:"size:100;not null" json:"name"`
+	Province string `gorm:"size:50;not null;index" json:"province"`
+	City     string `gorm:"size:50" json:"city"`
+	Address  string `gorm:"size:255" json:"address"`
+	Priority int    `gorm:"default:0" json:"priority"`              // 同等条件下优先级高的仓库先发货
+	Status   string `gorm:"size:20;default:'active'" json:"status"` // active, inactive
+
+	// 关联
+	Stocks []WarehouseStock `gorm:"foreignKey:WarehouseID" json:"stocks,omitempty"`
+}
+
+// TableName 指定表名
+func (Warehouse) TableName() string {
+	return "warehouses"
+}
+
+// WarehouseStock 仓库库存模型（Product.Stock 为各启用仓库库存之和）
+type WarehouseStock struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	WarehouseID uint `gorm:"uniqueIndex:idx_warehouse_product;not null" json:"warehouse_id"`
+	ProductID   uint `gorm:"uniqueIndex:idx_warehouse_product;index;not null" json:"product_id"`
+	Stock       int  `gorm:"not null;default:0" json:"stock"`
+
+	// 关联
+	Warehouse *Warehouse `gorm:"foreignKey:WarehouseID" json:"warehouse,omitempty"`
+	Product   *Product   `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+// TableName 指定表名
+func (WarehouseStock) TableName() string {
+	return "warehouse_stocks"
+}
+
+// StockTransfer 仓库间调拨记录
+type StockTransfer struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	FromWarehouseID uint   `gorm:"index;not null" json:"from_warehouse_id"`
+	ToWarehouseID   uint   `gorm:"index;not null" json:"to_warehouse_id"`
+	ProductID       uint   `gorm:"index;not null" json:"product_id"`
+	Quantity        int    `gorm:"not null" json:"quantity"`
+	OperatorID      uint   `gorm:"index" json:"operator_id"`
+	Remark          string `gorm:"size:255" json:"remark"`
+}
+
+// TableName 指定表名
+func (StockTransfer) TableName() string {
+	return "stock_transfers"
+}
+
+// Shipment 发货单模型（一个订单可能由多个仓库拆分发货）
+type Shipment struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	ShipmentNo  string     `gorm:"uniqueIndex;size:50;not null" json:"shipment_no"`
+	OrderID     uint       `gorm:"index;not null" json:"order_id"`
+	WarehouseID uint       `gorm:"index;not null" json:"warehouse_id"`
+	Status      string     `gorm:"size:20;default:'pending'" json:"status"` // pending, shipped, delivered, cancelled
+	ShippedAt   *time.Time `json:"shipped_at"`
+
+	// 关联
+	Warehouse *Warehouse     `gorm:"foreignKey:WarehouseID" json:"warehouse,omitempty"`
+	Items     []ShipmentItem `gorm:"foreignKey:ShipmentID" json:"items,omitempty"`
+}
+
+// TableName 指定表名
+func (Shipment) TableName() string {
+	return "shipments"
+}
+
+// ShipmentItem 发货单明细
+type ShipmentItem struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ShipmentID  uint `gorm:"index;not null" json:"shipment_id"`
+	OrderItemID uint `gorm:"index;not null" json:"order_item_id"`
+	ProductID   uint `gorm:"index;not null" json:"product_id"`
+	Quantity    int  `gorm:"not null" json:"quantity"`
+}
+
+// TableName 指定表名
+func (ShipmentItem) TableName() string {
+	return "shipment_items"
+}
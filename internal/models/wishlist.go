@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+)
+
+// Wishlist 用户的收藏夹，每个用户可有多个命名收藏夹
+type Wishlist struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID    uint   `gorm:"uniqueIndex:idx_wishlist_user_name;not null" json:"user_id"`
+	Name      string `gorm:"uniqueIndex:idx_wishlist_user_name;size:50;not null" json:"name"`
+	IsDefault bool   `gorm:"default:false" json:"is_default"` // 未指定收藏夹时加入默认收藏夹
+
+	ItemCount int `gorm:"-" json:"item_count"`
+
+	// 关联
+	Items []WishlistItem `gorm:"foreignKey:WishlistID" json:"items,omitempty"`
+}
+
+// TableName 指定表名
+func (Wishlist) TableName() string {
+	return "wishlists"
+}
+
+// WishlistItem 收藏夹中的商品
+// 同一收藏夹内商品与规格唯一（未选规格按 0 计），唯一索引在迁移中创建
+type WishlistItem struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"` // 收藏时间
+	UpdatedAt time.Time `json:"updated_at"`
+
+	WishlistID uint    `gorm:"not null" json:"wishlist_id"`
+	ProductID  uint    `gorm:"index;not null" json:"product_id"`
+	VariantID  *uint   `json:"variant_id"`                                      // 收藏时选择的规格，可为空
+	PriceAtAdd float64 `gorm:"type:decimal(10,2);not null" json:"price_at_add"` // 收藏时的价格，用于降价提醒
+
+	// 以下字段查询时计算
+	CurrentPrice float64 `gorm:"-" json:"current_price"`
+	PriceDrop    float64 `gorm:"-" json:"price_drop"`    // 比收藏时降低的金额
+	PriceDropped bool    `gorm:"-" json:"price_dropped"` // 是否已降价
+
+	// 关联
+	Product *Product        `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Variant *ProductVariant `gorm:"foreignKey:VariantID" json:"variant,omitempty"`
+}
+
+// TableName 指定表名
+func (WishlistItem) TableName() string {
+	return "wishlist_items"
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/shoppee/ecommerce/internal/config"
 	"github.com/shoppee/ecommerce/internal/handler"
 	"github.com/shoppee/ecommerce/internal/middleware"
+	"github.com/shoppee/ecommerce/internal/service"
 	"github.com/shoppee/ecommerce/internal/websocket"
 	"github.com/shoppee/ecommerce/pkg/response"
 )
@@ -33,8 +34,14 @@ func SetupRouter() *gin.Engine {
 		})
 	})
 
-	// 初始化WebSocket
+	// 本地存储的媒体文件
+	if mediaCfg := config.AppConfig.Media; mediaCfg.Storage == "" || mediaCfg.Storage == "local" {
+		r.Static(mediaCfg.BaseURL, mediaCfg.LocalDir)
+	}
+
+	// 初始化WebSocket（用户上线后补发离线通知）
 	websocket.InitWebSocket()
+	websocket.SetConnectHandler(service.NewNotificationService().DeliverPending)
 
 	// WebSocket连接（需要认证）
 	r.GET("/ws", middleware.AuthMiddleware(), websocket.HandleWebSocket)
@@ -56,12 +63,39 @@ func SetupRouter() *gin.Engine {
 
 		// 商品相关路由（部分公开）
 		productHandler := handler.NewProductHandler()
+		tagHandler := handler.NewTagHandler()
+		searchHandler := handler.NewSearchHandler()
 		products := api.Group("/products")
 		{
 			// 公开接口
-			products.GET("", productHandler.GetProductList)
-			products.GET("/search", productHandler.SearchProducts)
-			products.GET("/:id", productHandler.GetProductByID)
+			products.GET("", middleware.OptionalAuthMiddleware(), productHandler.GetProductList)
+			products.GET("/search", middleware.OptionalAuthMiddleware(), productHandler.SearchProducts)
+			products.GET("/suggest", searchHandler.Suggest)
+			products.GET("/compare", productHandler.CompareProducts)
+			products.GET("/by-slug/:slug", middleware.OptionalAuthMiddleware(), productHandler.GetProductBySlug)
+			products.GET("/:id", middleware.OptionalAuthMiddleware(), productHandler.GetProductByID)
+
+			// 商品规格（公开）
+			variantHandler := handler.NewVariantHandler()
+			products.GET("/:id/options", middleware.OptionalAuthMiddleware(), variantHandler.GetOptions)
+			products.GET("/:id/variants", middleware.OptionalAuthMiddleware(), variantHandler.GetVariants)
+
+			// 商品属性（公开）
+			attributeHandler := handler.NewAttributeHandler()
+			products.GET("/:id/attributes", middleware.OptionalAuthMiddleware(), attributeHandler.GetProductAttributes)
+
+			// 商品图片（公开）
+			mediaHandler := handler.NewMediaHandler()
+			products.GET("/:id/media", middleware.OptionalAuthMiddleware(), mediaHandler.GetProductMedia)
+
+			// 经常一起购买（公开）
+			recommendHandler := handler.NewRecommendHandler()
+			products.GET("/:id/related", middleware.OptionalAuthMiddleware(), recommendHandler.GetRelatedProducts)
+
+			// 到货通知订阅（需要认证）
+			subscriptionHandler := handler.NewStockSubscriptionHandler()
+			products.POST("/:id/restock-subscription", middleware.AuthMiddleware(), subscriptionHandler.Subscribe)
+			products.DELETE("/:id/restock-subscription", middleware.AuthMiddleware(), subscriptionHandler.Unsubscribe)
 
 			// 需要管理员权限
 			admin := products.Group("")
@@ -69,27 +103,156 @@ func SetupRouter() *gin.Engine {
 			{
 				admin.POST("", productHandler.CreateProduct)
 				admin.PUT("/:id", productHandler.UpdateProduct)
+				admin.PATCH("/:id", productHandler.PatchProduct)
 				admin.DELETE("/:id", productHandler.DeleteProduct)
 				admin.PATCH("/:id/status", productHandler.UpdateProductStatus)
+				admin.PUT("/:id/schedule", productHandler.ScheduleProduct)
 				admin.POST("/batch-stock", productHandler.BatchUpdateStock)
+				admin.POST("/import", productHandler.ImportProducts)
+				admin.GET("/export", productHandler.ExportProducts)
+				admin.POST("/:id/options", variantHandler.CreateOption)
+				admin.POST("/:id/variants", variantHandler.CreateVariant)
+				admin.PUT("/:id/variants/:variant_id", variantHandler.UpdateVariant)
+				admin.PUT("/:id/attributes", attributeHandler.SetProductAttributes)
+				admin.PUT("/:id/media", mediaHandler.SetProductMedia)
+				admin.PUT("/:id/tags", tagHandler.SetProductTags)
 			}
 		}
 
+		// 媒体上传（需要认证）
+		mediaHandler := handler.NewMediaHandler()
+		media := api.Group("/media")
+		{
+			media.GET("/:id", mediaHandler.GetMedia)
+			media.POST("", middleware.AuthMiddleware(), mediaHandler.Upload)
+			media.DELETE("/:id", middleware.AuthMiddleware(), mediaHandler.DeleteMedia)
+		}
+
 		// 分类相关路由（部分公开）
 		categoryHandler := handler.NewCategoryHandler()
 		categories := api.Group("/categories")
 		{
 			// 公开接口
 			categories.GET("", categoryHandler.GetCategoryList)
+			categories.GET("/tree", categoryHandler.GetCategoryTree)
+			categories.GET("/by-slug/:slug", categoryHandler.GetCategoryBySlug)
 			categories.GET("/:id", categoryHandler.GetCategory)
+			categories.GET("/:id/breadcrumb", categoryHandler.GetBreadcrumb)
+
+			// 分类属性（公开）
+			attributeHandler := handler.NewAttributeHandler()
+			categories.GET("/:id/attributes", attributeHandler.GetCategoryAttributes)
 
 			// 需要管理员权限
 			admin := categories.Group("")
 			admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
 			{
 				admin.POST("", categoryHandler.CreateCategory)
+				admin.PUT("/reorder", categoryHandler.ReorderCategories)
 				admin.PUT("/:id", categoryHandler.UpdateCategory)
+				admin.PUT("/:id/move", categoryHandler.MoveCategory)
 				admin.DELETE("/:id", categoryHandler.DeleteCategory)
+				admin.POST("/:id/attributes", attributeHandler.CreateAttribute)
+				admin.PUT("/:id/attributes/:attribute_id", attributeHandler.UpdateAttribute)
+				admin.DELETE("/:id/attributes/:attribute_id", attributeHandler.DeleteAttribute)
+			}
+		}
+
+		// 标签相关路由（部分公开）
+		tags := api.Group("/tags")
+		{
+			tags.GET("", tagHandler.GetTags)
+
+			// 需要管理员权限
+			admin := tags.Group("")
+			admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			{
+				admin.POST("", tagHandler.CreateTag)
+				admin.PUT("/:id", tagHandler.UpdateTag)
+				admin.DELETE("/:id", tagHandler.DeleteTag)
+			}
+		}
+
+		// 合集相关路由（部分公开），合集内商品通过 GET /products?collection=编码 查询
+		collectionHandler := handler.NewCollectionHandler()
+		collections := api.Group("/collections")
+		{
+			collections.GET("", collectionHandler.GetCollections)
+			collections.GET("/:code", collectionHandler.GetCollection)
+
+			// 需要管理员权限
+			admin := collections.Group("")
+			admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			{
+				admin.GET("/admin/all", collectionHandler.AdminGetCollections)
+				admin.POST("", collectionHandler.CreateCollection)
+				admin.PUT("/:id", collectionHandler.UpdateCollection)
+				admin.DELETE("/:id", collectionHandler.DeleteCollection)
+				admin.PUT("/:id/products", collectionHandler.SetCollectionProducts)
+			}
+		}
+
+		// 个人中心相关路由（需要认证）
+		me := api.Group("/me")
+		me.Use(middleware.AuthMiddleware())
+		{
+			me.GET("/restock-subscriptions", handler.NewStockSubscriptionHandler().GetMySubscriptions)
+
+			recentlyViewedHandler := handler.NewRecentlyViewedHandler()
+			me.GET("/recently-viewed", recentlyViewedHandler.GetRecentlyViewed)
+			me.DELETE("/recently-viewed", recentlyViewedHandler.ClearRecentlyViewed)
+		}
+
+		// 站内通知相关路由（需要认证）
+		notificationHandler := handler.NewNotificationHandler()
+		notifications := api.Group("/notifications")
+		notifications.Use(middleware.AuthMiddleware())
+		{
+			notifications.GET("", notificationHandler.GetNotifications)
+			notifications.PATCH("/:id/read", notificationHandler.MarkRead)
+		}
+
+		// 仓库相关路由（需要管理员权限）
+		warehouseHandler := handler.NewWarehouseHandler()
+		warehouses := api.Group("/warehouses")
+		warehouses.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+		{
+			warehouses.GET("", warehouseHandler.GetWarehouseList)
+			warehouses.POST("", warehouseHandler.CreateWarehouse)
+			warehouses.PUT("/:id", warehouseHandler.UpdateWarehouse)
+			warehouses.GET("/:id/stocks", warehouseHandler.GetWarehouseStocks)
+			warehouses.PUT("/:id/stocks", warehouseHandler.SetWarehouseStock)
+			warehouses.POST("/transfers", warehouseHandler.TransferStock)
+			warehouses.GET("/products/:id/stocks", warehouseHandler.GetProductStocks)
+		}
+
+		// 库存流水相关路由（需要管理员权限）
+		inventoryHandler := handler.NewInventoryHandler()
+		inventory := api.Group("/inventory")
+		inventory.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+		{
+			inventory.GET("/products/:id/movements", inventoryHandler.GetStockMovements)
+			inventory.POST("/products/:id/adjustments", inventoryHandler.AdjustStock)
+			inventory.GET("/products/:id/reconciliation", inventoryHandler.ReconcileStock)
+			inventory.GET("/reconciliation", inventoryHandler.ReconcileAll)
+		}
+
+		// 搜索相关路由
+		search := api.Group("/search")
+		{
+			// 搜索结果点击上报（公开，登录用户用于下单归因）
+			search.POST("/logs/:id/click", middleware.OptionalAuthMiddleware(), searchHandler.RecordClick)
+
+			// 搜索管理（需要管理员权限）
+			searchAdmin := search.Group("")
+			searchAdmin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			{
+				searchAdmin.GET("/synonyms", searchHandler.GetSynonyms)
+				searchAdmin.POST("/synonyms", searchHandler.CreateSynonym)
+				searchAdmin.DELETE("/synonyms/:id", searchHandler.DeleteSynonym)
+				searchAdmin.POST("/reindex", searchHandler.ReindexProducts)
+				searchAdmin.GET("/reports/queries", searchHandler.GetQueryReport)
+				searchAdmin.GET("/reports/zero-results", searchHandler.GetZeroResultReport)
 			}
 		}
 
@@ -99,13 +262,28 @@ func SetupRouter() *gin.Engine {
 		cart.Use(middleware.AuthMiddleware())
 		{
 			cart.GET("", cartHandler.GetCart)
-			cart.POST("/items", cartHandler.AddToCart)
+			cart.POST("/items", cartHandler.AddCartItem)
 			cart.PUT("/items/:id", cartHandler.UpdateCartItem)
-			cart.DELETE("/items/:id", cartHandler.RemoveFromCart)
+			cart.DELETE("/items/:id", cartHandler.DeleteCartItem)
 			cart.DELETE("/clear", cartHandler.ClearCart)
 			cart.PATCH("/items/:id/select", cartHandler.SelectCartItem)
 		}
 
+		// 收藏夹相关路由（需要认证）
+		wishlistHandler := handler.NewWishlistHandler()
+		wishlists := api.Group("/wishlists")
+		wishlists.Use(middleware.AuthMiddleware())
+		{
+			wishlists.GET("", wishlistHandler.GetWishlists)
+			wishlists.POST("", wishlistHandler.CreateWishlist)
+			wishlists.POST("/items", wishlistHandler.AddItem)
+			wishlists.GET("/:id", wishlistHandler.GetWishlist)
+			wishlists.PUT("/:id", wishlistHandler.RenameWishlist)
+			wishlists.DELETE("/:id", wishlistHandler.DeleteWishlist)
+			wishlists.DELETE("/:id/items/:item_id", wishlistHandler.RemoveItem)
+			wishlists.POST("/:id/items/:item_id/cart", wishlistHandler.MoveToCart)
+		}
+
 		// 收货地址相关路由（需要认证）
 		addressHandler := handler.NewAddressHandler()
 		addresses := api.Group("/addresses")
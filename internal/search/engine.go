@@ -0,0 +1,73 @@
+package search
+
+import (
+	"context"
+	"math"
+
+	"github.com/shoppee/ecommerce/pkg/tokenizer"
+)
+
+// Engine 商品搜索引擎
+// 实现只负责检索与维护索引，同义词扩展、结果加载与高亮由调用方完成
+type Engine interface {
+	// Name 引擎名称
+	Name() string
+	// Search 检索商品，按得分从高到低返回命中的商品ID
+	Search(ctx context.Context, q Query) (*Result, error)
+	// Index 写入或覆盖商品文档（只应写入可被搜索到的上架商品）
+	Index(ctx context.Context, docs []Document) error
+	// Delete 从索引中移除商品（下架、删除）
+	Delete(ctx context.Context, ids []uint) error
+}
+
+// Document 商品索引文档
+type Document struct {
+	ID          uint    `json:"id"`
+	Name        string  `json:"name"`
+	SKU         string  `json:"sku"`
+	Description string  `json:"description"`
+	CategoryID  uint    `json:"category_id"`
+	Price       float64 `json:"price"`
+	SaleCount   int     `json:"sale_count"`
+}
+
+// Query 检索条件
+type Query struct {
+	Keyword string     // 原始关键词
+	Terms   [][]string // 按空白拆分并做同义词扩展后的检索词：同一词的各写法任一命中即可，不同词须同时命中
+	Offset  int
+	Limit   int
+}
+
+// Empty 检索词中是否没有任何可查询的内容
+func (q Query) Empty() bool {
+	for _, variants := range q.Terms {
+		for _, v := range variants {
+			if len(tokenizer.QueryTokens(v)) > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Hit 单个命中结果
+type Hit struct {
+	ID    uint
+	Score float64
+}
+
+// Result 检索结果
+type Result struct {
+	Hits  []Hit
+	Total int64
+	Fuzzy bool // 是否由拼写容错匹配
+}
+
+// salesBoost 销量加权系数：得分 = 相关度 × (1 + weight × ln(1 + 销量))
+func salesBoost(weight float64, saleCount int) float64 {
+	if saleCount < 0 {
+		saleCount = 0
+	}
+	return 1 + weight*math.Log1p(float64(saleCount))
+}
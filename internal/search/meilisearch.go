@@ -0,0 +1,126 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MeilisearchEngine 外部搜索引擎 Meilisearch 的适配器
+// 分词、拼写容错与排序由 Meilisearch 完成（需在索引设置中把 sale_count:desc 加入排序规则），
+// 同义词使用 Meilisearch 自身的配置；写入为异步任务，接口返回时任务已入队
+type MeilisearchEngine struct {
+	baseURL string
+	apiKey  string
+	index   string
+	client  *http.Client
+}
+
+// NewMeilisearchEngine 创建 Meilisearch 适配器
+func NewMeilisearchEngine(baseURL, apiKey, index string) *MeilisearchEngine {
+	return &MeilisearchEngine{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		index:   index,
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Name 引擎名称
+func (e *MeilisearchEngine) Name() string {
+	return "meilisearch"
+}
+
+// meiliSearchRequest 检索请求
+type meiliSearchRequest struct {
+	Q                    string   `json:"q"`
+	Offset               int      `json:"offset"`
+	Limit                int      `json:"limit"`
+	AttributesToRetrieve []string `json:"attributesToRetrieve"`
+	ShowRankingScore     bool     `json:"showRankingScore"`
+}
+
+// meiliSearchResponse 检索响应
+type meiliSearchResponse struct {
+	Hits []struct {
+		ID           uint    `json:"id"`
+		RankingScore float64 `json:"_rankingScore"`
+	} `json:"hits"`
+	EstimatedTotalHits int64 `json:"estimatedTotalHits"`
+}
+
+// Search 检索商品
+func (e *MeilisearchEngine) Search(ctx context.Context, q Query) (*Result, error) {
+	var resp meiliSearchResponse
+	err := e.do(ctx, http.MethodPost, "/search", meiliSearchRequest{
+		Q:                    strings.TrimSpace(q.Keyword),
+		Offset:               q.Offset,
+		Limit:                q.Limit,
+		AttributesToRetrieve: []string{"id"},
+		ShowRankingScore:     true,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Hits: make([]Hit, 0, len(resp.Hits)), Total: resp.EstimatedTotalHits}
+	for _, h := range resp.Hits {
+		result.Hits = append(result.Hits, Hit{ID: h.ID, Score: h.RankingScore})
+	}
+	return result, nil
+}
+
+// Index 写入或覆盖文档
+func (e *MeilisearchEngine) Index(ctx context.Context, docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	return e.do(ctx, http.MethodPost, "/documents?primaryKey=id", docs, nil)
+}
+
+// Delete 移除文档
+func (e *MeilisearchEngine) Delete(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return e.do(ctx, http.MethodPost, "/documents/delete-batch", ids, nil)
+}
+
+// do 调用索引下的接口
+func (e *MeilisearchEngine) do(ctx context.Context, method, path string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	endpoint := e.baseURL + "/indexes/" + url.PathEscape(e.index) + path
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求搜索引擎失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("搜索引擎返回错误: %d %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,93 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMeiliStandIn 启动模拟 Meilisearch 接口的本地服务，检索由内存引擎完成
+func newMeiliStandIn(t *testing.T, index, apiKey string) (*httptest.Server, *MemoryEngine) {
+	engine := NewMemoryEngine(0.1)
+	prefix := "/indexes/" + index
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(prefix+"/documents", func(w http.ResponseWriter, r *http.Request) {
+		var docs []Document
+		if err := json.NewDecoder(r.Body).Decode(&docs); err != nil || r.URL.Query().Get("primaryKey") != "id" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		engine.Index(r.Context(), docs)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc(prefix+"/documents/delete-batch", func(w http.ResponseWriter, r *http.Request) {
+		var ids []uint
+		if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		engine.Delete(r.Context(), ids)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc(prefix+"/search", func(w http.ResponseWriter, r *http.Request) {
+		var req meiliSearchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var terms [][]string
+		for _, f := range strings.Fields(strings.ToLower(req.Q)) {
+			terms = append(terms, []string{f})
+		}
+		res, _ := engine.Search(r.Context(), Query{Terms: terms, Offset: req.Offset, Limit: req.Limit})
+
+		hits := make([]map[string]interface{}, 0, len(res.Hits))
+		for _, h := range res.Hits {
+			hits = append(hits, map[string]interface{}{"id": h.ID, "_rankingScore": h.Score})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"hits": hits, "estimatedTotalHits": res.Total})
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+apiKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":"invalid_api_key"}`))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, engine
+}
+
+// TestMeilisearchEngine 测试 Meilisearch 适配器的写入、检索与删除
+func TestMeilisearchEngine(t *testing.T) {
+	ctx := context.Background()
+	server, standIn := newMeiliStandIn(t, "products", "secret")
+	engine := NewMeilisearchEngine(server.URL+"/", "secret", "products")
+
+	require.NoError(t, engine.Index(ctx, testDocs))
+	assert.Equal(t, 3, standIn.Len())
+
+	res, err := engine.Search(ctx, Query{Keyword: "耳机", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), res.Total)
+	require.Len(t, res.Hits, 2)
+	assert.Greater(t, res.Hits[0].Score, 0.0)
+
+	require.NoError(t, engine.Delete(ctx, []uint{2}))
+	res, err = engine.Search(ctx, Query{Keyword: "耳机", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, res.Hits, 1)
+	assert.Equal(t, uint(1), res.Hits[0].ID)
+
+	_, err = NewMeilisearchEngine(server.URL, "wrong", "products").Search(ctx, Query{Keyword: "耳机"})
+	assert.ErrorContains(t, err, "401")
+}
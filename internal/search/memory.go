@@ -0,0 +1,156 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/shoppee/ecommerce/pkg/tokenizer"
+)
+
+// 内存引擎中名称（含SKU）与描述命中的权重
+const (
+	memoryNameWeight = 1.0
+	memoryBodyWeight = 0.4
+)
+
+// MemoryEngine 内存搜索引擎，分词规则与 Postgres 引擎一致，用于测试及单机开发
+type MemoryEngine struct {
+	mu          sync.RWMutex
+	docs        map[uint]memoryDoc
+	salesWeight float64
+}
+
+// memoryDoc 已分词的内存文档
+type memoryDoc struct {
+	Document
+	name map[string]bool
+	body map[string]bool
+}
+
+// NewMemoryEngine 创建内存搜索引擎
+func NewMemoryEngine(salesWeight float64) *MemoryEngine {
+	return &MemoryEngine{
+		docs:        make(map[uint]memoryDoc),
+		salesWeight: salesWeight,
+	}
+}
+
+// Name 引擎名称
+func (e *MemoryEngine) Name() string {
+	return "memory"
+}
+
+// Index 写入或覆盖文档
+func (e *MemoryEngine) Index(ctx context.Context, docs []Document) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, d := range docs {
+		e.docs[d.ID] = memoryDoc{
+			Document: d,
+			name:     tokenSet(d.Name + " " + d.SKU),
+			body:     tokenSet(d.Description),
+		}
+	}
+	return nil
+}
+
+// Delete 移除文档
+func (e *MemoryEngine) Delete(ctx context.Context, ids []uint) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, id := range ids {
+		delete(e.docs, id)
+	}
+	return nil
+}
+
+// Len 当前索引的文档数
+func (e *MemoryEngine) Len() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return len(e.docs)
+}
+
+// Search 检索：每个检索词须在名称或描述中命中，名称命中得分更高
+func (e *MemoryEngine) Search(ctx context.Context, q Query) (*Result, error) {
+	e.mu.RLock()
+	var hits []Hit
+	for _, d := range e.docs {
+		score, ok := d.match(q.Terms)
+		if !ok {
+			continue
+		}
+		hits = append(hits, Hit{ID: d.ID, Score: score * salesBoost(e.salesWeight, d.SaleCount)})
+	}
+	e.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID > hits[j].ID
+	})
+
+	return &Result{Hits: paginate(hits, q.Offset, q.Limit), Total: int64(len(hits))}, nil
+}
+
+// match 计算文档对检索词的相关度，任一检索词未命中时返回 false
+func (d memoryDoc) match(terms [][]string) (float64, bool) {
+	if len(terms) == 0 {
+		return 0, false
+	}
+
+	var score float64
+	for _, variants := range terms {
+		best := 0.0
+		for _, v := range variants {
+			tokens := tokenizer.QueryTokens(v)
+			if len(tokens) == 0 {
+				continue
+			}
+			switch {
+			case containsAll(d.name, tokens):
+				best = memoryNameWeight
+			case containsAll(d.body, tokens) && best < memoryBodyWeight:
+				best = memoryBodyWeight
+			}
+		}
+		if best == 0 {
+			return 0, false
+		}
+		score += best
+	}
+	return score, true
+}
+
+// tokenSet 分词并去重
+func tokenSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, t := range tokenizer.Tokenize(text) {
+		set[t] = true
+	}
+	return set
+}
+
+// containsAll 是否包含全部查询词
+func containsAll(set map[string]bool, tokens []string) bool {
+	for _, t := range tokens {
+		if !set[t] {
+			return false
+		}
+	}
+	return true
+}
+
+// paginate 截取分页区间
+func paginate(hits []Hit, offset, limit int) []Hit {
+	if offset >= len(hits) {
+		return []Hit{}
+	}
+	end := len(hits)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return hits[offset:end]
+}
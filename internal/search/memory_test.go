@@ -0,0 +1,54 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDocs 测试用商品文档
+var testDocs = []Document{
+	{ID: 1, Name: "无线蓝牙耳机", Description: "主动降噪，续航30小时", SaleCount: 10},
+	{ID: 2, Name: "头戴式耳机", Description: "蓝牙5.3，支持有线连接", SaleCount: 500},
+	{ID: 3, Name: "Apple iPhone 15", SKU: "IP15-128", Description: "A16 芯片", SaleCount: 100},
+}
+
+// TestMemoryEngineSearch 测试内存引擎的检索、排序与分页
+func TestMemoryEngineSearch(t *testing.T) {
+	ctx := context.Background()
+	engine := NewMemoryEngine(0.1)
+	require.NoError(t, engine.Index(ctx, testDocs))
+
+	// 名称命中优先于描述命中
+	res, err := engine.Search(ctx, Query{Terms: [][]string{{"蓝牙"}}, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), res.Total)
+	assert.Equal(t, uint(1), res.Hits[0].ID)
+
+	// 同义词任一命中即可，不同检索词须同时命中
+	res, err = engine.Search(ctx, Query{Terms: [][]string{{"手机", "iphone"}, {"ip15"}}, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, res.Hits, 1)
+	assert.Equal(t, uint(3), res.Hits[0].ID)
+
+	res, err = engine.Search(ctx, Query{Terms: [][]string{{"耳机"}}, Offset: 1, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), res.Total)
+	assert.Len(t, res.Hits, 1)
+
+	require.NoError(t, engine.Delete(ctx, []uint{1, 2}))
+	res, err = engine.Search(ctx, Query{Terms: [][]string{{"耳机"}}, Limit: 10})
+	require.NoError(t, err)
+	assert.Zero(t, res.Total)
+	assert.Equal(t, 1, engine.Len())
+}
+
+// TestBuildTSQuery 测试同义词扩展后的查询表达式
+func TestBuildTSQuery(t *testing.T) {
+	terms := [][]string{{"手机", "mobile"}, {"5g"}}
+	assert.Equal(t, "((手机) | (mobile)) & ((5g))", buildTSQuery(terms))
+	assert.Equal(t, "", buildTSQuery([][]string{{"!!"}}))
+	assert.True(t, Query{Terms: [][]string{{"!!"}}}.Empty())
+}
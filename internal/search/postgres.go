@@ -0,0 +1,145 @@
+package search
+
+import (
+	"context"
+	"strings"
+
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"github.com/shoppee/ecommerce/pkg/tokenizer"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PostgresEngine 基于 products 表全文检索列（search_vector）的搜索引擎
+// 检索分词由 Product 的模型钩子在写入时同步生成，Index 只用于重建分词
+type PostgresEngine struct {
+	db             *gorm.DB
+	salesWeight    float64
+	fuzzyThreshold float64
+}
+
+// NewPostgresEngine 创建 Postgres 搜索引擎
+// fuzzyThreshold 为拼写容错的最低相似度，<= 0 时不做拼写容错
+func NewPostgresEngine(db *gorm.DB, salesWeight, fuzzyThreshold float64) *PostgresEngine {
+	return &PostgresEngine{
+		db:             db,
+		salesWeight:    salesWeight,
+		fuzzyThreshold: fuzzyThreshold,
+	}
+}
+
+// Name 引擎名称
+func (e *PostgresEngine) Name() string {
+	return "postgres"
+}
+
+// Search 全文检索，按相关度结合销量排序；无结果时按名称相似度做拼写容错
+func (e *PostgresEngine) Search(ctx context.Context, q Query) (*Result, error) {
+	tsquery := buildTSQuery(q.Terms)
+	if tsquery == "" {
+		return &Result{Hits: []Hit{}}, nil
+	}
+
+	query := e.db.WithContext(ctx).Model(&models.Product{}).
+		Where("products.status = ?", "active").
+		Where("products.search_vector @@ to_tsquery('simple', ?)", tsquery)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return e.fuzzySearch(ctx, q)
+	}
+
+	var hits []Hit
+	if err := query.
+		Select("products.id, ts_rank(products.search_vector, to_tsquery('simple', ?)) * (1 + ? * ln(1 + products.sale_count)) AS score", tsquery, e.salesWeight).
+		Order("score DESC, products.id DESC").
+		Offset(q.Offset).Limit(q.Limit).
+		Scan(&hits).Error; err != nil {
+		return nil, err
+	}
+	return &Result{Hits: hits, Total: total}, nil
+}
+
+// fuzzySearch 拼写容错：按商品名称与关键词的三元组相似度匹配（需 pg_trgm 扩展）
+func (e *PostgresEngine) fuzzySearch(ctx context.Context, q Query) (*Result, error) {
+	result := &Result{Hits: []Hit{}, Fuzzy: true}
+	if e.fuzzyThreshold <= 0 {
+		return result, nil
+	}
+
+	keyword := strings.ToLower(strings.TrimSpace(q.Keyword))
+	err := e.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 阈值只在本事务内生效，<% 运算符可以使用名称上的三元组索引
+		if err := tx.Exec("SELECT set_config('pg_trgm.word_similarity_threshold', ?, true)", e.fuzzyThreshold).Error; err != nil {
+			return err
+		}
+
+		query := tx.Model(&models.Product{}).
+			Where("products.status = ?", "active").
+			Where("? <% lower(products.name)", keyword)
+		if err := query.Count(&result.Total).Error; err != nil {
+			return err
+		}
+		if result.Total == 0 {
+			return nil
+		}
+
+		return query.
+			Select("products.id, word_similarity(?, lower(products.name)) * (1 + ? * ln(1 + products.sale_count)) AS score", keyword, e.salesWeight).
+			Order("score DESC, products.id DESC").
+			Offset(q.Offset).Limit(q.Limit).
+			Scan(&result.Hits).Error
+	})
+	if err != nil {
+		// pg_trgm 不可用时不做拼写容错
+		logger.Warn("拼写容错搜索失败", zap.String("keyword", keyword), zap.Error(err))
+		return &Result{Hits: []Hit{}, Fuzzy: true}, nil
+	}
+	return result, nil
+}
+
+// Index 按文档内容重建商品的检索分词
+func (e *PostgresEngine) Index(ctx context.Context, docs []Document) error {
+	for _, d := range docs {
+		p := models.Product{Name: d.Name, SKU: d.SKU, Description: d.Description}
+		p.BuildSearchText()
+		if err := e.db.WithContext(ctx).Model(&models.Product{}).Where("id = ?", d.ID).
+			UpdateColumns(map[string]interface{}{
+				"search_name": p.SearchName,
+				"search_body": p.SearchBody,
+			}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete 检索时已按商品状态及软删除过滤，无需处理
+func (e *PostgresEngine) Delete(ctx context.Context, ids []uint) error {
+	return nil
+}
+
+// buildTSQuery 生成 to_tsquery 表达式：同一词的各写法之间为 OR，不同词之间为 AND，
+// 每种写法切分出的查询词须全部命中
+func buildTSQuery(terms [][]string) string {
+	var groups []string
+	for _, variants := range terms {
+		var alternatives []string
+		for _, v := range variants {
+			tokens := tokenizer.QueryTokens(v)
+			if len(tokens) == 0 {
+				continue
+			}
+			alternatives = append(alternatives, "("+strings.Join(tokens, " & ")+")")
+		}
+		if len(alternatives) == 0 {
+			continue
+		}
+		groups = append(groups, "("+strings.Join(alternatives, " | ")+")")
+	}
+	return strings.Join(groups, " & ")
+}
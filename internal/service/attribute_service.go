@@ -0,0 +1,269 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// AttributeService 商品属性服务
+type AttributeService struct{}
+
+// NewAttributeService 创建商品属性服务实例
+func NewAttributeService() *AttributeService {
+	return &AttributeService{}
+}
+
+// AttributeRequest 创建/更新分类属性请求
+type AttributeRequest struct {
+	Code       string `json:"code" binding:"required,max=50"`
+	Name       string `json:"name" binding:"required,max=100"`
+	Type       string `json:"type" binding:"required,oneof=string number boolean"`
+	Unit       string `json:"unit" binding:"max=20"`
+	Filterable *bool  `json:"filterable"` // 默认 true
+	Sort       int    `json:"sort"`
+}
+
+// ProductAttributesRequest 设置商品属性请求，键为属性 code
+type ProductAttributesRequest struct {
+	Attributes map[string]interface{} `json:"attributes" binding:"required"`
+}
+
+// GetCategoryAttributes 获取分类下的属性定义
+func (s *AttributeService) GetCategoryAttributes(categoryID uint) ([]models.Attribute, error) {
+	var attributes []models.Attribute
+	if err := database.DB.Where("category_id = ?", categoryID).
+		Order("sort ASC, id ASC").
+		Find(&attributes).Error; err != nil {
+		return nil, err
+	}
+	return attributes, nil
+}
+
+// CreateAttribute 为分类创建属性
+func (s *AttributeService) CreateAttribute(categoryID uint, req *AttributeRequest) (*models.Attribute, error) {
+	var category models.Category
+	if err := database.DB.First(&category, categoryID).Error; err != nil {
+		return nil, errors.New("分类不存在")
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.Attribute{}).
+		Where("category_id = ? AND code = ?", categoryID, req.Code).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("该分类下已存在相同编码的属性")
+	}
+
+	attribute := &models.Attribute{
+		CategoryID: categoryID,
+		Code:       req.Code,
+		Name:       req.Name,
+		Type:       req.Type,
+		Unit:       req.Unit,
+		Filterable: req.Filterable == nil || *req.Filterable,
+		Sort:       req.Sort,
+	}
+	if err := database.DB.Create(attribute).Error; err != nil {
+		return nil, err
+	}
+
+	logger.Info("创建分类属性", zap.Uint("category_id", categoryID), zap.String("code", attribute.Code))
+	return attribute, nil
+}
+
+// UpdateAttribute 更新分类属性（已有商品取值时不允许修改类型）
+func (s *AttributeService) UpdateAttribute(categoryID, attributeID uint, req *AttributeRequest) (*models.Attribute, error) {
+	var attribute models.Attribute
+	if err := database.DB.Where("id = ? AND category_id = ?", attributeID, categoryID).First(&attribute).Error; err != nil {
+		return nil, errors.New("属性不存在")
+	}
+
+	if req.Type != attribute.Type {
+		var used int64
+		if err := database.DB.Model(&models.ProductAttributeValue{}).
+			Where("attribute_id = ?", attributeID).
+			Count(&used).Error; err != nil {
+			return nil, err
+		}
+		if used > 0 {
+			return nil, errors.New("属性已被商品使用，不能修改类型")
+		}
+	}
+
+	updates := map[string]interface{}{
+		"code": req.Code,
+		"name": req.Name,
+		"type": req.Type,
+		"unit": req.Unit,
+		"sort": req.Sort,
+	}
+	if req.Filterable != nil {
+		updates["filterable"] = *req.Filterable
+	}
+	if err := database.DB.Model(&attribute).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	invalidateAttributeProducts(attribute.ID)
+
+	return &attribute, nil
+}
+
+// DeleteAttribute 删除分类属性及商品上的取值
+func (s *AttributeService) DeleteAttribute(categoryID, attributeID uint) error {
+	var productIDs []uint
+	err := database.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND category_id = ?", attributeID, categoryID).Delete(&models.Attribute{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("属性不存在")
+		}
+		if err := tx.Model(&models.ProductAttributeValue{}).Where("attribute_id = ?", attributeID).
+			Pluck("product_id", &productIDs).Error; err != nil {
+			return err
+		}
+		return tx.Where("attribute_id = ?", attributeID).Delete(&models.ProductAttributeValue{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	invalidateProductCache(productIDs...)
+	return nil
+}
+
+// invalidateAttributeProducts 属性定义变更后清除使用该属性的商品详情缓存
+func invalidateAttributeProducts(attributeID uint) {
+	var productIDs []uint
+	if err := database.DB.Model(&models.ProductAttributeValue{}).Where("attribute_id = ?", attributeID).
+		Pluck("product_id", &productIDs).Error; err != nil {
+		logger.Warn("查询属性商品失败，商品缓存未清除", zap.Uint("attribute_id", attributeID), zap.Error(err))
+		return
+	}
+	invalidateProductCache(productIDs...)
+}
+
+// GetProductAttributes 获取商品的属性值
+func (s *AttributeService) GetProductAttributes(productID uint) ([]models.ProductAttributeValue, error) {
+	var values []models.ProductAttributeValue
+	if err := database.DB.Preload("Attribute").
+		Joins("JOIN attributes ON attributes.id = product_attribute_values.attribute_id").
+		Where("product_attribute_values.product_id = ?", productID).
+		Order("attributes.sort ASC, attributes.id ASC").
+		Find(&values).Error; err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// SetProductAttributes 按商品所属分类的属性定义校验并整体替换商品属性值
+func (s *AttributeService) SetProductAttributes(productID uint, req *ProductAttributesRequest) ([]models.ProductAttributeValue, error) {
+	var product models.Product
+	if err := database.DB.First(&product, productID).Error; err != nil {
+		return nil, errors.New("商品不存在")
+	}
+
+	attributes, err := s.GetCategoryAttributes(product.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]models.Attribute, len(attributes))
+	for _, attr := range attributes {
+		byCode[attr.Code] = attr
+	}
+
+	values := make([]models.ProductAttributeValue, 0, len(req.Attributes))
+	for code, raw := range req.Attributes {
+		attr, ok := byCode[code]
+		if !ok {
+			return nil, fmt.Errorf("分类未定义属性 %s", code)
+		}
+		if raw == nil {
+			continue
+		}
+		value, err := parseAttributeValue(attr, raw)
+		if err != nil {
+			return nil, err
+		}
+		value.ProductID = productID
+		values = append(values, *value)
+	}
+
+	err = database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).Delete(&models.ProductAttributeValue{}).Error; err != nil {
+			return err
+		}
+		if len(values) == 0 {
+			return nil
+		}
+		return tx.Create(&values).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateProductCache(productID)
+	return values, nil
+}
+
+// parseAttributeValue 按属性类型解析取值
+func parseAttributeValue(attr models.Attribute, raw interface{}) (*models.ProductAttributeValue, error) {
+	value := &models.ProductAttributeValue{AttributeID: attr.ID}
+
+	switch attr.Type {
+	case models.AttributeTypeNumber:
+		var n float64
+		switch v := raw.(type) {
+		case float64:
+			n = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("属性 %s 须为数值", attr.Name)
+			}
+			n = parsed
+		default:
+			return nil, fmt.Errorf("属性 %s 须为数值", attr.Name)
+		}
+		value.NumberValue = &n
+		value.Value = strconv.FormatFloat(n, 'f', -1, 64)
+	case models.AttributeTypeBoolean:
+		var b bool
+		switch v := raw.(type) {
+		case bool:
+			b = v
+		case string:
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("属性 %s 须为布尔值", attr.Name)
+			}
+			b = parsed
+		default:
+			return nil, fmt.Errorf("属性 %s 须为布尔值", attr.Name)
+		}
+		value.Value = strconv.FormatBool(b)
+	default:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("属性 %s 须为文本", attr.Name)
+		}
+		s = strings.TrimSpace(s)
+		if s == "" || len(s) > 255 {
+			return nil, fmt.Errorf("属性 %s 的值长度须在1到255之间", attr.Name)
+		}
+		value.Value = s
+	}
+
+	return value, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseAttributeValue 测试按属性类型解析商品属性值
+func TestParseAttributeValue(t *testing.T) {
+	size := models.Attribute{ID: 1, Name: "屏幕尺寸", Type: models.AttributeTypeNumber}
+	v, err := parseAttributeValue(size, 6.1)
+	assert.NoError(t, err)
+	assert.Equal(t, "6.1", v.Value)
+	assert.Equal(t, 6.1, *v.NumberValue)
+
+	v, err = parseAttributeValue(size, "6.7")
+	assert.NoError(t, err)
+	assert.Equal(t, 6.7, *v.NumberValue)
+
+	_, err = parseAttributeValue(size, "大屏")
+	assert.Error(t, err)
+
+	nfc := models.Attribute{ID: 2, Name: "NFC", Type: models.AttributeTypeBoolean}
+	v, err = parseAttributeValue(nfc, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "true", v.Value)
+
+	brand := models.Attribute{ID: 3, Name: "品牌", Type: models.AttributeTypeString}
+	v, err = parseAttributeValue(brand, " Apple ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Apple", v.Value)
+
+	_, err = parseAttributeValue(brand, 123.0)
+	assert.Error(t, err)
+}
+
+// TestParseAttributeFilter 测试属性筛选参数解析
+func TestParseAttributeFilter(t *testing.T) {
+	f, err := parseAttributeFilter("brand", models.AttributeTypeString, "Apple, Huawei,")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Apple", "Huawei"}, f.Values)
+
+	f, err = parseAttributeFilter("screen_size", models.AttributeTypeNumber, "6-6.8")
+	assert.NoError(t, err)
+	assert.Equal(t, 6.0, *f.Min)
+	assert.Equal(t, 6.8, *f.Max)
+
+	f, err = parseAttributeFilter("screen_size", models.AttributeTypeNumber, "6-")
+	assert.NoError(t, err)
+	assert.Equal(t, 6.0, *f.Min)
+	assert.Nil(t, f.Max)
+
+	f, err = parseAttributeFilter("screen_size", models.AttributeTypeNumber, "6.1")
+	assert.NoError(t, err)
+	assert.Equal(t, *f.Min, *f.Max, "单个数值视为精确匹配")
+
+	_, err = parseAttributeFilter("screen_size", models.AttributeTypeNumber, "-")
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+
+	_, err = parseAttributeFilter("brand", models.AttributeTypeString, " , ")
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
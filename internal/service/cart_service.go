@@ -17,7 +17,7 @@ func NewCartService() *CartService {
 // GetCart 获取用户购物车
 func (s *CartService) GetCart(userID uint) (*models.Cart, error) {
 	var cart models.Cart
-	err := database.DB.Preload("Items.Product").
+	err := database.DB.Preload("CartItems.Product").Preload("CartItems.Variant").
 		Where("user_id = ?", userID).
 		First(&cart).Error
 	
@@ -36,15 +36,22 @@ func (s *CartService) GetCart(userID uint) (*models.Cart, error) {
 	return &cart, nil
 }
 
-// AddCartItem 添加商品到购物车
-func (s *CartService) AddCartItem(userID, productID uint, quantity int) error {
+// AddCartItem 添加商品到购物车（多规格商品须指定规格，variantID 为 0 表示单规格商品）
+func (s *CartService) AddCartItem(userID, productID, variantID uint, quantity int) error {
 	// 检查商品是否存在且有足够库存
 	var product models.Product
 	if err := database.DB.First(&product, productID).Error; err != nil {
 		return errors.New("商品不存在")
 	}
+	if !IsPublished(product.Status) {
+		return errors.New("商品已下架")
+	}
 
-	if product.Stock < quantity {
+	stock, err := s.availableStock(&product, variantID)
+	if err != nil {
+		return err
+	}
+	if stock < quantity {
 		return errors.New("库存不足")
 	}
 
@@ -54,15 +61,20 @@ func (s *CartService) AddCartItem(userID, productID uint, quantity int) error {
 		return err
 	}
 
-	// 检查是否已存在该商品
+	// 检查是否已存在该商品（同一商品的不同规格分别计入）
 	var existingItem models.CartItem
-	err = database.DB.Where("cart_id = ? AND product_id = ?", cart.ID, productID).
-		First(&existingItem).Error
+	query := database.DB.Where("cart_id = ? AND product_id = ?", cart.ID, productID)
+	if variantID == 0 {
+		query = query.Where("variant_id IS NULL")
+	} else {
+		query = query.Where("variant_id = ?", variantID)
+	}
+	err = query.First(&existingItem).Error
 
 	if err == nil {
 		// 已存在，更新数量
 		newQuantity := existingItem.Quantity + quantity
-		if product.Stock < newQuantity {
+		if stock < newQuantity {
 			return errors.New("库存不足")
 		}
 		return database.DB.Model(&existingItem).Update("quantity", newQuantity).Error
@@ -75,6 +87,9 @@ func (s *CartService) AddCartItem(userID, productID uint, quantity int) error {
 		Quantity:  quantity,
 		Selected:  true,
 	}
+	if variantID != 0 {
+		item.VariantID = &variantID
+	}
 
 	return database.DB.Create(&item).Error
 }
@@ -85,15 +100,19 @@ func (s *CartService) UpdateCartItemQuantity(userID, itemID uint, quantity int)
 	var item models.CartItem
 	err := database.DB.Joins("JOIN carts ON carts.id = cart_items.cart_id").
 		Where("cart_items.id = ? AND carts.user_id = ?", itemID, userID).
-		Preload("Product").
+		Preload("Product").Preload("Variant").
 		First(&item).Error
 	
 	if err != nil {
 		return errors.New("购物车项不存在")
 	}
 
-	// 检查库存
-	if item.Product.Stock < quantity {
+	// 检查库存（多规格商品以规格库存为准）
+	stock := item.Product.Stock
+	if item.Variant != nil {
+		stock = item.Variant.Stock
+	}
+	if stock < quantity {
 		return errors.New("库存不足")
 	}
 
@@ -136,8 +155,31 @@ func (s *CartService) GetSelectedItems(userID uint) ([]models.CartItem, error) {
 	var items []models.CartItem
 	err := database.DB.Joins("JOIN carts ON carts.id = cart_items.cart_id").
 		Where("carts.user_id = ? AND cart_items.selected = ?", userID, true).
-		Preload("Product").
+		Preload("Product").Preload("Variant").
 		Find(&items).Error
 	
 	return items, err
 }
+
+// availableStock 获取可加购库存：多规格商品取所选规格的库存
+func (s *CartService) availableStock(product *models.Product, variantID uint) (int, error) {
+	if variantID == 0 {
+		has, err := productsWithVariants(database.DB, []uint{product.ID})
+		if err != nil {
+			return 0, err
+		}
+		if has[product.ID] {
+			return 0, ErrVariantRequired
+		}
+		return product.Stock, nil
+	}
+
+	variant, err := findVariant(database.DB, product.ID, variantID)
+	if err != nil {
+		return 0, err
+	}
+	if variant.Status != "active" {
+		return 0, errors.New("该规格已下架")
+	}
+	return variant.Stock, nil
+}
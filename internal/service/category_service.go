@@ -1,10 +1,32 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/shoppee/ecommerce/internal/database"
 	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCategoryNotFound 分类不存在
+	ErrCategoryNotFound = errors.New("分类不存在")
+	// ErrCategoryCycle 移动分类形成环
+	ErrCategoryCycle = errors.New("不能将分类移动到自身或其子分类下")
+)
+
+// 分类树缓存（分类变更及商品分类、上下架变更时失效）
+const (
+	categoryTreeCacheKey = "category:tree"
+	categoryTreeCacheTTL = 10 * time.Minute
 )
 
 type CategoryService struct{}
@@ -13,43 +35,225 @@ func NewCategoryService() *CategoryService {
 	return &CategoryService{}
 }
 
-// GetCategoryTree 获取分类树
-func (s *CategoryService) GetCategoryTree() ([]*models.Category, error) {
-	var categories []*models.Category
-	
-	// 获取所有分类
+// CategoryCrumb 面包屑中的一级分类
+type CategoryCrumb struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// CategoryDetail 分类详情，含直接子分类、商品数（含子分类）及面包屑
+type CategoryDetail struct {
+	models.Category
+	Breadcrumb []CategoryCrumb `json:"breadcrumb"`
+}
+
+// MoveCategoryRequest 移动分类（连同子树）请求
+type MoveCategoryRequest struct {
+	ParentID uint `json:"parent_id"`                      // 0 表示移动为根分类
+	Sort     *int `json:"sort" binding:"omitempty,gte=0"` // 为空时排在新父分类下的最后
+}
+
+// ReorderCategoriesRequest 同级分类批量排序请求
+type ReorderCategoriesRequest struct {
+	ParentID uint   `json:"parent_id"`                    // 0 表示根分类
+	IDs      []uint `json:"ids" binding:"required,min=1"` // 该父分类下全部子分类的新顺序
+}
+
+// GetCategoryTree 获取分类树（带缓存），每个节点含子分类在内的已上架商品数
+func (s *CategoryService) GetCategoryTree() ([]models.Category, error) {
+	ctx := context.Background()
+	if cached, err := database.RedisClient.Get(ctx, categoryTreeCacheKey).Bytes(); err == nil {
+		var tree []models.Category
+		if err := json.Unmarshal(cached, &tree); err == nil {
+			return tree, nil
+		}
+	}
+
+	var categories []models.Category
 	if err := database.DB.Order("sort ASC, id ASC").Find(&categories).Error; err != nil {
 		return nil, err
 	}
+	counts, err := categoryProductCounts()
+	if err != nil {
+		return nil, err
+	}
+	tree := buildTree(categories, counts)
 
-	// 构建树形结构
-	return buildTree(categories, 0), nil
+	if data, err := json.Marshal(tree); err == nil {
+		if err := database.RedisClient.Set(ctx, categoryTreeCacheKey, data, categoryTreeCacheTTL).Err(); err != nil {
+			logger.Warn("缓存分类树失败", zap.Error(err))
+		}
+	}
+	return tree, nil
 }
 
-// buildTree 构建树形结构
-func buildTree(categories []*models.Category, parentID uint) []*models.Category {
-	var tree []*models.Category
-	
-	for _, category := range categories {
-		if category.ParentID == parentID {
-			children := buildTree(categories, category.ID)
-			if len(children) > 0 {
-				category.Children = children
-			}
-			tree = append(tree, category)
+// buildTree 按父子关系一次遍历构建分类树，并将商品数累加到祖先节点
+func buildTree(categories []models.Category, counts map[uint]int64) []models.Category {
+	known := make(map[uint]bool, len(categories))
+	for _, c := range categories {
+		known[c.ID] = true
+	}
+	children := make(map[uint][]models.Category)
+	for _, c := range categories {
+		parentID := uint(0)
+		if c.ParentID != nil && known[*c.ParentID] {
+			parentID = *c.ParentID
 		}
+		children[parentID] = append(children[parentID], c)
 	}
-	
+
+	var fill func(parentID uint) ([]models.Category, int64)
+	fill = func(parentID uint) ([]models.Category, int64) {
+		nodes := children[parentID]
+		var total int64
+		for i := range nodes {
+			var sub int64
+			nodes[i].Children, sub = fill(nodes[i].ID)
+			nodes[i].ProductCount = counts[nodes[i].ID] + sub
+			total += nodes[i].ProductCount
+		}
+		return nodes, total
+	}
+	tree, _ := fill(0)
 	return tree
 }
 
+// categoryProductCounts 各分类直属的已上架商品数（含缺货商品）
+func categoryProductCounts() (map[uint]int64, error) {
+	var rows []struct {
+		CategoryID uint
+		Count      int64
+	}
+	if err := database.DB.Model(&models.Product{}).
+		Select("category_id, COUNT(*) AS count").
+		Where("status IN ?", publishedStatuses).
+		Group("category_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, r := range rows {
+		counts[r.CategoryID] = r.Count
+	}
+	return counts, nil
+}
+
+// invalidateCategoryTree 分类变更后清除分类树缓存
+func invalidateCategoryTree() {
+	if database.RedisClient == nil {
+		return
+	}
+	if err := database.RedisClient.Del(context.Background(), categoryTreeCacheKey).Err(); err != nil {
+		logger.Warn("清除分类树缓存失败", zap.Error(err))
+	}
+}
+
+// categoryCountChanged 商品变更是否影响分类商品数：分类变化，或在上架与未上架之间切换
+func categoryCountChanged(before, after *models.Product) bool {
+	if !IsPublished(before.Status) && !IsPublished(after.Status) {
+		return false
+	}
+	return before.CategoryID != after.CategoryID || IsPublished(before.Status) != IsPublished(after.Status)
+}
+
+// GetCategory 获取分类详情，由分类树定位，返回直接子分类、商品数及面包屑
+func (s *CategoryService) GetCategory(id uint) (*CategoryDetail, error) {
+	tree, err := s.GetCategoryTree()
+	if err != nil {
+		return nil, err
+	}
+
+	var category models.Category
+	if err := database.DB.Select("id", "path").First(&category, id).Error; err != nil {
+		return nil, ErrCategoryNotFound
+	}
+	node, crumbs := locateCategory(tree, category.Path)
+	if node == nil {
+		// 分类树缓存尚未包含新建的分类
+		invalidateCategoryTree()
+		if tree, err = s.GetCategoryTree(); err != nil {
+			return nil, err
+		}
+		if node, crumbs = locateCategory(tree, category.Path); node == nil {
+			return nil, ErrCategoryNotFound
+		}
+	}
+
+	detail := &CategoryDetail{Category: *node, Breadcrumb: crumbs}
+	// 只返回直接子分类
+	for i := range detail.Children {
+		detail.Children[i].Children = nil
+	}
+	return detail, nil
+}
+
+// GetBreadcrumb 获取分类的面包屑（从根分类到自身）
+func (s *CategoryService) GetBreadcrumb(id uint) ([]CategoryCrumb, error) {
+	detail, err := s.GetCategory(id)
+	if err != nil {
+		return nil, err
+	}
+	return detail.Breadcrumb, nil
+}
+
+// locateCategory 按物化路径在分类树中逐级定位分类，同时生成面包屑
+func locateCategory(tree []models.Category, path string) (*models.Category, []CategoryCrumb) {
+	ids := parseCategoryPath(path)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var node *models.Category
+	crumbs := make([]CategoryCrumb, 0, len(ids))
+	level := tree
+	for _, id := range ids {
+		node = nil
+		for i := range level {
+			if level[i].ID == id {
+				node = &level[i]
+				break
+			}
+		}
+		if node == nil {
+			return nil, nil
+		}
+		crumbs = append(crumbs, CategoryCrumb{ID: node.ID, Name: node.Name})
+		level = node.Children
+	}
+	return node, crumbs
+}
+
+// parseCategoryPath 解析物化路径中的分类ID（根在前）
+func parseCategoryPath(path string) []uint {
+	var ids []uint
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		id, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids
+}
+
+// categorySubtree 分类及其全部子分类ID的子查询，用于按分类筛选商品时包含子分类的商品
+func categorySubtree(categoryID uint) *gorm.DB {
+	return database.DB.Model(&models.Category{}).Select("id").
+		Where("id = ? OR path LIKE (SELECT NULLIF(path, '') FROM categories WHERE id = ?) || '%'", categoryID, categoryID)
+}
+
 // GetCategoryList 获取分类列表（根据父ID）
 func (s *CategoryService) GetCategoryList(parentID uint) ([]*models.Category, error) {
 	var categories []*models.Category
-	
-	if err := database.DB.Where("parent_id = ?", parentID).
-		Order("sort ASC, id ASC").
-		Find(&categories).Error; err != nil {
+
+	query := database.DB.Order("sort ASC, id ASC")
+	if parentID == 0 {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", parentID)
+	}
+	if err := query.Find(&categories).Error; err != nil {
 		return nil, err
 	}
 
@@ -58,14 +262,6 @@ func (s *CategoryService) GetCategoryList(parentID uint) ([]*models.Category, er
 
 // CreateCategory 创建分类
 func (s *CategoryService) CreateCategory(name, description string, parentID uint, icon string, sort int, status string) (*models.Category, error) {
-	// 验证父分类是否存在
-	if parentID > 0 {
-		var parent models.Category
-		if err := database.DB.First(&parent, parentID).Error; err != nil {
-			return nil, errors.New("父分类不存在")
-		}
-	}
-
 	if status == "" {
 		status = "active"
 	}
@@ -73,44 +269,241 @@ func (s *CategoryService) CreateCategory(name, description string, parentID uint
 	category := &models.Category{
 		Name:        name,
 		Description: description,
-		ParentID:    parentID,
 		Icon:        icon,
 		Sort:        sort,
 		Status:      status,
 	}
 
-	if err := database.DB.Create(category).Error; err != nil {
+	err := database.Transaction(func(tx *gorm.DB) error {
+		if err := lockCategoryTree(tx); err != nil {
+			return err
+		}
+
+		// 验证父分类是否存在
+		parentPath := "/"
+		if parentID > 0 {
+			var parent models.Category
+			if err := tx.First(&parent, parentID).Error; err != nil {
+				return errors.New("父分类不存在")
+			}
+			category.ParentID = &parent.ID
+			category.Depth = parent.Depth + 1
+			parentPath = parent.Path
+		}
+
+		if err := tx.Create(category).Error; err != nil {
+			return err
+		}
+		category.Path = fmt.Sprintf("%s%d/", parentPath, category.ID)
+		return tx.Model(category).Update("path", category.Path).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	invalidateCategoryTree()
 	return category, nil
 }
 
-// UpdateCategory 更新分类
+// UpdateCategory 更新分类，slug 变更时旧 slug 记入历史（传空字符串时由名称重新生成）
 func (s *CategoryService) UpdateCategory(id uint, updates map[string]interface{}) error {
-	// 检查分类是否存在
+	err := database.Transaction(func(tx *gorm.DB) error {
+		// 检查分类是否存在
+		var category models.Category
+		if err := tx.First(&category, id).Error; err != nil {
+			return errors.New("分类不存在")
+		}
+
+		if requested, ok := updates["slug"].(string); ok {
+			name, ok := updates["name"].(string)
+			if !ok {
+				name = category.Name
+			}
+			newSlug, err := changeSlug(tx, models.SlugEntityCategory, category.ID, category.Slug, requested, name)
+			if err != nil {
+				return err
+			}
+			updates["slug"] = newSlug
+		}
+		return tx.Model(&category).Updates(updates).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	invalidateCategoryTree()
+	invalidateCategoryProducts([]uint{id})
+	return nil
+}
+
+// MoveCategory 将分类连同其子树移动到新的父分类下，禁止移动到自身或子孙分类下
+func (s *CategoryService) MoveCategory(id uint, req *MoveCategoryRequest) (*models.Category, error) {
 	var category models.Category
-	if err := database.DB.First(&category, id).Error; err != nil {
-		return errors.New("分类不存在")
+	err := database.Transaction(func(tx *gorm.DB) error {
+		if err := lockCategoryTree(tx); err != nil {
+			return err
+		}
+		if err := tx.First(&category, id).Error; err != nil {
+			return ErrCategoryNotFound
+		}
+
+		parentPath, depth := "/", 0
+		var parentID *uint
+		if req.ParentID > 0 {
+			var parent models.Category
+			if err := tx.First(&parent, req.ParentID).Error; err != nil {
+				return errors.New("父分类不存在")
+			}
+			if strings.HasPrefix(parent.Path, category.Path) {
+				return ErrCategoryCycle
+			}
+			parentPath, depth, parentID = parent.Path, parent.Depth+1, &parent.ID
+		}
+
+		sort := 0
+		if req.Sort != nil {
+			sort = *req.Sort
+		} else {
+			siblings := tx.Model(&models.Category{}).Where("id <> ?", id)
+			if parentID == nil {
+				siblings = siblings.Where("parent_id IS NULL")
+			} else {
+				siblings = siblings.Where("parent_id = ?", *parentID)
+			}
+			if err := siblings.Select("COALESCE(MAX(sort), 0) + 1").Scan(&sort).Error; err != nil {
+				return err
+			}
+		}
+
+		oldPath := category.Path
+		newPath := fmt.Sprintf("%s%d/", parentPath, category.ID)
+		if err := rebaseCategoryPaths(tx, oldPath, newPath, depth-category.Depth); err != nil {
+			return err
+		}
+		if err := tx.Model(&category).Updates(map[string]interface{}{"parent_id": parentID, "sort": sort}).Error; err != nil {
+			return err
+		}
+
+		category.ParentID, category.Path, category.Depth, category.Sort = parentID, newPath, depth, sort
+		logger.Info("移动分类", zap.Uint("category_id", id), zap.String("from", oldPath), zap.String("to", newPath))
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return database.DB.Model(&category).Updates(updates).Error
+	invalidateCategoryTree()
+	invalidateCategoryProducts(categorySubtree(id))
+	return &category, nil
 }
 
-// DeleteCategory 删除分类
-func (s *CategoryService) DeleteCategory(id uint) error {
-	// 检查是否有子分类
-	var count int64
-	database.DB.Model(&models.Category{}).Where("parent_id = ?", id).Count(&count)
-	if count > 0 {
-		return errors.New("该分类下有子分类，无法删除")
+// ReorderCategories 按给定顺序重排同一父分类下的全部子分类
+func (s *CategoryService) ReorderCategories(req *ReorderCategoriesRequest) error {
+	ids := uniqueIDs(req.IDs)
+	if len(ids) != len(req.IDs) {
+		return errors.New("排序列表中存在重复的分类")
 	}
 
-	// 检查是否有商品
-	database.DB.Model(&models.Product{}).Where("category_id = ?", id).Count(&count)
-	if count > 0 {
-		return errors.New("该分类下有商品，无法删除")
+	err := database.Transaction(func(tx *gorm.DB) error {
+		if err := lockCategoryTree(tx); err != nil {
+			return err
+		}
+
+		var siblings []uint
+		query := tx.Model(&models.Category{})
+		if req.ParentID == 0 {
+			query = query.Where("parent_id IS NULL")
+		} else {
+			query = query.Where("parent_id = ?", req.ParentID)
+		}
+		if err := query.Pluck("id", &siblings).Error; err != nil {
+			return err
+		}
+		if len(siblings) != len(ids) {
+			return errors.New("排序列表须包含该父分类下的全部子分类")
+		}
+		known := make(map[uint]bool, len(siblings))
+		for _, id := range siblings {
+			known[id] = true
+		}
+		for _, id := range ids {
+			if !known[id] {
+				return fmt.Errorf("分类 %d 不属于该父分类", id)
+			}
+		}
+
+		for i, id := range ids {
+			if err := tx.Model(&models.Category{}).Where("id = ?", id).Update("sort", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	return database.DB.Delete(&models.Category{}, id).Error
+	invalidateCategoryTree()
+	invalidateCategoryProducts(ids)
+	return nil
+}
+
+// DeleteCategory 删除分类，子分类连同其子树上移到被删除分类的父分类下
+func (s *CategoryService) DeleteCategory(id uint) error {
+	var productIDs []uint
+	err := database.Transaction(func(tx *gorm.DB) error {
+		if err := lockCategoryTree(tx); err != nil {
+			return err
+		}
+		var category models.Category
+		if err := tx.First(&category, id).Error; err != nil {
+			return ErrCategoryNotFound
+		}
+
+		// 检查是否有商品
+		var count int64
+		if err := tx.Model(&models.Product{}).Where("category_id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("该分类下有商品，无法删除")
+		}
+		// 子树上移后其商品的分类路径随之变化
+		if err := tx.Model(&models.Product{}).Where("category_id IN (?)", categorySubtree(id)).
+			Pluck("id", &productIDs).Error; err != nil {
+			return err
+		}
+
+		parentPath := strings.TrimSuffix(category.Path, fmt.Sprintf("%d/", category.ID))
+		if err := rebaseCategoryPaths(tx.Where("id <> ?", id), category.Path, parentPath, -1); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Category{}).Where("parent_id = ?", id).
+			Update("parent_id", category.ParentID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&category).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	invalidateCategoryTree()
+	invalidateProductCache(productIDs...)
+	return nil
+}
+
+// rebaseCategoryPaths 将路径前缀为 oldPath 的分类改为 newPath 前缀，并调整层级
+func rebaseCategoryPaths(tx *gorm.DB, oldPath, newPath string, depthDelta int) error {
+	return tx.Model(&models.Category{}).
+		Where("path LIKE ?", oldPath+"%").
+		Updates(map[string]interface{}{
+			"path":  gorm.Expr("? || SUBSTRING(path FROM ?)", newPath, len(oldPath)+1),
+			"depth": gorm.Expr("depth + ?", depthDelta),
+		}).Error
+}
+
+// lockCategoryTree 串行化分类树的结构变更，避免并发移动形成环或路径不一致（不阻塞读取）
+func lockCategoryTree(tx *gorm.DB) error {
+	return tx.Exec("LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE").Error
 }
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuildTreeAndLocate 测试分类树构建、商品数向上累加及面包屑定位
+func TestBuildTreeAndLocate(t *testing.T) {
+	parent := func(id uint) *uint { return &id }
+	categories := []models.Category{
+		{ID: 1, Name: "电子产品", Path: "/1/"},
+		{ID: 2, Name: "服装", Path: "/2/"},
+		{ID: 3, Name: "手机", Path: "/1/3/", ParentID: parent(1)},
+		{ID: 4, Name: "智能手机", Path: "/1/3/4/", ParentID: parent(3)},
+		{ID: 5, Name: "电脑", Path: "/1/5/", ParentID: parent(1)},
+	}
+	counts := map[uint]int64{1: 1, 3: 2, 4: 5, 5: 3, 2: 4}
+
+	tree := buildTree(categories, counts)
+	require.Len(t, tree, 2)
+	assert.Equal(t, int64(11), tree[0].ProductCount, "电子产品含全部子分类")
+	assert.Equal(t, int64(4), tree[1].ProductCount)
+	require.Len(t, tree[0].Children, 2)
+	assert.Equal(t, int64(7), tree[0].Children[0].ProductCount)
+
+	node, crumbs := locateCategory(tree, "/1/3/4/")
+	require.NotNil(t, node)
+	assert.Equal(t, uint(4), node.ID)
+	assert.Equal(t, []CategoryCrumb{{1, "电子产品"}, {3, "手机"}, {4, "智能手机"}}, crumbs)
+
+	node, _ = locateCategory(tree, "/2/9/")
+	assert.Nil(t, node)
+	node, _ = locateCategory(tree, "")
+	assert.Nil(t, node)
+}
+
+// TestCategoryCountChanged 测试商品变更是否需要刷新分类商品数
+func TestCategoryCountChanged(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after models.Product
+		want          bool
+	}{
+		{"上架商品换分类", models.Product{CategoryID: 1, Status: "active"}, models.Product{CategoryID: 2, Status: "active"}, true},
+		{"草稿换分类", models.Product{CategoryID: 1, Status: "draft"}, models.Product{CategoryID: 2, Status: "draft"}, false},
+		{"上架", models.Product{CategoryID: 1, Status: "draft"}, models.Product{CategoryID: 1, Status: "active"}, true},
+		{"下架", models.Product{CategoryID: 1, Status: "out_of_stock"}, models.Product{CategoryID: 1, Status: "inactive"}, true},
+		{"售罄仍计数", models.Product{CategoryID: 1, Status: "active"}, models.Product{CategoryID: 1, Status: "out_of_stock"}, false},
+		{"草稿改为下架", models.Product{CategoryID: 1, Status: "draft"}, models.Product{CategoryID: 1, Status: "inactive"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, categoryCountChanged(&tt.before, &tt.after))
+		})
+	}
+}
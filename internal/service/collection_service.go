@@ -0,0 +1,278 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrCollectionNotFound 合集不存在
+	ErrCollectionNotFound = errors.New("合集不存在")
+	// ErrCollectionNotManual 规则合集的商品由规则决定，不能手动编排
+	ErrCollectionNotManual = errors.New("规则合集不能手动设置商品")
+)
+
+// 人工合集的最大商品数
+const maxCollectionProducts = 500
+
+// CollectionService 商品合集服务
+type CollectionService struct{}
+
+// NewCollectionService 创建商品合集服务实例
+func NewCollectionService() *CollectionService {
+	return &CollectionService{}
+}
+
+// CollectionRequest 创建/更新合集请求
+type CollectionRequest struct {
+	Code        string                 `json:"code" binding:"required,max=50"`
+	Name        string                 `json:"name" binding:"required,max=100"`
+	Description string                 `json:"description"`
+	Type        string                 `json:"type" binding:"required,oneof=manual rule"`
+	Status      string                 `json:"status" binding:"omitempty,oneof=active inactive"`
+	Sort        int                    `json:"sort"`
+	Rule        *models.CollectionRule `json:"rule"` // 规则合集必填，至少设置一个条件
+}
+
+// CollectionProductsRequest 设置人工合集商品请求，按数组顺序排列
+type CollectionProductsRequest struct {
+	ProductIDs []uint `json:"product_ids" binding:"max=500"`
+}
+
+// GetCollections 获取合集列表，includeInactive 为 false 时只返回启用的合集
+func (s *CollectionService) GetCollections(includeInactive bool) ([]models.Collection, error) {
+	query := database.DB.Order("sort ASC, id ASC")
+	if !includeInactive {
+		query = query.Where("status = ?", "active")
+	}
+	var collections []models.Collection
+	if err := query.Find(&collections).Error; err != nil {
+		return nil, err
+	}
+	return collections, nil
+}
+
+// GetCollection 按编码获取启用的合集（商品通过商品列表接口的 collection 参数查询）
+func (s *CollectionService) GetCollection(code string) (*models.Collection, error) {
+	var collection models.Collection
+	if err := database.DB.Where("code = ? AND status = ?", code, "active").First(&collection).Error; err != nil {
+		return nil, ErrCollectionNotFound
+	}
+	return &collection, nil
+}
+
+// CreateCollection 创建合集
+func (s *CollectionService) CreateCollection(req *CollectionRequest) (*models.Collection, error) {
+	collection := &models.Collection{Status: "active"}
+	if err := applyCollectionRequest(collection, req); err != nil {
+		return nil, err
+	}
+	if err := checkCollectionCode(0, req.Code); err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Create(collection).Error; err != nil {
+		return nil, err
+	}
+
+	logger.Info("创建合集", zap.Uint("collection_id", collection.ID), zap.String("code", collection.Code))
+	return collection, nil
+}
+
+// UpdateCollection 更新合集；改为规则合集时清除人工编排的商品
+func (s *CollectionService) UpdateCollection(id uint, req *CollectionRequest) (*models.Collection, error) {
+	var collection models.Collection
+	if err := database.DB.First(&collection, id).Error; err != nil {
+		return nil, ErrCollectionNotFound
+	}
+	if err := applyCollectionRequest(&collection, req); err != nil {
+		return nil, err
+	}
+	if err := checkCollectionCode(id, req.Code); err != nil {
+		return nil, err
+	}
+
+	err := database.Transaction(func(tx *gorm.DB) error {
+		if collection.Type == models.CollectionTypeRule {
+			if err := tx.Where("collection_id = ?", id).Delete(&models.CollectionProduct{}).Error; err != nil {
+				return err
+			}
+		}
+		// 整体保存，规则中清空的条件同样写入 NULL
+		return tx.Save(&collection).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// DeleteCollection 删除合集及其商品编排
+func (s *CollectionService) DeleteCollection(id uint) error {
+	err := database.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Collection{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCollectionNotFound
+		}
+		return tx.Where("collection_id = ?", id).Delete(&models.CollectionProduct{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("删除合集", zap.Uint("collection_id", id))
+	return nil
+}
+
+// SetCollectionProducts 替换人工合集的商品，顺序与请求一致
+func (s *CollectionService) SetCollectionProducts(id uint, req *CollectionProductsRequest) ([]models.CollectionProduct, error) {
+	productIDs := uniqueIDs(req.ProductIDs)
+	if len(productIDs) > maxCollectionProducts {
+		return nil, fmt.Errorf("合集最多包含 %d 个商品", maxCollectionProducts)
+	}
+
+	items := make([]models.CollectionProduct, 0, len(productIDs))
+	err := database.Transaction(func(tx *gorm.DB) error {
+		var collection models.Collection
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&collection, id).Error; err != nil {
+			return ErrCollectionNotFound
+		}
+		if collection.Type != models.CollectionTypeManual {
+			return ErrCollectionNotManual
+		}
+
+		if len(productIDs) > 0 {
+			var count int64
+			if err := tx.Model(&models.Product{}).Where("id IN ?", productIDs).Count(&count).Error; err != nil {
+				return err
+			}
+			if int(count) != len(productIDs) {
+				return ErrProductNotFound
+			}
+		}
+
+		if err := tx.Where("collection_id = ?", id).Delete(&models.CollectionProduct{}).Error; err != nil {
+			return err
+		}
+		for i, productID := range productIDs {
+			items = append(items, models.CollectionProduct{CollectionID: id, ProductID: productID, Sort: i})
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&items, 100).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("设置合集商品", zap.Uint("collection_id", id), zap.Int("count", len(items)))
+	return items, nil
+}
+
+// applyCollectionRequest 校验请求并写入合集字段
+func applyCollectionRequest(collection *models.Collection, req *CollectionRequest) error {
+	if !codePattern.MatchString(req.Code) {
+		return ErrInvalidCode
+	}
+
+	collection.Code = req.Code
+	collection.Name = req.Name
+	collection.Description = req.Description
+	collection.Type = req.Type
+	collection.Sort = req.Sort
+	if req.Status != "" {
+		collection.Status = req.Status
+	}
+
+	collection.Rule = models.CollectionRule{}
+	if req.Type != models.CollectionTypeRule {
+		return nil
+	}
+	if req.Rule == nil || req.Rule.IsEmpty() {
+		return errors.New("规则合集至少需要设置一个筛选条件")
+	}
+	rule := *req.Rule
+	if rule.MinPrice != nil && rule.MaxPrice != nil && *rule.MinPrice > *rule.MaxPrice {
+		return errors.New("最低价格不能高于最高价格")
+	}
+	if rule.CategoryID != nil {
+		var count int64
+		if err := database.DB.Model(&models.Category{}).Where("id = ?", *rule.CategoryID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("规则中的分类不存在")
+		}
+	}
+	if rule.TagID != nil {
+		var count int64
+		if err := database.DB.Model(&models.Tag{}).Where("id = ?", *rule.TagID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("规则中的标签不存在")
+		}
+	}
+	collection.Rule = rule
+	return nil
+}
+
+// checkCollectionCode 校验合集编码唯一
+func checkCollectionCode(id uint, code string) error {
+	var count int64
+	if err := database.DB.Model(&models.Collection{}).Where("code = ? AND id <> ?", code, id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("合集编码已存在")
+	}
+	return nil
+}
+
+// applyCollectionFilter 商品列表按合集筛选：人工合集取编排的商品，规则合集按规则匹配
+func applyCollectionFilter(query *gorm.DB, collection *models.Collection) *gorm.DB {
+	if collection.Type == models.CollectionTypeManual {
+		return query.Where("EXISTS (SELECT 1 FROM collection_products cp WHERE cp.product_id = products.id AND cp.collection_id = ?)", collection.ID)
+	}
+
+	rule := collection.Rule
+	if rule.CategoryID != nil {
+		query = query.Where("products.category_id IN (?)", categorySubtree(*rule.CategoryID))
+	}
+	if rule.TagID != nil {
+		query = query.Where("EXISTS (SELECT 1 FROM product_tags pt WHERE pt.product_id = products.id AND pt.tag_id = ?)", *rule.TagID)
+	}
+	if rule.MinPrice != nil {
+		query = query.Where("products.price >= ?", *rule.MinPrice)
+	}
+	if rule.MaxPrice != nil {
+		query = query.Where("products.price <= ?", *rule.MaxPrice)
+	}
+	if rule.InStock {
+		query = query.Where("products.stock > 0")
+	}
+	return query
+}
+
+// orderByCollection 未指定排序时按合集顺序排列：人工合集按编排顺序，规则合集按规则中的排序
+func orderByCollection(query *gorm.DB, collection *models.Collection) *gorm.DB {
+	if collection.Type == models.CollectionTypeManual {
+		return query.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "(SELECT cp.sort FROM collection_products cp WHERE cp.product_id = products.id AND cp.collection_id = ?) ASC, products.id DESC",
+			Vars:               []interface{}{collection.ID},
+			WithoutParentheses: true,
+		}})
+	}
+	return orderProducts(query, collection.Rule.Sort)
+}
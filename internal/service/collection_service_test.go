@@ -0,0 +1,72 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCollectionProductList 测试商品列表按标签、人工合集与规则合集筛选
+func TestCollectionProductList(t *testing.T) {
+	setupTest()
+
+	run := time.Now().UnixNano()
+	products := []models.Product{
+		{Name: "合集商品A", Price: 59, Stock: 10, SKU: fmt.Sprintf("COL_A_%d", run), Status: "active"},
+		{Name: "合集商品B", Price: 199, Stock: 10, SKU: fmt.Sprintf("COL_B_%d", run), Status: "active"},
+		{Name: "合集商品C", Price: 89, Stock: 10, SKU: fmt.Sprintf("COL_C_%d", run), Status: "active"},
+	}
+	database.DB.Create(&products)
+
+	tagService := NewTagService()
+	tag, err := tagService.CreateTag(&TagRequest{Code: fmt.Sprintf("eco-%d", run), Name: fmt.Sprintf("环保%d", run)})
+	assert.NoError(t, err)
+	_, err = tagService.SetProductTags(products[0].ID, &ProductTagsRequest{TagIDs: []uint{tag.ID}})
+	assert.NoError(t, err)
+	_, err = tagService.SetProductTags(products[1].ID, &ProductTagsRequest{TagIDs: []uint{tag.ID}})
+	assert.NoError(t, err)
+
+	productService := NewProductService()
+	listIDs := func(req *ProductListRequest) []uint {
+		list, _, err := productService.GetProductList(req)
+		assert.NoError(t, err)
+		ids := make([]uint, 0, len(list))
+		for _, p := range list {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	assert.ElementsMatch(t, []uint{products[0].ID, products[1].ID}, listIDs(&ProductListRequest{Tags: tag.Code}))
+
+	// 人工合集按编排顺序返回
+	collectionService := NewCollectionService()
+	manual, err := collectionService.CreateCollection(&CollectionRequest{
+		Code: fmt.Sprintf("summer-%d", run), Name: "夏季大促", Type: models.CollectionTypeManual,
+	})
+	assert.NoError(t, err)
+	_, err = collectionService.SetCollectionProducts(manual.ID, &CollectionProductsRequest{
+		ProductIDs: []uint{products[2].ID, products[0].ID},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{products[2].ID, products[0].ID}, listIDs(&ProductListRequest{Collection: manual.Code}))
+
+	// 规则合集：带标签且价格不超过 99
+	maxPrice := 99.0
+	rule, err := collectionService.CreateCollection(&CollectionRequest{
+		Code: fmt.Sprintf("eco-under-99-%d", run), Name: "99元以下环保好物", Type: models.CollectionTypeRule,
+		Rule: &models.CollectionRule{TagID: &tag.ID, MaxPrice: &maxPrice},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{products[0].ID}, listIDs(&ProductListRequest{Collection: rule.Code}))
+
+	_, err = collectionService.SetCollectionProducts(rule.ID, &CollectionProductsRequest{ProductIDs: []uint{products[1].ID}})
+	assert.ErrorIs(t, err, ErrCollectionNotManual)
+
+	_, _, err = productService.GetProductList(&ProductListRequest{Collection: "missing-collection"})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
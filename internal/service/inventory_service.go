@@ -0,0 +1,266 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InventoryService 库存流水服务
+type InventoryService struct{}
+
+// NewInventoryService 创建库存流水服务实例
+func NewInventoryService() *InventoryService {
+	return &InventoryService{}
+}
+
+// stockMovementMeta 库存变动说明（原因、关联单号、操作人）
+type stockMovementMeta struct {
+	Reason      string
+	RefID       string
+	ActorID     uint
+	WarehouseID uint
+	VariantID   uint // 多规格商品变动的规格，0 表示单规格商品
+	Remark      string
+}
+
+// StockAdjustRequest 人工库存调整请求
+type StockAdjustRequest struct {
+	VariantID   uint   `json:"variant_id"`                       // 多规格商品必填
+	WarehouseID uint   `json:"warehouse_id"`                     // 按仓库管理库存的商品必填
+	Quantity    int    `json:"quantity" binding:"required,ne=0"` // 正数入库、负数出库
+	Reason      string `json:"reason" binding:"required,oneof=restock adjustment return"`
+	RefID       string `json:"ref_id" binding:"max=64"`
+	Remark      string `json:"remark" binding:"max=255"`
+}
+
+// StockReconciliation 库存对账结果
+type StockReconciliation struct {
+	ProductID     uint   `json:"product_id"`
+	CurrentStock  int    `json:"current_stock"`  // 商品表中的库存
+	OpeningStock  int    `json:"opening_stock"`  // 首条流水之前的期初库存
+	LedgerStock   int    `json:"ledger_stock"`   // 由流水重建的库存
+	MovementCount int    `json:"movement_count"` // 流水条数
+	BrokenLinks   []uint `json:"broken_links"`   // 变动前库存与上一条变动后库存不衔接的流水ID
+	Consistent    bool   `json:"consistent"`
+}
+
+// recordStockMovement 写入一条库存流水（须与库存变更处于同一事务）
+func recordStockMovement(tx *gorm.DB, productID uint, before, after int, meta stockMovementMeta) error {
+	if before == after {
+		return nil
+	}
+	return tx.Create(&models.StockMovement{
+		ProductID:   productID,
+		WarehouseID: meta.WarehouseID,
+		VariantID:   meta.VariantID,
+		Reason:      meta.Reason,
+		RefID:       meta.RefID,
+		ActorID:     meta.ActorID,
+		Quantity:    after - before,
+		BeforeStock: before,
+		AfterStock:  after,
+		Remark:      meta.Remark,
+	}).Error
+}
+
+// stockChangeResult 单个商品的库存变更结果
+type stockChangeResult struct {
+	Product          models.Product // 变更后的商品
+	Before           int            // 变更前库存
+	Restocked        bool           // 是否由缺货恢复为在售
+	RestockedVariant uint           // 由售罄恢复有货的规格ID，0 表示没有
+}
+
+// changeStockLocked 加行锁后变更商品库存并记录流水
+// 多规格商品须通过 meta.VariantID 指定规格，商品总库存重新按启用规格的库存汇总；
+// 按仓库管理库存的商品须通过 meta.WarehouseID 指定仓库，商品总库存重新按各仓库库存汇总
+func changeStockLocked(tx *gorm.DB, productID uint, quantity int, meta stockMovementMeta) (*stockChangeResult, error) {
+	var product models.Product
+
+	// 使用FOR UPDATE悲观锁防止并发问题（先锁商品再锁规格，与下单扣减顺序一致）
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+	variantRestocked, err := changeVariantStock(tx, productID, meta.VariantID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	newStock := product.Stock + quantity
+	if meta.VariantID != 0 {
+		// 停用规格的库存不计入商品总库存
+		if newStock, err = variantStockTotal(tx, productID); err != nil {
+			return nil, err
+		}
+	}
+	managed, err := changeWarehouseStock(tx, productID, meta.WarehouseID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	if managed {
+		if newStock, err = warehouseStockTotal(tx, productID); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &stockChangeResult{Before: product.Stock}
+	wasOutOfStock := product.Status == "out_of_stock"
+	if err := syncProductStock(tx, &product, newStock); err != nil {
+		return nil, err
+	}
+	result.Restocked = wasOutOfStock && product.Status == "active"
+
+	if err := recordStockMovement(tx, productID, result.Before, product.Stock, meta); err != nil {
+		return nil, err
+	}
+	result.Product = product
+	if variantRestocked {
+		result.RestockedVariant = meta.VariantID
+	}
+	return result, nil
+}
+
+// afterStockChange 库存变更事务提交后的处理：清缓存、同步搜索索引、低库存预警、到货通知
+func afterStockChange(r *stockChangeResult) {
+	invalidateProductCache(r.Product.ID)
+	// 售罄或恢复在售改变了商品能否被搜索到
+	if r.Restocked || (r.Product.Status == "out_of_stock" && r.Before > 0) {
+		indexProductsAsync(r.Product.ID)
+	}
+	if r.Product.Stock < r.Before {
+		alertLowStock(r.Product)
+	}
+	if r.Restocked {
+		notifyBackInStock(r.Product.ID, 0)
+	}
+	if r.RestockedVariant != 0 {
+		notifyBackInStock(r.Product.ID, r.RestockedVariant)
+	}
+}
+
+// AdjustStock 人工调整库存（入库、盘点、退货）
+func (s *InventoryService) AdjustStock(operatorID, productID uint, req *StockAdjustRequest) (*models.Product, error) {
+	var result *stockChangeResult
+	err := database.Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = changeStockLocked(tx, productID, req.Quantity, stockMovementMeta{
+			Reason:      req.Reason,
+			RefID:       req.RefID,
+			ActorID:     operatorID,
+			WarehouseID: req.WarehouseID,
+			VariantID:   req.VariantID,
+			Remark:      req.Remark,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	afterStockChange(result)
+	logger.Info("人工调整库存",
+		zap.Uint("product_id", productID),
+		zap.Uint("variant_id", req.VariantID),
+		zap.Uint("warehouse_id", req.WarehouseID),
+		zap.Int("quantity", req.Quantity),
+		zap.String("reason", req.Reason),
+		zap.Uint("operator_id", operatorID),
+	)
+	return &result.Product, nil
+}
+
+// GetStockMovements 获取商品库存流水（按时间倒序）
+func (s *InventoryService) GetStockMovements(productID uint, reason string, page, pageSize int) ([]models.StockMovement, int64, error) {
+	query := database.DB.Model(&models.StockMovement{}).Where("product_id = ?", productID)
+	if reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var movements []models.StockMovement
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&movements).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return movements, total, nil
+}
+
+// ReconcileStock 根据流水重建商品库存并与当前库存对账
+func (s *InventoryService) ReconcileStock(productID uint) (*StockReconciliation, error) {
+	var product models.Product
+	if err := database.DB.Unscoped().First(&product, productID).Error; err != nil {
+		return nil, errors.New("商品不存在")
+	}
+
+	var movements []models.StockMovement
+	if err := database.DB.Where("product_id = ?", productID).Order("id ASC").Find(&movements).Error; err != nil {
+		return nil, err
+	}
+
+	return reconcileMovements(product.ID, product.Stock, movements), nil
+}
+
+// ReconcileAll 对所有有流水的商品对账，仅返回不一致的结果
+func (s *InventoryService) ReconcileAll() ([]StockReconciliation, error) {
+	var productIDs []uint
+	if err := database.DB.Model(&models.StockMovement{}).
+		Distinct("product_id").Order("product_id ASC").
+		Pluck("product_id", &productIDs).Error; err != nil {
+		return nil, err
+	}
+
+	mismatched := make([]StockReconciliation, 0)
+	for _, productID := range productIDs {
+		result, err := s.ReconcileStock(productID)
+		if err != nil {
+			return nil, fmt.Errorf("商品 %d 对账失败: %w", productID, err)
+		}
+		if !result.Consistent {
+			mismatched = append(mismatched, *result)
+		}
+	}
+
+	if len(mismatched) > 0 {
+		logger.Warn("库存对账发现不一致", zap.Int("count", len(mismatched)))
+	}
+	return mismatched, nil
+}
+
+// reconcileMovements 按流水顺序重建库存
+// 流水表上线前已存在的库存以首条流水的变动前库存作为期初值
+func reconcileMovements(productID uint, currentStock int, movements []models.StockMovement) *StockReconciliation {
+	result := &StockReconciliation{
+		ProductID:     productID,
+		CurrentStock:  currentStock,
+		MovementCount: len(movements),
+		BrokenLinks:   make([]uint, 0),
+	}
+
+	result.OpeningStock = currentStock
+	if len(movements) > 0 {
+		result.OpeningStock = movements[0].BeforeStock
+	}
+
+	stock := result.OpeningStock
+	for _, m := range movements {
+		if m.BeforeStock != stock {
+			result.BrokenLinks = append(result.BrokenLinks, m.ID)
+		}
+		stock += m.Quantity
+	}
+	result.LedgerStock = stock
+	result.Consistent = result.LedgerStock == currentStock && len(result.BrokenLinks) == 0
+
+	return result
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReconcileMovements 测试根据库存流水重建库存
+func TestReconcileMovements(t *testing.T) {
+	movements := []models.StockMovement{
+		{ID: 1, Reason: models.StockReasonRestock, Quantity: 100, BeforeStock: 0, AfterStock: 100},
+		{ID: 2, Reason: models.StockReasonSale, Quantity: -3, BeforeStock: 100, AfterStock: 97},
+		{ID: 3, Reason: models.StockReasonCancel, Quantity: 3, BeforeStock: 97, AfterStock: 100},
+		{ID: 4, Reason: models.StockReasonSale, Quantity: -10, BeforeStock: 100, AfterStock: 90},
+	}
+
+	tests := []struct {
+		name           string
+		currentStock   int
+		movements      []models.StockMovement
+		wantLedger     int
+		wantBroken     []uint
+		wantConsistent bool
+	}{
+		{
+			name:           "流水与库存一致",
+			currentStock:   90,
+			movements:      movements,
+			wantLedger:     90,
+			wantBroken:     []uint{},
+			wantConsistent: true,
+		},
+		{
+			name:           "库存被绕过流水直接修改",
+			currentStock:   80,
+			movements:      movements,
+			wantLedger:     90,
+			wantBroken:     []uint{},
+			wantConsistent: false,
+		},
+		{
+			name:         "流水断链",
+			currentStock: 85,
+			movements: append(append([]models.StockMovement{}, movements...),
+				models.StockMovement{ID: 5, Reason: models.StockReasonSale, Quantity: -5, BeforeStock: 90, AfterStock: 85},
+				models.StockMovement{ID: 6, Reason: models.StockReasonReturn, Quantity: 2, BeforeStock: 80, AfterStock: 82},
+			),
+			wantLedger:     87,
+			wantBroken:     []uint{6},
+			wantConsistent: false,
+		},
+		{
+			name:           "无流水",
+			currentStock:   42,
+			wantLedger:     42,
+			wantBroken:     []uint{},
+			wantConsistent: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := reconcileMovements(1, tt.currentStock, tt.movements)
+			assert.Equal(t, tt.wantLedger, result.LedgerStock)
+			assert.Equal(t, tt.wantBroken, result.BrokenLinks)
+			assert.Equal(t, tt.wantConsistent, result.Consistent)
+		})
+	}
+}
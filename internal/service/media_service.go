@@ -0,0 +1,495 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // 注册 GIF 解码器
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shoppee/ecommerce/internal/config"
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/internal/storage"
+	"github.com/shoppee/ecommerce/pkg/imaging"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrUnsupportedMediaType 文件类型不支持
+	ErrUnsupportedMediaType = errors.New("不支持的文件类型，仅支持 JPEG、PNG、GIF 图片")
+	// ErrMediaTooLarge 文件超过大小限制
+	ErrMediaTooLarge = errors.New("文件过大")
+	// ErrMediaInUse 媒体仍被商品或评价使用
+	ErrMediaInUse = errors.New("媒体仍被商品或评价使用，无法删除")
+)
+
+// mediaExtensions 支持上传的图片类型（按文件内容识别）及存储扩展名
+var mediaExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// 每个商品、评价最多关联的图片数
+const (
+	maxProductMedia = 20
+	maxReviewMedia  = 9
+)
+
+// 存储操作超时时间
+const mediaStorageTimeout = 30 * time.Second
+
+// thumbnailSize 缩略图尺寸：等比缩放到边长不超过 Size 的正方形内
+type thumbnailSize struct {
+	Name string
+	Size int
+}
+
+var (
+	mediaStorageMu sync.RWMutex
+	mediaStorage   storage.Storage
+)
+
+// InitMediaStorage 按配置初始化媒体存储，并校验缩略图尺寸配置
+func InitMediaStorage() error {
+	cfg := config.AppConfig.Media
+	if _, err := parseThumbnailSizes(cfg.ThumbnailSizes); err != nil {
+		return err
+	}
+
+	var s storage.Storage
+	switch cfg.Storage {
+	case "", "local":
+		s = storage.NewLocalStorage(cfg.LocalDir, cfg.BaseURL)
+	case "s3":
+		if cfg.S3Endpoint == "" || cfg.S3Bucket == "" {
+			return errors.New("未配置 S3_ENDPOINT 或 S3_BUCKET")
+		}
+		s = storage.NewS3Storage(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PublicURL: cfg.S3PublicURL,
+			PathStyle: cfg.S3PathStyle,
+		})
+	default:
+		return fmt.Errorf("不支持的媒体存储: %s", cfg.Storage)
+	}
+
+	SetMediaStorage(s)
+	logger.Info("媒体存储初始化成功", zap.String("storage", cfg.Storage))
+	return nil
+}
+
+// SetMediaStorage 替换媒体存储（测试时可注入本地临时目录）
+func SetMediaStorage(s storage.Storage) {
+	mediaStorageMu.Lock()
+	defer mediaStorageMu.Unlock()
+	mediaStorage = s
+}
+
+// getMediaStorage 获取媒体存储，未初始化时使用本地存储
+func getMediaStorage() storage.Storage {
+	mediaStorageMu.RLock()
+	s := mediaStorage
+	mediaStorageMu.RUnlock()
+	if s != nil {
+		return s
+	}
+	return storage.NewLocalStorage(config.AppConfig.Media.LocalDir, config.AppConfig.Media.BaseURL)
+}
+
+// parseThumbnailSizes 解析缩略图尺寸配置，如 small:200,medium:400
+func parseThumbnailSizes(spec string) ([]thumbnailSize, error) {
+	var sizes []thumbnailSize
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, ":")
+		name = strings.TrimSpace(name)
+		size, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || name == "" || len(name) > 20 || err != nil || size <= 0 || size > 4096 || seen[name] {
+			return nil, fmt.Errorf("无效的缩略图尺寸配置: %s", part)
+		}
+		seen[name] = true
+		sizes = append(sizes, thumbnailSize{Name: name, Size: size})
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i].Size < sizes[j].Size })
+	return sizes, nil
+}
+
+// MediaService 媒体服务
+type MediaService struct{}
+
+// NewMediaService 创建媒体服务实例
+func NewMediaService() *MediaService {
+	return &MediaService{}
+}
+
+// MediaLinkItem 关联图片项
+type MediaLinkItem struct {
+	MediaID uint   `json:"media_id" binding:"required"`
+	AltText string `json:"alt_text" binding:"max=255"`
+}
+
+// ProductMediaRequest 设置商品图片请求（按数组顺序排序，第一张为主图）
+type ProductMediaRequest struct {
+	Items []MediaLinkItem `json:"items" binding:"max=20,dive"`
+}
+
+// Upload 上传图片：按文件内容校验类型与大小，保存原图并生成各尺寸缩略图
+// 同一用户重复上传相同图片时直接返回已有记录
+func (s *MediaService) Upload(uploaderID uint, r io.Reader, size int64) (*models.Media, error) {
+	cfg := config.AppConfig.Media
+	if size > cfg.MaxUploadSize {
+		return nil, ErrMediaTooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(r, cfg.MaxUploadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > cfg.MaxUploadSize {
+		return nil, ErrMediaTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := mediaExtensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	imgCfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("无法识别的图片文件")
+	}
+	if imgCfg.Width*imgCfg.Height > cfg.MaxPixels {
+		return nil, fmt.Errorf("%w: 图片尺寸不能超过 %d 像素", ErrMediaTooLarge, cfg.MaxPixels)
+	}
+
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	existing, err := findUploadedMedia(uploaderID, checksum)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("图片解码失败")
+	}
+
+	sizes, err := parseThumbnailSizes(cfg.ThumbnailSizes)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mediaStorageTimeout)
+	defer cancel()
+	store := getMediaStorage()
+
+	// 对象键带上用户ID与内容哈希，不同用户上传相同图片互不影响
+	prefix := fmt.Sprintf("media/%s/%d/%s", time.Now().Format("200601"), uploaderID, checksum[:32])
+	media := &models.Media{
+		UploaderID:  uploaderID,
+		StorageKey:  prefix + ext,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       imgCfg.Width,
+		Height:      imgCfg.Height,
+		Checksum:    checksum,
+	}
+	media.URL = store.URL(media.StorageKey)
+
+	var stored []string
+	cleanup := func() {
+		for _, key := range stored {
+			if err := store.Delete(context.Background(), key); err != nil {
+				logger.Warn("清理媒体文件失败", zap.String("key", key), zap.Error(err))
+			}
+		}
+	}
+
+	if err := store.Put(ctx, media.StorageKey, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return nil, err
+	}
+	stored = append(stored, media.StorageKey)
+
+	for _, ts := range sizes {
+		thumb, err := buildThumbnail(img, contentType, ts.Size)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		key := prefix + "_" + ts.Name + thumb.ext
+		if err := store.Put(ctx, key, bytes.NewReader(thumb.data), int64(len(thumb.data)), thumb.contentType); err != nil {
+			cleanup()
+			return nil, err
+		}
+		stored = append(stored, key)
+
+		media.Thumbnails = append(media.Thumbnails, models.MediaThumbnail{
+			Name:       ts.Name,
+			StorageKey: key,
+			URL:        store.URL(key),
+			Width:      thumb.width,
+			Height:     thumb.height,
+		})
+	}
+
+	// 并发上传相同图片时由唯一索引保证只有一条记录，后写入的请求返回已有记录
+	var duplicate bool
+	err = database.Transaction(func(tx *gorm.DB) error {
+		result := tx.Omit("Thumbnails").Clauses(clause.OnConflict{DoNothing: true}).Create(media)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			duplicate = true
+			return nil
+		}
+		if len(media.Thumbnails) == 0 {
+			return nil
+		}
+		for i := range media.Thumbnails {
+			media.Thumbnails[i].MediaID = media.ID
+		}
+		return tx.Create(&media.Thumbnails).Error
+	})
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	if duplicate {
+		existing, err := findUploadedMedia(uploaderID, checksum)
+		if err != nil {
+			return nil, err
+		}
+		// 对象键由内容哈希生成，已有记录引用的文件不能删除，只清理其未引用的（如跨月上传生成的新键）
+		used := map[string]bool{existing.StorageKey: true}
+		for _, t := range existing.Thumbnails {
+			used[t.StorageKey] = true
+		}
+		unused := stored[:0]
+		for _, key := range stored {
+			if !used[key] {
+				unused = append(unused, key)
+			}
+		}
+		stored = unused
+		cleanup()
+		return existing, nil
+	}
+
+	logger.Info("上传媒体成功", zap.Uint("media_id", media.ID), zap.Uint("uploader_id", uploaderID), zap.Int64("size", media.Size))
+	return media, nil
+}
+
+// findUploadedMedia 查找用户已上传的相同图片
+func findUploadedMedia(uploaderID uint, checksum string) (*models.Media, error) {
+	var media models.Media
+	if err := database.DB.Preload("Thumbnails").
+		Where("uploader_id = ? AND checksum = ?", uploaderID, checksum).
+		First(&media).Error; err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
+
+// thumbnail 编码后的缩略图
+type thumbnail struct {
+	data          []byte
+	contentType   string
+	ext           string
+	width, height int
+}
+
+// buildThumbnail 生成缩略图：JPEG 原图输出 JPEG，PNG/GIF 输出 PNG 以保留透明度（GIF 取首帧）
+func buildThumbnail(img image.Image, contentType string, size int) (*thumbnail, error) {
+	resized := imaging.Fit(img, size, size)
+	b := resized.Bounds()
+	thumb := &thumbnail{width: b.Dx(), height: b.Dy()}
+
+	var buf bytes.Buffer
+	if contentType == "image/jpeg" {
+		if err := jpeg.Encode(&buf, resized, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, err
+		}
+		thumb.contentType, thumb.ext = "image/jpeg", ".jpg"
+	} else {
+		if err := png.Encode(&buf, resized); err != nil {
+			return nil, err
+		}
+		thumb.contentType, thumb.ext = "image/png", ".png"
+	}
+	thumb.data = buf.Bytes()
+	return thumb, nil
+}
+
+// GetMedia 获取媒体详情
+func (s *MediaService) GetMedia(id uint) (*models.Media, error) {
+	var media models.Media
+	if err := database.DB.Preload("Thumbnails").First(&media, id).Error; err != nil {
+		return nil, errors.New("媒体不存在")
+	}
+	return &media, nil
+}
+
+// DeleteMedia 删除未被使用的媒体及其文件（上传者本人或管理员）
+func (s *MediaService) DeleteMedia(id, userID uint, isAdmin bool) error {
+	var media models.Media
+	if err := database.DB.Preload("Thumbnails").First(&media, id).Error; err != nil {
+		return errors.New("媒体不存在")
+	}
+	if !isAdmin && media.UploaderID != userID {
+		return errors.New("无权删除该媒体")
+	}
+
+	err := database.Transaction(func(tx *gorm.DB) error {
+		var used int64
+		if err := tx.Model(&models.ProductMedia{}).Where("media_id = ?", id).Count(&used).Error; err != nil {
+			return err
+		}
+		if used == 0 {
+			if err := tx.Model(&models.ReviewMedia{}).Where("media_id = ?", id).Count(&used).Error; err != nil {
+				return err
+			}
+		}
+		if used > 0 {
+			return ErrMediaInUse
+		}
+
+		if err := tx.Where("media_id = ?", id).Delete(&models.MediaThumbnail{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&media).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// 文件删除失败只记录日志，不影响记录删除
+	store := getMediaStorage()
+	keys := []string{media.StorageKey}
+	for _, t := range media.Thumbnails {
+		keys = append(keys, t.StorageKey)
+	}
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := store.Delete(context.Background(), key); err != nil {
+			logger.Warn("删除媒体文件失败", zap.String("key", key), zap.Error(err))
+		}
+	}
+
+	logger.Info("删除媒体成功", zap.Uint("media_id", id))
+	return nil
+}
+
+// GetProductMedia 获取商品图片（按排序）
+func (s *MediaService) GetProductMedia(productID uint) ([]models.ProductMedia, error) {
+	var items []models.ProductMedia
+	if err := database.DB.Preload("Media.Thumbnails").
+		Where("product_id = ?", productID).
+		Order("sort ASC, id ASC").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// SetProductMedia 整体设置商品图片及顺序、替代文本
+func (s *MediaService) SetProductMedia(productID uint, req *ProductMediaRequest) ([]models.ProductMedia, error) {
+	var product models.Product
+	if err := database.DB.Select("id").First(&product, productID).Error; err != nil {
+		return nil, errors.New("商品不存在")
+	}
+
+	ids := make([]uint, 0, len(req.Items))
+	for _, item := range req.Items {
+		ids = append(ids, item.MediaID)
+	}
+	if err := checkMediaIDs(ids, 0, maxProductMedia); err != nil {
+		return nil, err
+	}
+
+	err := database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).Delete(&models.ProductMedia{}).Error; err != nil {
+			return err
+		}
+		for i, item := range req.Items {
+			link := &models.ProductMedia{
+				ProductID: productID,
+				MediaID:   item.MediaID,
+				Sort:      i,
+				AltText:   strings.TrimSpace(item.AltText),
+			}
+			if err := tx.Create(link).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateProductCache(productID)
+	logger.Info("设置商品图片成功", zap.Uint("product_id", productID), zap.Int("count", len(req.Items)))
+	return s.GetProductMedia(productID)
+}
+
+// checkMediaIDs 校验媒体ID：不重复、数量不超限、均存在；uploaderID > 0 时还要求为该用户上传
+func checkMediaIDs(ids []uint, uploaderID uint, limit int) error {
+	if len(ids) > limit {
+		return fmt.Errorf("最多关联 %d 张图片", limit)
+	}
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return errors.New("图片不能重复")
+		}
+		seen[id] = true
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := database.DB.Model(&models.Media{}).Where("id IN ?", ids)
+	if uploaderID > 0 {
+		query = query.Where("uploader_id = ?", uploaderID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) != len(ids) {
+		return errors.New("图片不存在")
+	}
+	return nil
+}
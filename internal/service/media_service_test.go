@@ -0,0 +1,47 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseThumbnailSizes 测试缩略图尺寸配置解析
+func TestParseThumbnailSizes(t *testing.T) {
+	sizes, err := parseThumbnailSizes("large:800, small:200,medium:400")
+	require.NoError(t, err)
+	assert.Equal(t, []thumbnailSize{{"small", 200}, {"medium", 400}, {"large", 800}}, sizes)
+
+	for _, spec := range []string{"small", "small:0", "small:abc", "small:200,small:300", ":200"} {
+		_, err := parseThumbnailSizes(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+// TestBuildThumbnail 测试缩略图等比缩放及输出格式
+func TestBuildThumbnail(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 1000, 500))
+	for i := range src.Pix {
+		src.Pix[i] = 0x80
+	}
+	src.SetNRGBA(0, 0, color.NRGBA{A: 0})
+
+	thumb, err := buildThumbnail(src, "image/png", 200)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", thumb.contentType)
+	assert.Equal(t, []int{200, 100}, []int{thumb.width, thumb.height})
+
+	decoded, err := png.Decode(bytes.NewReader(thumb.data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 200, 100), decoded.Bounds())
+
+	thumb, err = buildThumbnail(src, "image/jpeg", 2000)
+	require.NoError(t, err)
+	assert.Equal(t, ".jpg", thumb.ext)
+	assert.Equal(t, []int{1000, 500}, []int{thumb.width, thumb.height}, "小图不放大")
+}
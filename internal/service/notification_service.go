@@ -0,0 +1,138 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/internal/websocket"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// NotificationService 站内通知服务
+type NotificationService struct{}
+
+// NewNotificationService 创建站内通知服务实例
+func NewNotificationService() *NotificationService {
+	return &NotificationService{}
+}
+
+// StoreForRole 为指定角色的所有用户保存通知，delivered 中的用户视为已通过WebSocket送达
+func (s *NotificationService) StoreForRole(role, msgType, title string, content map[string]interface{}, delivered []uint) error {
+	var userIDs []uint
+	if err := database.DB.Model(&models.User{}).
+		Where("role = ? AND status = ?", role, "active").
+		Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	return s.StoreForUsers(userIDs, msgType, title, content, delivered)
+}
+
+// StoreForUsers 为指定用户保存通知，delivered 中的用户视为已通过WebSocket送达
+func (s *NotificationService) StoreForUsers(userIDs []uint, msgType, title string, content map[string]interface{}, delivered []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+
+	online := make(map[uint]bool, len(delivered))
+	for _, id := range delivered {
+		online[id] = true
+	}
+
+	now := time.Now()
+	notifications := make([]models.Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		n := models.Notification{
+			UserID:  userID,
+			Type:    msgType,
+			Title:   title,
+			Content: string(data),
+		}
+		if online[userID] {
+			n.DeliveredAt = &now
+		}
+		notifications = append(notifications, n)
+	}
+
+	return database.DB.Create(&notifications).Error
+}
+
+// DeliverPending 用户上线后补发未送达的通知
+func (s *NotificationService) DeliverPending(userID uint) {
+	var pending []models.Notification
+	if err := database.DB.Where("user_id = ? AND delivered_at IS NULL", userID).
+		Order("id ASC").
+		Find(&pending).Error; err != nil {
+		logger.Error("查询待补发通知失败", zap.Uint("user_id", userID), zap.Error(err))
+		return
+	}
+
+	for _, n := range pending {
+		var content map[string]interface{}
+		if err := json.Unmarshal([]byte(n.Content), &content); err != nil {
+			logger.Error("解析通知内容失败", zap.Uint("notification_id", n.ID), zap.Error(err))
+			continue
+		}
+
+		msg := &websocket.Message{
+			Type:    n.Type,
+			Content: content,
+			UserID:  userID,
+			Time:    n.CreatedAt.Unix(),
+		}
+		if websocket.GlobalHub == nil || !websocket.GlobalHub.SendToUser(userID, msg) {
+			// 连接已断开，留待下次上线补发
+			return
+		}
+
+		database.DB.Model(&n).Update("delivered_at", time.Now())
+	}
+
+	if len(pending) > 0 {
+		logger.Info("补发离线通知", zap.Uint("user_id", userID), zap.Int("count", len(pending)))
+	}
+}
+
+// GetUserNotifications 获取用户通知列表
+func (s *NotificationService) GetUserNotifications(userID uint, unreadOnly bool, page, pageSize int) ([]models.Notification, int64, error) {
+	query := database.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []models.Notification
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, total, nil
+}
+
+// MarkRead 标记通知为已读
+func (s *NotificationService) MarkRead(userID, notificationID uint) error {
+	result := database.DB.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ? AND read_at IS NULL", notificationID, userID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("通知不存在或已读")
+	}
+	return nil
+}
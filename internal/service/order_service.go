@@ -10,6 +10,7 @@ import (
 	"github.com/shoppee/ecommerce/pkg/logger"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // OrderService 订单服务
@@ -24,29 +25,29 @@ func NewOrderService() *OrderService {
 	}
 }
 
+// ErrInsufficientStock 库存不足（下单时条件扣减失败）
+var ErrInsufficientStock = errors.New("库存不足")
+
+// CreateOrderRequest 创建订单请求
+type CreateOrderRequest struct {
+	AddressID     uint   `json:"address_id" binding:"required"`
+	CartItemIDs   []uint `json:"cart_item_ids" binding:"required"`
+	PaymentMethod string `json:"payment_method" binding:"required,oneof=alipay wechat card"`
+	Remark        string `json:"remark"`
+}
+
 // CreateOrder 创建订单
-func (s *OrderService) CreateOrder(userID uint, req interface{}) (*models.Order, error) {
-	type OrderReq struct {
-		AddressID     uint   `json:"address_id"`
-		CartItemIDs   []uint `json:"cart_item_ids"`
-		PaymentMethod string `json:"payment_method"`
-		Remark        string `json:"remark"`
-	}
-	
-	reqStruct, ok := req.(*OrderReq)
-	if !ok {
-		return nil, errors.New("无效的请求数据")
-	}
-	
+func (s *OrderService) CreateOrder(userID uint, req *CreateOrderRequest) (*models.Order, error) {
 	// 获取地址信息
 	var address models.Address
-	if err := database.DB.Where("id = ? AND user_id = ?", reqStruct.AddressID, userID).First(&address).Error; err != nil {
+	if err := database.DB.Where("id = ? AND user_id = ?", req.AddressID, userID).First(&address).Error; err != nil {
 		return nil, errors.New("地址不存在")
 	}
 	
-	// 获取购物车项
+	// 获取购物车项（按商品ID排序，保证并发下单时加锁顺序一致，避免死锁）
 	var cartItems []models.CartItem
-	if err := database.DB.Preload("Product").Where("id IN ?", reqStruct.CartItemIDs).Find(&cartItems).Error; err != nil {
+	if err := database.DB.Preload("Product").Preload("Variant").Where("id IN ?", req.CartItemIDs).
+		Order("product_id ASC, variant_id ASC").Find(&cartItems).Error; err != nil {
 		return nil, err
 	}
 	
@@ -54,55 +55,83 @@ func (s *OrderService) CreateOrder(userID uint, req interface{}) (*models.Order,
 		return nil, errors.New("购物车为空")
 	}
 	
+	// 多规格商品必须选择规格（规格上线前加入购物车的商品需重新选择）
+	productIDs := make([]uint, 0, len(cartItems))
+	for _, item := range cartItems {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	hasVariants, err := productsWithVariants(database.DB, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range cartItems {
+		if item.Variant == nil && hasVariants[item.ProductID] {
+			return nil, fmt.Errorf("商品 %s %w", item.Product.Name, ErrVariantRequired)
+		}
+		if !IsPublished(item.Product.Status) {
+			return nil, fmt.Errorf("商品 %s 已下架", item.Product.Name)
+		}
+		if item.Variant != nil && item.Variant.Status != "active" {
+			return nil, fmt.Errorf("商品 %s 规格 %s 已下架", item.Product.Name, item.Variant.Name)
+		}
+	}
+	
 	// 计算总金额并创建订单
+	// 生成订单号
+	orderNo := fmt.Sprintf("ORD%d%d", time.Now().Unix(), userID)
+	
 	var order *models.Order
-	err := database.Transaction(func(tx *gorm.DB) error {
+	var deducted []models.Product
+	err = database.Transaction(func(tx *gorm.DB) error {
 		// 计算总金额
 		var totalAmount float64
 		var orderItems []models.OrderItem
 		
 		for _, item := range cartItems {
-			// 检查库存
-			if item.Product.Stock < item.Quantity {
-				return fmt.Errorf("商品 %s 库存不足", item.Product.Name)
+			// 条件扣减库存：仅当库存充足时才更新，由数据库保证原子性，
+			// 不依赖事务开始前读取的库存快照
+			meta := stockMovementMeta{Reason: models.StockReasonSale, RefID: orderNo, ActorID: userID, VariantID: variantIDOf(item.VariantID)}
+			product, err := deductStock(tx, item.ProductID, item.Quantity, meta)
+			if err != nil {
+				if errors.Is(err, ErrInsufficientStock) {
+					return fmt.Errorf("商品 %s %w", item.Product.Name, err)
+				}
+				return err
 			}
+			deducted = append(deducted, *product)
 			
-			subTotal := item.Product.Price * float64(item.Quantity)
-			totalAmount += subTotal
-			
-			// 创建订单项
+			// 创建订单项（多规格商品按规格的价格、SKU和图片生成快照）
 			orderItem := models.OrderItem{
-				ProductID:    item.ProductID,
-				Quantity:     item.Quantity,
-				Price:        item.Product.Price,
-				SubTotal:     subTotal,
-				ProductName:  item.Product.Name,
-				ProductSKU:   item.Product.SKU,
+				ProductID:   item.ProductID,
+				VariantID:   item.VariantID,
+				Quantity:    item.Quantity,
+				Price:       item.Product.Price,
+				ProductName: item.Product.Name,
+				ProductSKU:  item.Product.SKU,
 			}
-			orderItems = append(orderItems, orderItem)
-			
-			// 扣减库存
-			if err := tx.Model(&models.Product{}).Where("id = ?", item.ProductID).
-				UpdateColumn("stock", gorm.Expr("stock - ?", item.Quantity)).Error; err != nil {
-				return err
+			if item.Variant != nil {
+				orderItem.Price = item.Variant.Price
+				orderItem.ProductSKU = item.Variant.SKU
+				orderItem.ProductImage = item.Variant.Image
+				orderItem.VariantName = item.Variant.Name
 			}
+			orderItem.SubTotal = orderItem.Price * float64(item.Quantity)
+			totalAmount += orderItem.SubTotal
+			orderItems = append(orderItems, orderItem)
 		}
 		
-		// 生成订单号
-		orderNo := fmt.Sprintf("ORD%d%d", time.Now().Unix(), userID)
-		
 		// 创建订单
 		order = &models.Order{
 			OrderNo:         orderNo,
 			UserID:          userID,
 			TotalAmount:     totalAmount,
 			Status:          "pending",
-			PaymentMethod:   reqStruct.PaymentMethod,
+			PaymentMethod:   req.PaymentMethod,
 			PaymentStatus:   "unpaid",
 			ReceiverName:    address.Name,
 			ReceiverPhone:   address.Phone,
 			ReceiverAddress: fmt.Sprintf("%s%s%s%s", address.Province, address.City, address.District, address.Detail),
-			Remark:          reqStruct.Remark,
+			Remark:          req.Remark,
 		}
 		
 		if err := tx.Create(order).Error; err != nil {
@@ -117,8 +146,13 @@ func (s *OrderService) CreateOrder(userID uint, req interface{}) (*models.Order,
 			return err
 		}
 		
+		// 多仓分配，无法由单仓满足时拆分为多个发货单
+		if err := allocateShipments(tx, order, orderItems, address.Province); err != nil {
+			return err
+		}
+		
 		// 删除购物车项
-		if err := tx.Delete(&models.CartItem{}, reqStruct.CartItemIDs).Error; err != nil {
+		if err := tx.Delete(&models.CartItem{}, req.CartItemIDs).Error; err != nil {
 			return err
 		}
 		
@@ -129,10 +163,106 @@ func (s *OrderService) CreateOrder(userID uint, req interface{}) (*models.Order,
 		return nil, err
 	}
 	
+	// 事务提交后再清除商品缓存、检查补货阈值，避免回滚的扣减触发预警
+	invalidateProductCache(productIDs...)
+	alertLowStock(deducted...)
+	// 售罄的商品从搜索结果中移除
+	var soldOut []uint
+	for _, p := range deducted {
+		if p.Stock == 0 {
+			soldOut = append(soldOut, p.ID)
+		}
+	}
+	indexProductsAsync(soldOut...)
+	go attributeSearchPurchase(userID, order.ID, productIDs)
+	
 	logger.Info("创建订单成功", zap.Uint("user_id", userID), zap.Uint("order_id", order.ID))
 	return order, nil
 }
 
+// deductStock 原子扣减库存（stock >= quantity 时才扣减，库存归零时标记为缺货），并记录库存流水
+// 多规格商品同时条件扣减规格库存；返回扣减后的库存与补货阈值，供事务提交后做低库存预警
+func deductStock(tx *gorm.DB, productID uint, quantity int, meta stockMovementMeta) (*models.Product, error) {
+	var product models.Product
+	result := tx.Model(&product).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "name"}, {Name: "stock"}, {Name: "reorder_threshold"}}}).
+		Where("id = ? AND stock >= ?", productID, quantity).
+		UpdateColumn("stock", gorm.Expr("stock - ?", quantity))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInsufficientStock
+	}
+	
+	if meta.VariantID != 0 {
+		result := tx.Model(&models.ProductVariant{}).
+			Where("id = ? AND product_id = ? AND stock >= ?", meta.VariantID, productID, quantity).
+			UpdateColumn("stock", gorm.Expr("stock - ?", quantity))
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil, ErrInsufficientStock
+		}
+	}
+
+	if product.Stock == 0 {
+		if err := tx.Model(&models.Product{}).Where("id = ? AND status = ?", productID, "active").
+			UpdateColumn("status", "out_of_stock").Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if err := recordStockMovement(tx, productID, product.Stock+quantity, product.Stock, meta); err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// restoreStock 回补库存（缺货商品恢复为在售，多规格商品同时回补规格库存），并记录库存流水
+// 返回商品是否由缺货恢复为在售，供事务提交后发送到货通知
+func restoreStock(tx *gorm.DB, productID uint, quantity int, meta stockMovementMeta) (bool, error) {
+	productQuantity := quantity
+	if meta.VariantID != 0 {
+		// 先锁商品再读规格状态，与停用规格时的加锁顺序一致；停用规格的库存不计入商品总库存
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Find(&models.Product{}, productID).Error; err != nil {
+			return false, err
+		}
+		var variant models.ProductVariant
+		if err := tx.Select("status").Where("id = ?", meta.VariantID).Find(&variant).Error; err != nil {
+			return false, err
+		}
+		if variant.Status != "active" {
+			productQuantity = 0
+		}
+	}
+
+	var product models.Product
+	if err := tx.Model(&product).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "stock"}, {Name: "status"}}}).
+		Where("id = ?", productID).
+		UpdateColumn("stock", gorm.Expr("stock + ?", productQuantity)).Error; err != nil {
+		return false, err
+	}
+	if meta.VariantID != 0 {
+		if err := tx.Model(&models.ProductVariant{}).Where("id = ?", meta.VariantID).
+			UpdateColumn("stock", gorm.Expr("stock + ?", quantity)).Error; err != nil {
+			return false, err
+		}
+	}
+
+	restocked := product.Status == "out_of_stock" && product.Stock > 0
+	if restocked {
+		if err := tx.Model(&models.Product{}).Where("id = ?", productID).
+			UpdateColumn("status", "active").Error; err != nil {
+			return false, err
+		}
+	}
+
+	return restocked, recordStockMovement(tx, productID, product.Stock-productQuantity, product.Stock, meta)
+}
+
 // GetUserOrders 获取用户订单列表
 func (s *OrderService) GetUserOrders(userID uint, page, pageSize int, status string) ([]models.Order, int64, error) {
 	query := database.DB.Model(&models.Order{}).Where("user_id = ?", userID)
@@ -161,7 +291,7 @@ func (s *OrderService) GetUserOrders(userID uint, page, pageSize int, status str
 // GetOrder 获取订单详情
 func (s *OrderService) GetOrder(orderID, userID uint) (*models.Order, error) {
 	var order models.Order
-	if err := database.DB.Preload("OrderItems.Product").
+	if err := database.DB.Preload("OrderItems.Product").Preload("Shipments.Items").
 		Where("id = ? AND user_id = ?", orderID, userID).
 		First(&order).Error; err != nil {
 		return nil, err
@@ -171,7 +301,8 @@ func (s *OrderService) GetOrder(orderID, userID uint) (*models.Order, error) {
 
 // CancelOrder 取消订单
 func (s *OrderService) CancelOrder(orderID, userID uint) error {
-	return database.Transaction(func(tx *gorm.DB) error {
+	var restored, restocked []uint
+	err := database.Transaction(func(tx *gorm.DB) error {
 		var order models.Order
 		if err := tx.Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
 			return err
@@ -182,13 +313,19 @@ func (s *OrderService) CancelOrder(orderID, userID uint) error {
 			return errors.New("订单状态不允许取消")
 		}
 		
-		// 更新订单状态
+		// 条件更新订单状态，并发取消时只有一个请求能从待支付转为已取消，避免重复回补库存
 		now := time.Now()
-		if err := tx.Model(&order).Updates(map[string]interface{}{
-			"status":       "cancelled",
-			"cancelled_at": &now,
-		}).Error; err != nil {
-			return err
+		result := tx.Model(&models.Order{}).
+			Where("id = ? AND status = ?", orderID, "pending").
+			Updates(map[string]interface{}{
+				"status":       "cancelled",
+				"cancelled_at": &now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("订单状态不允许取消")
 		}
 		
 		// 恢复库存
@@ -198,15 +335,37 @@ func (s *OrderService) CancelOrder(orderID, userID uint) error {
 		}
 		
 		for _, item := range orderItems {
-			if err := tx.Model(&models.Product{}).Where("id = ?", item.ProductID).
-				UpdateColumn("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
+			meta := stockMovementMeta{Reason: models.StockReasonCancel, RefID: order.OrderNo, ActorID: userID, VariantID: variantIDOf(item.VariantID)}
+			back, err := restoreStock(tx, item.ProductID, item.Quantity, meta)
+			if err != nil {
 				return err
 			}
+			restored = append(restored, item.ProductID)
+			if back {
+				restocked = append(restocked, item.ProductID)
+			}
+		}
+		
+		// 退回仓库库存
+		if err := releaseShipments(tx, orderID, stockMovementMeta{Reason: models.StockReasonCancel, RefID: order.OrderNo, ActorID: userID}); err != nil {
+			return err
 		}
 		
 		logger.Info("取消订单成功", zap.Uint("order_id", orderID))
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	
+	invalidateProductCache(restored...)
+	indexProductsAsync(restocked...)
+
+	// 缺货商品因取消订单恢复在售，通知订阅者
+	for _, productID := range restocked {
+		notifyBackInStock(productID, 0)
+	}
+	return nil
 }
 
 // ConfirmReceipt 确认收货
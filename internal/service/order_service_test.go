@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createCheckoutBuyer 创建一个带收货地址和购物车项的测试买家
+func createCheckoutBuyer(t *testing.T, tag string, productID uint, quantity int) (uint, *CreateOrderRequest) {
+	t.Helper()
+
+	user := models.User{
+		Username: "buyer_" + tag,
+		Email:    "buyer_" + tag + "@example.com",
+		Password: "password123",
+		Role:     "user",
+		Status:   "active",
+	}
+	require.NoError(t, database.DB.Create(&user).Error)
+
+	address := models.Address{
+		UserID:   user.ID,
+		Name:     "测试买家",
+		Phone:    "13800138000",
+		Province: "广东省",
+		City:     "深圳市",
+		District: "南山区",
+		Detail:   "科技园1号",
+	}
+	require.NoError(t, database.DB.Create(&address).Error)
+
+	cart := models.Cart{UserID: user.ID}
+	require.NoError(t, database.DB.Create(&cart).Error)
+
+	item := models.CartItem{CartID: cart.ID, ProductID: productID, Quantity: quantity, Selected: true}
+	require.NoError(t, database.DB.Create(&item).Error)
+
+	return user.ID, &CreateOrderRequest{
+		AddressID:     address.ID,
+		CartItemIDs:   []uint{item.ID},
+		PaymentMethod: "alipay",
+	}
+}
+
+// TestCreateOrderNoOversell 并发下单压测：数百个买家同时抢购低库存商品，库存不能为负
+func TestCreateOrderNoOversell(t *testing.T) {
+	setupTest()
+
+	orderService := NewOrderService()
+
+	const (
+		initialStock = 10
+		buyers       = 300
+	)
+
+	run := time.Now().UnixNano()
+	product := models.Product{
+		Name:   "限量抢购商品",
+		Price:  99,
+		Stock:  initialStock,
+		SKU:    fmt.Sprintf("OVERSELL%d", run),
+		Status: "active",
+	}
+	require.NoError(t, database.DB.Create(&product).Error)
+
+	type checkout struct {
+		userID uint
+		req    *CreateOrderRequest
+	}
+	checkouts := make([]checkout, buyers)
+	for i := 0; i < buyers; i++ {
+		userID, req := createCheckoutBuyer(t, fmt.Sprintf("%d_%d", run, i), product.ID, 1)
+		checkouts[i] = checkout{userID: userID, req: req}
+	}
+
+	// 所有协程就绪后同时放行，尽量制造竞争
+	var (
+		wg          sync.WaitGroup
+		start       = make(chan struct{})
+		succeeded   int64
+		outOfStock  int64
+		otherErrors int64
+	)
+	for _, co := range checkouts {
+		wg.Add(1)
+		go func(co checkout) {
+			defer wg.Done()
+			<-start
+			_, err := orderService.CreateOrder(co.userID, co.req)
+			switch {
+			case err == nil:
+				atomic.AddInt64(&succeeded, 1)
+			case errors.Is(err, ErrInsufficientStock):
+				atomic.AddInt64(&outOfStock, 1)
+			default:
+				atomic.AddInt64(&otherErrors, 1)
+				t.Logf("unexpected checkout error: %v", err)
+			}
+		}(co)
+	}
+	close(start)
+	wg.Wait()
+
+	var updated models.Product
+	require.NoError(t, database.DB.First(&updated, product.ID).Error)
+
+	assert.GreaterOrEqual(t, updated.Stock, 0, "库存不能为负")
+	assert.Equal(t, int64(0), otherErrors)
+	assert.Equal(t, int64(initialStock), succeeded)
+	assert.Equal(t, int64(buyers-initialStock), outOfStock)
+	assert.Equal(t, 0, updated.Stock)
+	assert.Equal(t, "out_of_stock", updated.Status)
+
+	// 已售数量必须与订单项一致
+	var sold int64
+	database.DB.Model(&models.OrderItem{}).Where("product_id = ?", product.ID).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&sold)
+	assert.Equal(t, int64(initialStock), sold)
+}
+
+// TestCancelOrderConcurrent 并发取消同一订单：只有一个请求成功，库存只回补一次
+func TestCancelOrderConcurrent(t *testing.T) {
+	setupTest()
+
+	orderService := NewOrderService()
+
+	const (
+		initialStock = 5
+		quantity     = 2
+		cancels      = 20
+	)
+
+	run := time.Now().UnixNano()
+	product := models.Product{
+		Name:   "并发取消商品",
+		Price:  49,
+		Stock:  initialStock,
+		SKU:    fmt.Sprintf("CANCEL%d", run),
+		Status: "active",
+	}
+	require.NoError(t, database.DB.Create(&product).Error)
+
+	userID, req := createCheckoutBuyer(t, fmt.Sprintf("cancel_%d", run), product.ID, quantity)
+	order, err := orderService.CreateOrder(userID, req)
+	require.NoError(t, err)
+
+	var (
+		wg        sync.WaitGroup
+		start     = make(chan struct{})
+		succeeded int64
+	)
+	for i := 0; i < cancels; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if err := orderService.CancelOrder(order.ID, userID); err == nil {
+				atomic.AddInt64(&succeeded, 1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), succeeded)
+
+	var updated models.Product
+	require.NoError(t, database.DB.First(&updated, product.ID).Error)
+	assert.Equal(t, initialStock, updated.Stock, "库存只能回补一次")
+
+	var cancelled models.Order
+	require.NoError(t, database.DB.First(&cancelled, order.ID).Error)
+	assert.Equal(t, "cancelled", cancelled.Status)
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"github.com/shoppee/ecommerce/pkg/singleflight"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 商品详情缓存：详情序列化后整体存入 Redis，不存在的商品短暂缓存占位值，避免反复穿透到数据库。
+// 每次失效递增商品的失效代数，回填时代数已变说明读库期间发生过写入，放弃回填以免写回旧数据
+const (
+	productCacheTTL     = time.Hour
+	productCacheJitter  = 10 * time.Minute // 过期时间随机延长，避免同批缓存同时过期
+	productMissingTTL   = time.Minute
+	productCacheMissing = "-"            // 商品不存在的占位值
+	productCacheGenTTL  = 24 * time.Hour // 失效代数保留时间，须远长于一次读库耗时
+)
+
+// ProductDetail 商品详情（含分类、规格、属性、图片及评分汇总）
+type ProductDetail struct {
+	models.Product
+	Rating RatingSummary `json:"rating"`
+}
+
+// RatingSummary 商品评分汇总，只统计已发布的评价
+type RatingSummary struct {
+	Average float64  `json:"average"` // 平均评分，保留一位小数
+	Count   int64    `json:"count"`
+	Stars   [5]int64 `json:"stars"` // 1~5 星的评价数
+}
+
+// 同一实例内相同商品的并发未命中只查询一次数据库
+var productLoads singleflight.Group
+
+// 仅当失效代数与读库前一致时才回填缓存
+var fillProductCacheScript = redis.NewScript(`
+if (redis.call("GET", KEYS[2]) or "") ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+return 1
+`)
+
+func productCacheKey(id uint) string {
+	return fmt.Sprintf("product:%d", id)
+}
+
+func productCacheGenKey(id uint) string {
+	return fmt.Sprintf("product:%d:gen", id)
+}
+
+// getProductDetail 读取商品详情：先查缓存，未命中时合并并发请求查库并回填
+func (s *ProductService) getProductDetail(id uint) (*ProductDetail, error) {
+	if detail, found, ok := readProductCache(id); ok {
+		if !found {
+			return nil, ErrProductNotFound
+		}
+		return detail, nil
+	}
+
+	v, err, _ := productLoads.Do(strconv.FormatUint(uint64(id), 10), func() (interface{}, error) {
+		gen, genErr := productCacheGen(id)
+		detail, err := s.queryProductDetail(id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if genErr == nil {
+				fillProductCache(id, gen, productCacheMissing, productMissingTTL)
+			}
+			return nil, ErrProductNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if genErr == nil {
+			if data, err := json.Marshal(detail); err == nil {
+				fillProductCache(id, gen, string(data), jitterTTL(productCacheTTL, productCacheJitter))
+			}
+		}
+		return detail, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 合并的请求共享同一份结果，复制后再由调用方替换为实时浏览量
+	detail := *v.(*ProductDetail)
+	return &detail, nil
+}
+
+// queryProductDetail 从数据库加载商品详情及评分汇总
+func (s *ProductService) queryProductDetail(id uint) (*ProductDetail, error) {
+	var detail ProductDetail
+	if err := s.detailQuery().First(&detail.Product, id).Error; err != nil {
+		return nil, err
+	}
+
+	var rows []ratingCount
+	if err := database.DB.Model(&models.Review{}).
+		Select("rating, COUNT(*) AS count").
+		Where("product_id = ? AND status = ?", id, "published").
+		Group("rating").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	detail.Rating = summarizeRatings(rows)
+	return &detail, nil
+}
+
+type ratingCount struct {
+	Rating int
+	Count  int64
+}
+
+// summarizeRatings 由各星级的评价数汇总平均评分
+func summarizeRatings(rows []ratingCount) RatingSummary {
+	var summary RatingSummary
+	var total int64
+	for _, r := range rows {
+		if r.Rating < 1 || r.Rating > 5 {
+			continue
+		}
+		summary.Stars[r.Rating-1] += r.Count
+		summary.Count += r.Count
+		total += int64(r.Rating) * r.Count
+	}
+	if summary.Count > 0 {
+		summary.Average = math.Round(float64(total)/float64(summary.Count)*10) / 10
+	}
+	return summary
+}
+
+// readProductCache 读取缓存；ok 为 false 表示未命中（含 Redis 不可用），found 为 false 表示商品不存在
+func readProductCache(id uint) (detail *ProductDetail, found, ok bool) {
+	if database.RedisClient == nil {
+		return nil, false, false
+	}
+	data, err := database.RedisClient.Get(context.Background(), productCacheKey(id)).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logger.Warn("读取商品缓存失败", zap.Uint("product_id", id), zap.Error(err))
+		}
+		return nil, false, false
+	}
+	if data == productCacheMissing {
+		return nil, false, true
+	}
+
+	detail = &ProductDetail{}
+	if err := json.Unmarshal([]byte(data), detail); err != nil {
+		logger.Warn("商品缓存格式错误", zap.Uint("product_id", id), zap.Error(err))
+		return nil, false, false
+	}
+	return detail, true, true
+}
+
+// productCacheGen 读取商品缓存的失效代数，从未失效过时为空
+func productCacheGen(id uint) (string, error) {
+	if database.RedisClient == nil {
+		return "", errors.New("Redis 未初始化")
+	}
+	gen, err := database.RedisClient.Get(context.Background(), productCacheGenKey(id)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return gen, err
+}
+
+// fillProductCache 回填缓存，读库期间缓存被失效过则放弃
+func fillProductCache(id uint, gen, value string, ttl time.Duration) {
+	keys := []string{productCacheKey(id), productCacheGenKey(id)}
+	if err := fillProductCacheScript.Run(context.Background(), database.RedisClient, keys,
+		gen, value, ttl.Milliseconds()).Err(); err != nil {
+		logger.Warn("写入商品缓存失败", zap.Uint("product_id", id), zap.Error(err))
+	}
+}
+
+// jitterTTL 在基础过期时间上随机延长 [0, jitter)
+func jitterTTL(ttl, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Int63n(int64(jitter)))
+}
+
+// invalidateProductCache 清除商品详情缓存（在写入事务提交后调用），并递增失效代数使进行中的回填作废
+func invalidateProductCache(productIDs ...uint) {
+	if database.RedisClient == nil || len(productIDs) == 0 {
+		return
+	}
+	ctx := context.Background()
+	pipe := database.RedisClient.Pipeline()
+	for _, id := range productIDs {
+		pipe.Incr(ctx, productCacheGenKey(id))
+		pipe.Expire(ctx, productCacheGenKey(id), productCacheGenTTL)
+		pipe.Del(ctx, productCacheKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Warn("清除商品缓存失败", zap.Int("products", len(productIDs)), zap.Error(err))
+	}
+}
+
+// invalidateCategoryProducts 清除分类下全部商品的详情缓存（商品详情中含所属分类），categories 为分类ID列表或子查询
+func invalidateCategoryProducts(categories interface{}) {
+	var ids []uint
+	if err := database.DB.Model(&models.Product{}).
+		Where("category_id IN (?)", categories).
+		Pluck("id", &ids).Error; err != nil {
+		logger.Warn("查询分类商品失败，商品缓存未清除", zap.Error(err))
+		return
+	}
+	invalidateProductCache(ids...)
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSummarizeRatings 测试评分汇总
+func TestSummarizeRatings(t *testing.T) {
+	summary := summarizeRatings([]ratingCount{
+		{Rating: 5, Count: 6},
+		{Rating: 4, Count: 3},
+		{Rating: 1, Count: 1},
+		{Rating: 0, Count: 9}, // 非法评分忽略
+	})
+
+	assert.Equal(t, int64(10), summary.Count)
+	assert.Equal(t, [5]int64{1, 0, 0, 3, 6}, summary.Stars)
+	assert.Equal(t, 4.3, summary.Average)
+
+	assert.Equal(t, RatingSummary{}, summarizeRatings(nil))
+}
+
+// TestJitterTTL 测试缓存过期时间随机延长
+func TestJitterTTL(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		ttl := jitterTTL(time.Hour, 10*time.Minute)
+		assert.GreaterOrEqual(t, ttl, time.Hour)
+		assert.Less(t, ttl, time.Hour+10*time.Minute)
+	}
+	assert.Equal(t, time.Hour, jitterTTL(time.Hour, 0))
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"gorm.io/gorm"
+)
+
+// maxCompareProducts 一次最多对比的商品数
+const maxCompareProducts = 4
+
+// 对比中的库存状态
+const (
+	StockStatusInStock    = "in_stock"
+	StockStatusLowStock   = "low_stock"
+	StockStatusOutOfStock = "out_of_stock"
+)
+
+// ProductComparison 商品对比结果，Products 与每行 Values 的顺序一致
+type ProductComparison struct {
+	Products   []ComparedProduct `json:"products"`
+	Attributes []ComparisonRow   `json:"attributes"`
+}
+
+// ComparedProduct 参与对比的商品
+type ComparedProduct struct {
+	models.Product
+	MinPrice    float64 `json:"min_price"` // 多规格商品为各规格最低价，否则等于售价
+	MaxPrice    float64 `json:"max_price"`
+	Rating      float64 `json:"rating"` // 已发布评价的平均评分，保留一位小数
+	ReviewCount int64   `json:"review_count"`
+	StockStatus string  `json:"stock_status"` // in_stock, low_stock, out_of_stock
+}
+
+// ComparisonRow 对比表中的一行属性，按属性编码对齐不同分类的商品
+type ComparisonRow struct {
+	Code    string    `json:"code"`
+	Name    string    `json:"name"`
+	Type    string    `json:"type"`
+	Unit    string    `json:"unit"`
+	Values  []*string `json:"values"`  // 与 Products 一一对应，商品无该属性时为 null
+	Differs bool      `json:"differs"` // 各商品的值是否不同，便于前端「只看不同」
+}
+
+// CompareProducts 商品对比，按请求顺序返回 2~4 个商品的价格、评分、库存状态、规格及对齐后的属性
+func (s *ProductService) CompareProducts(ids []uint) (*ProductComparison, error) {
+	ids = uniqueIDs(ids)
+	if len(ids) < 2 || len(ids) > maxCompareProducts {
+		return nil, fmt.Errorf("请选择 2~%d 个商品进行对比", maxCompareProducts)
+	}
+
+	var products []models.Product
+	if err := database.DB.Where("id IN ? AND status IN ?", ids, publishedStatuses).
+		Preload("Category").
+		Preload("Media", "sort = ?", 0).
+		Preload("Media.Media.Thumbnails").
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("sort ASC, id ASC") }).
+		Preload("Options.Values", func(db *gorm.DB) *gorm.DB { return db.Order("sort ASC, id ASC") }).
+		Preload("Variants", "status = ?", "active").
+		Preload("Variants.OptionValues").
+		Preload("Attributes.Attribute").
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
+	if len(products) != len(ids) {
+		return nil, ErrProductNotFound
+	}
+
+	ratings, err := productRatings(database.DB, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]models.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+	ordered := make([]models.Product, 0, len(ids))
+	for _, id := range ids {
+		ordered = append(ordered, byID[id])
+	}
+
+	comparison := &ProductComparison{
+		Products:   make([]ComparedProduct, 0, len(ordered)),
+		Attributes: buildComparisonRows(ordered),
+	}
+	for _, p := range ordered {
+		item := ComparedProduct{
+			Product:     p,
+			Rating:      ratings[p.ID].Rating,
+			ReviewCount: ratings[p.ID].Count,
+			StockStatus: stockStatus(p),
+		}
+		item.MinPrice, item.MaxPrice = priceRange(p)
+		// 属性已整理进对比行，不再重复返回
+		item.Attributes = nil
+		comparison.Products = append(comparison.Products, item)
+	}
+	return comparison, nil
+}
+
+type productRating struct {
+	ProductID uint
+	Rating    float64
+	Count     int64
+}
+
+// productRatings 统计商品已发布评价的平均分与数量
+func productRatings(db *gorm.DB, ids []uint) (map[uint]productRating, error) {
+	var rows []productRating
+	if err := db.Model(&models.Review{}).
+		Select("product_id, AVG(rating) AS rating, COUNT(*) AS count").
+		Where("product_id IN ? AND status = ?", ids, "published").
+		Group("product_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	ratings := make(map[uint]productRating, len(rows))
+	for _, r := range rows {
+		r.Rating = math.Round(r.Rating*10) / 10
+		ratings[r.ProductID] = r
+	}
+	return ratings, nil
+}
+
+// priceRange 商品价格区间，多规格商品取有效规格的最低/最高价
+func priceRange(p models.Product) (float64, float64) {
+	if len(p.Variants) == 0 {
+		return p.Price, p.Price
+	}
+	low, high := p.Variants[0].Price, p.Variants[0].Price
+	for _, v := range p.Variants[1:] {
+		low = math.Min(low, v.Price)
+		high = math.Max(high, v.Price)
+	}
+	return low, high
+}
+
+// stockStatus 商品库存状态，库存低于补货阈值时为 low_stock
+func stockStatus(p models.Product) string {
+	switch {
+	case p.Stock <= 0 || p.Status == "out_of_stock":
+		return StockStatusOutOfStock
+	case p.ReorderThreshold > 0 && p.Stock < p.ReorderThreshold:
+		return StockStatusLowStock
+	default:
+		return StockStatusInStock
+	}
+}
+
+// buildComparisonRows 按属性编码对齐各商品的属性值，行按属性排序值、编码排序
+func buildComparisonRows(products []models.Product) []ComparisonRow {
+	rows := make(map[string]*ComparisonRow)
+	sorts := make(map[string]int)
+	for i, p := range products {
+		for _, v := range p.Attributes {
+			if v.Attribute == nil {
+				continue
+			}
+			row, ok := rows[v.Attribute.Code]
+			if !ok {
+				row = &ComparisonRow{
+					Code:   v.Attribute.Code,
+					Name:   v.Attribute.Name,
+					Type:   v.Attribute.Type,
+					Unit:   v.Attribute.Unit,
+					Values: make([]*string, len(products)),
+				}
+				rows[v.Attribute.Code] = row
+				sorts[v.Attribute.Code] = v.Attribute.Sort
+			}
+			value := v.Value
+			row.Values[i] = &value
+		}
+	}
+
+	result := make([]ComparisonRow, 0, len(rows))
+	for _, row := range rows {
+		row.Differs = valuesDiffer(row.Values)
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if sorts[result[i].Code] != sorts[result[j].Code] {
+			return sorts[result[i].Code] < sorts[result[j].Code]
+		}
+		return result[i].Code < result[j].Code
+	})
+	return result
+}
+
+// valuesDiffer 判断一行属性值是否存在差异（缺失也视为不同）
+func valuesDiffer(values []*string) bool {
+	for _, v := range values[1:] {
+		if (v == nil) != (values[0] == nil) || (v != nil && *v != *values[0]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuildComparisonRows 测试不同分类商品的属性按编码对齐
+func TestBuildComparisonRows(t *testing.T) {
+	brand := &models.Attribute{Code: "brand", Name: "品牌", Sort: 1}
+	screen := &models.Attribute{Code: "screen", Name: "屏幕尺寸", Type: models.AttributeTypeNumber, Unit: "英寸", Sort: 2}
+	// 另一分类下同编码的属性
+	brand2 := &models.Attribute{Code: "brand", Name: "品牌", Sort: 1}
+
+	products := []models.Product{
+		{Attributes: []models.ProductAttributeValue{
+			{Value: "6.1", Attribute: screen},
+			{Value: "Apple", Attribute: brand},
+		}},
+		{Attributes: []models.ProductAttributeValue{
+			{Value: "Apple", Attribute: brand2},
+		}},
+	}
+
+	rows := buildComparisonRows(products)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, "brand", rows[0].Code)
+	assert.Equal(t, "Apple", *rows[0].Values[0])
+	assert.Equal(t, "Apple", *rows[0].Values[1])
+	assert.False(t, rows[0].Differs)
+
+	assert.Equal(t, "screen", rows[1].Code)
+	assert.Equal(t, "6.1", *rows[1].Values[0])
+	assert.Nil(t, rows[1].Values[1], "缺失的属性为 null")
+	assert.True(t, rows[1].Differs)
+}
+
+// TestStockStatusAndPriceRange 测试库存状态与价格区间
+func TestStockStatusAndPriceRange(t *testing.T) {
+	assert.Equal(t, StockStatusInStock, stockStatus(models.Product{Stock: 10, Status: "active"}))
+	assert.Equal(t, StockStatusLowStock, stockStatus(models.Product{Stock: 3, ReorderThreshold: 5, Status: "active"}))
+	assert.Equal(t, StockStatusOutOfStock, stockStatus(models.Product{Stock: 0, Status: "active"}))
+
+	low, high := priceRange(models.Product{Price: 99})
+	assert.Equal(t, 99.0, low)
+	assert.Equal(t, 99.0, high)
+
+	low, high = priceRange(models.Product{Price: 99, Variants: []models.ProductVariant{{Price: 109}, {Price: 89}, {Price: 129}}})
+	assert.Equal(t, 89.0, low)
+	assert.Equal(t, 129.0, high)
+}
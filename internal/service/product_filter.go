@@ -0,0 +1,312 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidFilter 商品筛选条件无效
+var ErrInvalidFilter = errors.New("无效的筛选条件")
+
+// 筛选项统计时需要排除自身条件的筛选维度
+const (
+	facetPrice   = "price"
+	facetInStock = "in_stock"
+)
+
+// ProductFacets 商品列表的筛选项统计
+type ProductFacets struct {
+	Price      RangeFacet       `json:"price"`
+	InStock    int64            `json:"in_stock"` // 有货商品数
+	Attributes []AttributeFacet `json:"attributes"`
+}
+
+// RangeFacet 数值区间统计
+type RangeFacet struct {
+	Min *float64 `json:"min"`
+	Max *float64 `json:"max"`
+}
+
+// FacetValue 单个取值及匹配商品数
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// AttributeFacet 单个属性的筛选项统计：文本/布尔属性返回各取值计数，数值属性返回区间
+type AttributeFacet struct {
+	Code   string       `json:"code"`
+	Name   string       `json:"name"`
+	Type   string       `json:"type"`
+	Unit   string       `json:"unit,omitempty"`
+	Values []FacetValue `json:"values,omitempty"`
+	Range  *RangeFacet  `json:"range,omitempty"`
+}
+
+// attributeFilter 解析后的单个属性筛选条件
+type attributeFilter struct {
+	Code   string
+	Type   string
+	Values []string
+	Min    *float64
+	Max    *float64
+}
+
+// filterProducts 按列表请求构建商品筛选查询，skip 指定的维度不参与筛选（用于统计该维度自身的筛选项）
+func (s *ProductService) filterProducts(req *ProductListRequest, skip string) (*gorm.DB, error) {
+	query := database.DB.Model(&models.Product{})
+
+	// 分类筛选（包含子分类的商品）
+	if req.CategoryID > 0 {
+		query = query.Where("products.category_id IN (?)", categorySubtree(req.CategoryID))
+	}
+
+	// 关键词搜索
+	if req.Keyword != "" {
+		query = query.Where("products.name LIKE ? OR products.description LIKE ?", "%"+req.Keyword+"%", "%"+req.Keyword+"%")
+	}
+
+	// 状态筛选：未指定或无权查看未上架商品时，只列出在售和缺货商品
+	if req.Status != "" && (req.ShowUnpublished || IsPublished(req.Status)) {
+		query = query.Where("products.status = ?", req.Status)
+	} else {
+		query = query.Where("products.status IN ?", publishedStatuses)
+	}
+
+	// 价格区间
+	if skip != facetPrice {
+		if req.MinPrice != nil {
+			query = query.Where("products.price >= ?", *req.MinPrice)
+		}
+		if req.MaxPrice != nil {
+			query = query.Where("products.price <= ?", *req.MaxPrice)
+		}
+	}
+
+	// 仅有货
+	if req.InStock && skip != facetInStock {
+		query = query.Where("products.stock > 0")
+	}
+
+	// 标签筛选（任一匹配）
+	if tags := splitCodes(req.Tags); len(tags) > 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM product_tags pt JOIN tags t ON t.id = pt.tag_id "+
+			"WHERE pt.product_id = products.id AND t.code IN ?)", tags)
+	}
+
+	// 合集筛选
+	if req.Collection != "" {
+		if req.collection == nil {
+			collection, err := NewCollectionService().GetCollection(req.Collection)
+			if err != nil {
+				return nil, fmt.Errorf("%w: 合集 %s 不存在", ErrInvalidFilter, req.Collection)
+			}
+			req.collection = collection
+		}
+		query = applyCollectionFilter(query, req.collection)
+	}
+
+	// 属性筛选
+	filters, err := parseAttributeFilters(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range filters {
+		if skip == "attr:"+f.Code {
+			continue
+		}
+		query = applyAttributeFilter(query, f)
+	}
+
+	return query, nil
+}
+
+// splitCodes 拆分逗号分隔的编码列表
+func splitCodes(raw string) []string {
+	var codes []string
+	for _, code := range strings.Split(raw, ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// parseAttributeFilters 结合属性定义解析请求中的属性筛选条件
+func parseAttributeFilters(req *ProductListRequest) ([]attributeFilter, error) {
+	if len(req.Attributes) == 0 {
+		return nil, nil
+	}
+
+	codes := make([]string, 0, len(req.Attributes))
+	for code := range req.Attributes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	query := database.DB.Where("code IN ?", codes)
+	if req.CategoryID > 0 {
+		query = query.Where("category_id = ?", req.CategoryID)
+	}
+	var attributes []models.Attribute
+	if err := query.Find(&attributes).Error; err != nil {
+		return nil, err
+	}
+	types := make(map[string]string, len(attributes))
+	for _, attr := range attributes {
+		types[attr.Code] = attr.Type
+	}
+
+	filters := make([]attributeFilter, 0, len(codes))
+	for _, code := range codes {
+		attrType, ok := types[code]
+		if !ok {
+			return nil, fmt.Errorf("%w: 未知属性 %s", ErrInvalidFilter, code)
+		}
+		f, err := parseAttributeFilter(code, attrType, req.Attributes[code])
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// parseAttributeFilter 解析单个属性的筛选值
+func parseAttributeFilter(code, attrType, raw string) (attributeFilter, error) {
+	f := attributeFilter{Code: code, Type: attrType}
+	raw = strings.TrimSpace(raw)
+
+	if attrType == models.AttributeTypeNumber {
+		lo, hi, found := strings.Cut(raw, "-")
+		if !found {
+			lo, hi = raw, raw
+		}
+		if lo = strings.TrimSpace(lo); lo != "" {
+			n, err := strconv.ParseFloat(lo, 64)
+			if err != nil {
+				return f, fmt.Errorf("%w: 属性 %s 的区间格式应为 min-max", ErrInvalidFilter, code)
+			}
+			f.Min = &n
+		}
+		if hi = strings.TrimSpace(hi); hi != "" {
+			n, err := strconv.ParseFloat(hi, 64)
+			if err != nil {
+				return f, fmt.Errorf("%w: 属性 %s 的区间格式应为 min-max", ErrInvalidFilter, code)
+			}
+			f.Max = &n
+		}
+		if f.Min == nil && f.Max == nil {
+			return f, fmt.Errorf("%w: 属性 %s 的区间不能为空", ErrInvalidFilter, code)
+		}
+		return f, nil
+	}
+
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			f.Values = append(f.Values, v)
+		}
+	}
+	if len(f.Values) == 0 {
+		return f, fmt.Errorf("%w: 属性 %s 的取值不能为空", ErrInvalidFilter, code)
+	}
+	return f, nil
+}
+
+// applyAttributeFilter 追加属性筛选条件
+func applyAttributeFilter(query *gorm.DB, f attributeFilter) *gorm.DB {
+	cond := "SELECT 1 FROM product_attribute_values pav JOIN attributes a ON a.id = pav.attribute_id " +
+		"WHERE pav.product_id = products.id AND a.code = ?"
+	args := []interface{}{f.Code}
+
+	if f.Type == models.AttributeTypeNumber {
+		if f.Min != nil {
+			cond += " AND pav.number_value >= ?"
+			args = append(args, *f.Min)
+		}
+		if f.Max != nil {
+			cond += " AND pav.number_value <= ?"
+			args = append(args, *f.Max)
+		}
+	} else {
+		cond += " AND pav.value IN ?"
+		args = append(args, f.Values)
+	}
+
+	return query.Where("EXISTS ("+cond+")", args...)
+}
+
+// GetProductFacets 统计当前筛选条件下的筛选项：每个维度的统计都排除该维度自身的条件，
+// 这样已选中某个品牌时仍能看到其他品牌的数量
+func (s *ProductService) GetProductFacets(req *ProductListRequest) (*ProductFacets, error) {
+	facets := &ProductFacets{Attributes: make([]AttributeFacet, 0)}
+
+	priceQuery, err := s.filterProducts(req, facetPrice)
+	if err != nil {
+		return nil, err
+	}
+	if err := priceQuery.Select("MIN(products.price) AS min, MAX(products.price) AS max").
+		Scan(&facets.Price).Error; err != nil {
+		return nil, err
+	}
+
+	stockQuery, err := s.filterProducts(req, facetInStock)
+	if err != nil {
+		return nil, err
+	}
+	if err := stockQuery.Where("products.stock > 0").Count(&facets.InStock).Error; err != nil {
+		return nil, err
+	}
+
+	// 属性由分类定义，未指定分类时不返回属性筛选项
+	if req.CategoryID == 0 {
+		return facets, nil
+	}
+
+	var attributes []models.Attribute
+	if err := database.DB.Where("category_id = ? AND filterable = ?", req.CategoryID, true).
+		Order("sort ASC, id ASC").
+		Find(&attributes).Error; err != nil {
+		return nil, err
+	}
+
+	for _, attr := range attributes {
+		query, err := s.filterProducts(req, "attr:"+attr.Code)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Joins("JOIN product_attribute_values pav ON pav.product_id = products.id AND pav.attribute_id = ?", attr.ID)
+
+		facet := AttributeFacet{Code: attr.Code, Name: attr.Name, Type: attr.Type, Unit: attr.Unit}
+		if attr.Type == models.AttributeTypeNumber {
+			facet.Range = &RangeFacet{}
+			if err := query.Select("MIN(pav.number_value) AS min, MAX(pav.number_value) AS max").
+				Scan(facet.Range).Error; err != nil {
+				return nil, err
+			}
+			if facet.Range.Min == nil {
+				continue
+			}
+		} else {
+			if err := query.Select("pav.value AS value, COUNT(*) AS count").
+				Group("pav.value").
+				Order("count DESC, pav.value ASC").
+				Scan(&facet.Values).Error; err != nil {
+				return nil, err
+			}
+			if len(facet.Values) == 0 {
+				continue
+			}
+		}
+		facets.Attributes = append(facets.Attributes, facet)
+	}
+
+	return facets, nil
+}
@@ -0,0 +1,706 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 导入导出文件格式
+const (
+	ProductFileCSV  = "csv"
+	ProductFileJSON = "json"
+)
+
+// 导入行的处理结果
+const (
+	ImportActionCreate    = "create"
+	ImportActionUpdate    = "update"
+	ImportActionUnchanged = "unchanged"
+	ImportActionError     = "error"
+)
+
+// 单次导入的最大行数
+const maxImportRows = 5000
+
+// 分类路径分隔符，如「数码/手机」
+const categoryPathSeparator = "/"
+
+// productFileColumns 导入导出文件的列（CSV 表头，JSON 字段名）
+var productFileColumns = []string{"sku", "name", "description", "category", "price", "orig_price", "stock", "reorder_threshold", "status"}
+
+// ProductImportRow 导入导出的单个商品；除 sku 外留空的字段在更新时保持原值
+type ProductImportRow struct {
+	SKU              string   `json:"sku"`
+	Name             string   `json:"name,omitempty"`
+	Description      *string  `json:"description,omitempty"`
+	Category         string   `json:"category,omitempty"` // 分类名称或完整路径（父分类/子分类）
+	Price            *float64 `json:"price,omitempty"`
+	OrigPrice        *float64 `json:"orig_price,omitempty"`
+	Stock            *int     `json:"stock,omitempty"` // 目标库存，与当前库存的差额记入库存流水
+	ReorderThreshold *int     `json:"reorder_threshold,omitempty"`
+	Status           string   `json:"status,omitempty"` // draft, active, inactive，新建商品为空时为草稿
+}
+
+// ImportRowResult 单行导入结果
+type ImportRowResult struct {
+	Row       int      `json:"row"` // CSV 为文件行号（含表头），JSON 为数组下标 + 1
+	SKU       string   `json:"sku"`
+	Action    string   `json:"action"`
+	ProductID uint     `json:"product_id,omitempty"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// ImportReport 导入报告
+type ImportReport struct {
+	DryRun    bool              `json:"dry_run"`
+	Total     int               `json:"total"`
+	Created   int               `json:"created"`
+	Updated   int               `json:"updated"`
+	Unchanged int               `json:"unchanged"`
+	Failed    int               `json:"failed"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+// ProductExportRequest 导出筛选条件
+type ProductExportRequest struct {
+	Format     string `form:"format" binding:"omitempty,oneof=csv json"`
+	CategoryID uint   `form:"category_id"`
+	Status     string `form:"status"`
+}
+
+// parsedImportRow 解析后的导入行
+type parsedImportRow struct {
+	line   int
+	row    ProductImportRow
+	errors []string
+}
+
+// ImportProducts 从 CSV/JSON 导入商品：按 SKU 新建或更新（已删除的商品会恢复），
+// 每行独立校验、独立事务，返回逐行报告；dryRun 时只校验并给出将执行的操作
+func (s *ProductService) ImportProducts(operatorID uint, format string, r io.Reader, dryRun bool) (*ImportReport, error) {
+	var rows []parsedImportRow
+	var err error
+	switch format {
+	case ProductFileCSV:
+		rows, err = parseProductCSV(r)
+	case ProductFileJSON:
+		rows, err = parseProductJSON(r)
+	default:
+		return nil, errors.New("不支持的文件格式，仅支持 csv、json")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("文件中没有商品数据")
+	}
+	if len(rows) > maxImportRows {
+		return nil, fmt.Errorf("单次最多导入 %d 个商品", maxImportRows)
+	}
+
+	categories, err := loadCategoryIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	// 预加载已有商品（含已删除），用于判断新建或更新
+	skus := make([]string, 0, len(rows))
+	for _, r := range rows {
+		if r.row.SKU != "" {
+			skus = append(skus, r.row.SKU)
+		}
+	}
+	existing := make(map[string]models.Product, len(skus))
+	for start := 0; start < len(skus); start += 500 {
+		end := start + 500
+		if end > len(skus) {
+			end = len(skus)
+		}
+		var products []models.Product
+		if err := database.DB.Unscoped().Where("sku IN ?", skus[start:end]).Find(&products).Error; err != nil {
+			return nil, err
+		}
+		for _, p := range products {
+			existing[p.SKU] = p
+		}
+	}
+
+	report := &ImportReport{DryRun: dryRun, Total: len(rows), Rows: make([]ImportRowResult, 0, len(rows))}
+	seen := make(map[string]int, len(rows))
+	var changed []uint
+
+	for _, r := range rows {
+		result := ImportRowResult{Row: r.line, SKU: r.row.SKU, Errors: r.errors}
+		if r.row.SKU != "" {
+			if first, ok := seen[r.row.SKU]; ok {
+				result.Errors = append(result.Errors, fmt.Sprintf("SKU 与第 %d 行重复", first))
+			} else {
+				seen[r.row.SKU] = r.line
+			}
+		}
+
+		current, exists := existing[r.row.SKU]
+		var categoryID uint
+		if len(result.Errors) == 0 {
+			categoryID, result.Errors = validateImportRow(&r.row, exists, categories)
+		}
+
+		if len(result.Errors) == 0 {
+			var productID uint
+			var action string
+			var err error
+			if exists {
+				productID, action, err = s.applyImportUpdate(operatorID, &current, &r.row, categoryID, dryRun)
+			} else {
+				productID, action, err = s.applyImportCreate(&r.row, categoryID, dryRun)
+			}
+			if err != nil {
+				result.Errors = append(result.Errors, importErrorMessage(err))
+			} else {
+				result.ProductID, result.Action = productID, action
+				if productID > 0 && action != ImportActionUnchanged {
+					changed = append(changed, productID)
+				}
+			}
+		}
+
+		switch {
+		case len(result.Errors) > 0:
+			result.Action = ImportActionError
+			report.Failed++
+		case result.Action == ImportActionCreate:
+			report.Created++
+		case result.Action == ImportActionUpdate:
+			report.Updated++
+		default:
+			report.Unchanged++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	if !dryRun {
+		indexProductsAsync(changed...)
+		if len(changed) > 0 {
+			invalidateCategoryTree()
+		}
+		logger.Info("导入商品完成",
+			zap.Uint("operator_id", operatorID),
+			zap.Int("created", report.Created),
+			zap.Int("updated", report.Updated),
+			zap.Int("failed", report.Failed))
+	}
+	return report, nil
+}
+
+// validateImportRow 校验导入行并解析分类，exists 表示按 SKU 更新已有商品
+func validateImportRow(row *ProductImportRow, exists bool, categories *categoryIndex) (uint, []string) {
+	var errs []string
+	if row.SKU == "" {
+		errs = append(errs, "sku 不能为空")
+	} else if len(row.SKU) > 100 {
+		errs = append(errs, "sku 不能超过100个字符")
+	}
+	if !exists {
+		if row.Name == "" {
+			errs = append(errs, "新商品 name 不能为空")
+		}
+		if row.Price == nil {
+			errs = append(errs, "新商品 price 不能为空")
+		}
+		if row.Category == "" {
+			errs = append(errs, "新商品 category 不能为空")
+		}
+	}
+	if len([]rune(row.Name)) > 200 {
+		errs = append(errs, "name 不能超过200个字符")
+	}
+	if row.Price != nil && *row.Price <= 0 {
+		errs = append(errs, "price 必须大于0")
+	}
+	if row.OrigPrice != nil && *row.OrigPrice < 0 {
+		errs = append(errs, "orig_price 不能为负数")
+	}
+	if row.Stock != nil && *row.Stock < 0 {
+		errs = append(errs, "stock 不能为负数")
+	}
+	if row.ReorderThreshold != nil && *row.ReorderThreshold < 0 {
+		errs = append(errs, "reorder_threshold 不能为负数")
+	}
+	if row.Status != "" && row.Status != "draft" && row.Status != "active" && row.Status != "inactive" {
+		errs = append(errs, "status 只能为 draft、active 或 inactive")
+	}
+
+	var categoryID uint
+	if row.Category != "" {
+		id, err := categories.resolve(row.Category)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		categoryID = id
+	}
+	return categoryID, errs
+}
+
+// applyImportCreate 新建商品，初始库存记入入库流水
+func (s *ProductService) applyImportCreate(row *ProductImportRow, categoryID uint, dryRun bool) (uint, string, error) {
+	if dryRun {
+		return 0, ImportActionCreate, nil
+	}
+
+	product := &models.Product{
+		Name:       row.Name,
+		SKU:        row.SKU,
+		Price:      *row.Price,
+		CategoryID: categoryID,
+		Status:     "draft",
+	}
+	if row.Description != nil {
+		product.Description = *row.Description
+	}
+	if row.OrigPrice != nil {
+		product.OrigPrice = *row.OrigPrice
+	}
+	if row.Stock != nil {
+		product.Stock = *row.Stock
+	}
+	if row.ReorderThreshold != nil {
+		product.ReorderThreshold = *row.ReorderThreshold
+	}
+	if row.Status != "" {
+		product.Status = row.Status
+	}
+
+	err := database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		return recordStockMovement(tx, product.ID, 0, product.Stock, stockMovementMeta{
+			Reason: models.StockReasonRestock,
+			RefID:  "product_import",
+		})
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	invalidateProductCache(product.ID)
+	return product.ID, ImportActionCreate, nil
+}
+
+// applyImportUpdate 更新已有商品：只写入有变化的字段，库存差额通过库存流水调整，已删除的商品会恢复
+func (s *ProductService) applyImportUpdate(operatorID uint, current *models.Product, row *ProductImportRow, categoryID uint, dryRun bool) (uint, string, error) {
+	updates := make(map[string]interface{})
+	if row.Name != "" && row.Name != current.Name {
+		updates["name"] = row.Name
+	}
+	if row.Description != nil && *row.Description != current.Description {
+		updates["description"] = *row.Description
+	}
+	if categoryID > 0 && categoryID != current.CategoryID {
+		updates["category_id"] = categoryID
+	}
+	if row.Price != nil && *row.Price != current.Price {
+		updates["price"] = *row.Price
+	}
+	if row.OrigPrice != nil && *row.OrigPrice != current.OrigPrice {
+		updates["orig_price"] = *row.OrigPrice
+	}
+	if row.ReorderThreshold != nil && *row.ReorderThreshold != current.ReorderThreshold {
+		updates["reorder_threshold"] = *row.ReorderThreshold
+	}
+	// 缺货状态由库存维护，导入只切换上下架
+	if row.Status != "" && row.Status != current.Status &&
+		!(row.Status == "active" && current.Status == "out_of_stock") {
+		updates["status"] = row.Status
+	}
+	restore := current.DeletedAt.Valid
+	stockDelta := 0
+	if row.Stock != nil {
+		stockDelta = *row.Stock - current.Stock
+	}
+
+	if len(updates) == 0 && !restore && stockDelta == 0 {
+		return current.ID, ImportActionUnchanged, nil
+	}
+	if dryRun {
+		if stockDelta != 0 {
+			// 多规格商品的库存按规格维护，试运行时也要提示
+			has, err := productsWithVariants(database.DB, []uint{current.ID})
+			if err != nil {
+				return 0, "", err
+			}
+			if has[current.ID] {
+				return 0, "", ErrVariantRequired
+			}
+		}
+		return current.ID, ImportActionUpdate, nil
+	}
+
+	var change *stockChangeResult
+	err := database.Transaction(func(tx *gorm.DB) error {
+		if restore {
+			if err := tx.Unscoped().Model(&models.Product{}).Where("id = ?", current.ID).
+				Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+		}
+		if len(updates) > 0 {
+			updates["version"] = gorm.Expr("version + 1")
+			if err := tx.Model(current).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		if stockDelta != 0 {
+			var err error
+			change, err = changeStockLocked(tx, current.ID, stockDelta, stockMovementMeta{
+				Reason:  stockReasonFor(stockDelta),
+				RefID:   "product_import",
+				ActorID: operatorID,
+			})
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	if change != nil {
+		afterStockChange(change)
+	} else {
+		invalidateProductCache(current.ID)
+	}
+	return current.ID, ImportActionUpdate, nil
+}
+
+// importErrorMessage 导入失败原因
+func importErrorMessage(err error) string {
+	if errors.Is(err, ErrVariantRequired) {
+		return "多规格商品的库存需按规格调整，请留空 stock 列"
+	}
+	if errors.Is(err, ErrWarehouseRequired) {
+		return "按仓库管理库存的商品需按仓库调整库存，请留空 stock 列"
+	}
+	return stockErrorMessage(err)
+}
+
+// parseProductCSV 解析 CSV：首行为表头（列顺序不限，可省略可选列）
+func parseProductCSV(r io.Reader) ([]parsedImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("文件为空")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("CSV 格式错误: %w", err)
+	}
+
+	known := make(map[string]bool, len(productFileColumns))
+	for _, c := range productFileColumns {
+		known[c] = true
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !known[name] {
+			return nil, fmt.Errorf("未知的列: %s", name)
+		}
+		if _, dup := index[name]; dup {
+			return nil, fmt.Errorf("重复的列: %s", name)
+		}
+		index[name] = i
+	}
+	if _, ok := index["sku"]; !ok {
+		return nil, errors.New("缺少 sku 列")
+	}
+
+	var rows []parsedImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("CSV 格式错误: %w", err)
+		}
+		if blankRecord(record) {
+			continue
+		}
+		if len(rows) >= maxImportRows {
+			return nil, fmt.Errorf("单次最多导入 %d 个商品", maxImportRows)
+		}
+
+		get := func(col string) (string, bool) {
+			i, ok := index[col]
+			if !ok || i >= len(record) {
+				return "", false
+			}
+			v := strings.TrimSpace(record[i])
+			return v, v != ""
+		}
+
+		p := parsedImportRow{line: line}
+		p.row.SKU, _ = get("sku")
+		p.row.Name, _ = get("name")
+		p.row.Category, _ = get("category")
+		p.row.Status, _ = get("status")
+		if i, ok := index["description"]; ok && i < len(record) {
+			// 描述保留原始内容，空值表示清空
+			desc := record[i]
+			p.row.Description = &desc
+		}
+		if v, ok := get("price"); ok {
+			p.row.Price = parseImportFloat(v, "price", &p.errors)
+		}
+		if v, ok := get("orig_price"); ok {
+			p.row.OrigPrice = parseImportFloat(v, "orig_price", &p.errors)
+		}
+		if v, ok := get("stock"); ok {
+			p.row.Stock = parseImportInt(v, "stock", &p.errors)
+		}
+		if v, ok := get("reorder_threshold"); ok {
+			p.row.ReorderThreshold = parseImportInt(v, "reorder_threshold", &p.errors)
+		}
+
+		rows = append(rows, p)
+	}
+	return rows, nil
+}
+
+// blankRecord 是否为空行
+func blankRecord(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseProductJSON 解析 JSON 商品数组
+func parseProductJSON(r io.Reader) ([]parsedImportRow, error) {
+	var items []ProductImportRow
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&items); err != nil {
+		return nil, fmt.Errorf("JSON 格式错误: %w", err)
+	}
+
+	rows := make([]parsedImportRow, 0, len(items))
+	for i, item := range items {
+		item.SKU = strings.TrimSpace(item.SKU)
+		item.Name = strings.TrimSpace(item.Name)
+		item.Category = strings.TrimSpace(item.Category)
+		item.Status = strings.TrimSpace(item.Status)
+		rows = append(rows, parsedImportRow{line: i + 1, row: item})
+	}
+	return rows, nil
+}
+
+// parseImportFloat 解析数值列，失败时记录错误
+func parseImportFloat(v, col string, errs *[]string) *float64 {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		*errs = append(*errs, fmt.Sprintf("%s 不是有效的数字: %s", col, v))
+		return nil
+	}
+	return &f
+}
+
+// parseImportInt 解析整数列，失败时记录错误
+func parseImportInt(v, col string, errs *[]string) *int {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		*errs = append(*errs, fmt.Sprintf("%s 不是有效的整数: %s", col, v))
+		return nil
+	}
+	return &n
+}
+
+// categoryIndex 分类名称、路径索引
+type categoryIndex struct {
+	byPath map[string]uint
+	byName map[string][]uint
+	paths  map[uint]string
+}
+
+// loadCategoryIndex 加载全部分类并生成完整路径
+func loadCategoryIndex() (*categoryIndex, error) {
+	var categories []models.Category
+	if err := database.DB.Select("id", "name", "parent_id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return newCategoryIndex(categories), nil
+}
+
+// newCategoryIndex 由分类列表生成索引
+func newCategoryIndex(categories []models.Category) *categoryIndex {
+	byID := make(map[uint]models.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	idx := &categoryIndex{
+		byPath: make(map[string]uint, len(categories)),
+		byName: make(map[string][]uint, len(categories)),
+		paths:  make(map[uint]string, len(categories)),
+	}
+	for _, c := range categories {
+		names := []string{c.Name}
+		seen := map[uint]bool{c.ID: true}
+		for parentID := c.ParentID; parentID != nil; {
+			parent, ok := byID[*parentID]
+			if !ok || seen[parent.ID] {
+				break
+			}
+			seen[parent.ID] = true
+			names = append([]string{parent.Name}, names...)
+			parentID = parent.ParentID
+		}
+		path := strings.Join(names, categoryPathSeparator)
+		idx.paths[c.ID] = path
+		idx.byPath[strings.ToLower(path)] = c.ID
+		key := strings.ToLower(c.Name)
+		idx.byName[key] = append(idx.byName[key], c.ID)
+	}
+	return idx
+}
+
+// resolve 按完整路径或名称查找分类，名称不唯一时要求使用路径
+func (idx *categoryIndex) resolve(ref string) (uint, error) {
+	parts := strings.Split(ref, categoryPathSeparator)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	key := strings.ToLower(strings.Join(parts, categoryPathSeparator))
+
+	if id, ok := idx.byPath[key]; ok {
+		return id, nil
+	}
+	if len(parts) == 1 {
+		switch ids := idx.byName[key]; len(ids) {
+		case 0:
+		case 1:
+			return ids[0], nil
+		default:
+			return 0, fmt.Errorf("分类名称 %s 不唯一，请使用完整路径", ref)
+		}
+	}
+	return 0, fmt.Errorf("分类不存在: %s", ref)
+}
+
+// ExportProducts 按导入格式导出商品（分类使用完整路径），导出文件可直接重新导入
+func (s *ProductService) ExportProducts(req *ProductExportRequest, w io.Writer) error {
+	categories, err := loadCategoryIndex()
+	if err != nil {
+		return err
+	}
+
+	query := database.DB.Model(&models.Product{}).Order("id ASC")
+	if req.CategoryID > 0 {
+		query = query.Where("category_id = ?", req.CategoryID)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var write func(row ProductImportRow) error
+	var finish func() error
+	switch req.Format {
+	case "", ProductFileCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(productFileColumns); err != nil {
+			return err
+		}
+		write = func(row ProductImportRow) error {
+			return cw.Write([]string{
+				row.SKU, row.Name, *row.Description, row.Category,
+				strconv.FormatFloat(*row.Price, 'f', -1, 64),
+				strconv.FormatFloat(*row.OrigPrice, 'f', -1, 64),
+				strconv.Itoa(*row.Stock),
+				strconv.Itoa(*row.ReorderThreshold),
+				row.Status,
+			})
+		}
+		finish = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case ProductFileJSON:
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+		first := true
+		write = func(row ProductImportRow) error {
+			data, err := json.Marshal(row)
+			if err != nil {
+				return err
+			}
+			if !first {
+				if _, err := io.WriteString(w, ",\n"); err != nil {
+					return err
+				}
+			}
+			first = false
+			_, err = w.Write(data)
+			return err
+		}
+		finish = func() error {
+			_, err := io.WriteString(w, "]\n")
+			return err
+		}
+	default:
+		return errors.New("不支持的文件格式，仅支持 csv、json")
+	}
+
+	var products []models.Product
+	result := query.FindInBatches(&products, 500, func(tx *gorm.DB, batch int) error {
+		for i := range products {
+			if err := write(exportRow(&products[i], categories)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	return finish()
+}
+
+// exportRow 商品转换为导出行
+func exportRow(p *models.Product, categories *categoryIndex) ProductImportRow {
+	status := p.Status
+	if status == "out_of_stock" {
+		// 缺货由库存决定，重新导入时按上架处理
+		status = "active"
+	}
+	description := p.Description
+	price, origPrice := p.Price, p.OrigPrice
+	stock, threshold := p.Stock, p.ReorderThreshold
+	return ProductImportRow{
+		SKU:              p.SKU,
+		Name:             p.Name,
+		Description:      &description,
+		Category:         categories.paths[p.CategoryID],
+		Price:            &price,
+		OrigPrice:        &origPrice,
+		Stock:            &stock,
+		ReorderThreshold: &threshold,
+		Status:           status,
+	}
+}
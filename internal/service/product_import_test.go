@@ -0,0 +1,66 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProductCSV(t *testing.T) {
+	data := "name,sku,price,stock,category\n" +
+		"手机,SKU-1,1999.5,10,数码/手机\n" +
+		"\n" +
+		",SKU-2,abc,,\n"
+
+	rows, err := parseProductCSV(strings.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, 2, rows[0].line)
+	assert.Equal(t, "SKU-1", rows[0].row.SKU)
+	assert.Equal(t, 1999.5, *rows[0].row.Price)
+	assert.Equal(t, 10, *rows[0].row.Stock)
+	assert.Equal(t, "数码/手机", rows[0].row.Category)
+	assert.Nil(t, rows[0].row.Description)
+	assert.Empty(t, rows[0].errors)
+
+	assert.Equal(t, 4, rows[1].line)
+	assert.Nil(t, rows[1].row.Price)
+	assert.Nil(t, rows[1].row.Stock)
+	assert.Len(t, rows[1].errors, 1)
+
+	_, err = parseProductCSV(strings.NewReader("sku,colour\nA,red\n"))
+	assert.Error(t, err)
+	_, err = parseProductCSV(strings.NewReader("name,price\nA,1\n"))
+	assert.Error(t, err)
+}
+
+func TestCategoryIndexResolve(t *testing.T) {
+	parent := uint(1)
+	other := uint(3)
+	idx := newCategoryIndex([]models.Category{
+		{ID: 1, Name: "数码"},
+		{ID: 2, Name: "手机", ParentID: &parent},
+		{ID: 3, Name: "二手"},
+		{ID: 4, Name: "手机", ParentID: &other},
+		{ID: 5, Name: "耳机", ParentID: &parent},
+	})
+
+	assert.Equal(t, "数码/手机", idx.paths[2])
+
+	id, err := idx.resolve("数码 / 手机")
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), id)
+
+	id, err = idx.resolve("耳机")
+	require.NoError(t, err)
+	assert.Equal(t, uint(5), id)
+
+	_, err = idx.resolve("手机")
+	assert.Error(t, err, "名称不唯一时应要求完整路径")
+	_, err = idx.resolve("数码/相机")
+	assert.Error(t, err)
+}
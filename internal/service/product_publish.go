@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/internal/websocket"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 未上架（不对顾客展示、不可购买）的商品状态，定时上架只对这些状态生效
+var unpublishedStatuses = []string{"draft", "inactive"}
+
+// 已上架的商品状态，定时下架只对这些状态生效
+var publishedStatuses = []string{"active", "out_of_stock"}
+
+// ScheduleProductRequest 设置定时上下架请求，时间为空表示取消对应的计划
+type ScheduleProductRequest struct {
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+	Version     int        `json:"version" binding:"gte=0"`
+}
+
+// CheckVisible 校验商品对调用方是否可见：已上架商品对所有人可见，草稿和已下架商品只有管理员可预览；
+// 商品不存在或不可见时返回 ErrProductNotFound
+func (s *ProductService) CheckVisible(productID uint, admin bool) error {
+	detail, err := s.getProductDetail(productID)
+	if err != nil {
+		return err
+	}
+	if !admin && !IsPublished(detail.Status) {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// IsPublished 商品是否已上架（草稿、已下架商品只有管理员可预览）
+func IsPublished(status string) bool {
+	for _, s := range publishedStatuses {
+		if status == s {
+			return true
+		}
+	}
+	return false
+}
+
+// initialStatus 新建商品未指定状态时的初始状态：默认为草稿，指定的上架时间已到时直接上架
+func initialStatus(publishAt *time.Time) string {
+	if publishAt != nil && !publishAt.After(time.Now()) {
+		return "active"
+	}
+	return "draft"
+}
+
+// ScheduleProduct 设置商品的定时上架、下架时间，version 大于 0 时校验乐观锁版本
+func (s *ProductService) ScheduleProduct(id uint, version int, req *ScheduleProductRequest) (*models.Product, error) {
+	var scheduleErr error
+	product, err := s.editProduct(id, version, func(product *models.Product) map[string]interface{} {
+		if scheduleErr = checkSchedule(product.Status, req.PublishAt, req.UnpublishAt); scheduleErr != nil {
+			return nil
+		}
+		return map[string]interface{}{
+			"publish_at":   req.PublishAt,
+			"unpublish_at": req.UnpublishAt,
+		}
+	})
+	if scheduleErr != nil {
+		return nil, scheduleErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("设置商品定时上下架", zap.Uint("product_id", id),
+		zap.Timep("publish_at", product.PublishAt), zap.Timep("unpublish_at", product.UnpublishAt))
+	return product, nil
+}
+
+// checkSchedule 校验定时上下架时间：已上架商品不能再设置上架时间，下架时间须晚于上架时间
+func checkSchedule(status string, publishAt, unpublishAt *time.Time) error {
+	if publishAt != nil && IsPublished(status) {
+		return &FieldError{Field: "publish_at", Message: "商品已上架，无需设置上架时间"}
+	}
+	if unpublishAt != nil {
+		if !unpublishAt.After(time.Now()) {
+			return &FieldError{Field: "unpublish_at", Message: "下架时间须晚于当前时间"}
+		}
+		if publishAt != nil && !unpublishAt.After(*publishAt) {
+			return &FieldError{Field: "unpublish_at", Message: "下架时间须晚于上架时间"}
+		}
+	}
+	return nil
+}
+
+// RunPublishScheduler 定期执行到期的定时上下架，直到 ctx 取消
+func (s *ProductService) RunPublishScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.publishDueProducts(time.Now())
+			s.unpublishDueProducts(time.Now())
+		}
+	}
+}
+
+// publishDueProducts 上架到期的商品并广播上新消息
+// 每个商品由单条 UPDATE ... RETURNING 完成状态切换，多实例同时执行时只有一个实例会拿到该商品
+func (s *ProductService) publishDueProducts(now time.Time) {
+	var published []models.Product
+	if err := database.DB.Model(&published).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "name"}, {Name: "status"}}}).
+		Where("publish_at <= ? AND status IN ?", now, unpublishedStatuses).
+		Where("unpublish_at IS NULL OR unpublish_at > ?", now).
+		Updates(map[string]interface{}{
+			"status":     gorm.Expr("CASE WHEN stock > 0 THEN 'active' ELSE 'out_of_stock' END"),
+			"publish_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		}).Error; err != nil {
+		logger.Error("定时上架失败", zap.Error(err))
+		return
+	}
+	if len(published) == 0 {
+		return
+	}
+
+	ids := make([]uint, 0, len(published))
+	for _, p := range published {
+		ids = append(ids, p.ID)
+		invalidateProductCache(p.ID)
+		logger.Info("商品定时上架", zap.Uint("product_id", p.ID), zap.String("status", p.Status))
+		if p.Status == "active" {
+			websocket.BroadcastPromotion("新品上架", fmt.Sprintf("「%s」现已上架，快来看看吧", p.Name))
+		}
+	}
+	indexProductsAsync(ids...)
+	invalidateCategoryTree()
+}
+
+// unpublishDueProducts 下架到期的商品；尚未上架就已到下架时间的商品直接取消定时上下架
+func (s *ProductService) unpublishDueProducts(now time.Time) {
+	var unpublished []models.Product
+	if err := database.DB.Model(&unpublished).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "status"}}}).
+		Where("unpublish_at <= ? AND status IN ?", now, publishedStatuses).
+		Updates(map[string]interface{}{
+			"status":       "inactive",
+			"unpublish_at": nil,
+			"version":      gorm.Expr("version + 1"),
+		}).Error; err != nil {
+		logger.Error("定时下架失败", zap.Error(err))
+		return
+	}
+
+	if err := database.DB.Model(&models.Product{}).
+		Where("unpublish_at <= ? AND status IN ?", now, unpublishedStatuses).
+		Updates(map[string]interface{}{"publish_at": nil, "unpublish_at": nil}).Error; err != nil {
+		logger.Error("清理过期的定时上下架失败", zap.Error(err))
+	}
+	if len(unpublished) == 0 {
+		return
+	}
+
+	ids := make([]uint, 0, len(unpublished))
+	for _, p := range unpublished {
+		ids = append(ids, p.ID)
+		invalidateProductCache(p.ID)
+		logger.Info("商品定时下架", zap.Uint("product_id", p.ID))
+	}
+	indexProductsAsync(ids...)
+	invalidateCategoryTree()
+}
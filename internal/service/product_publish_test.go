@@ -0,0 +1,50 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCheckSchedule 测试定时上下架时间校验
+func TestCheckSchedule(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	muchLater := now.Add(48 * time.Hour)
+	past := now.Add(-time.Hour)
+
+	assert.NoError(t, checkSchedule("draft", &later, &muchLater))
+	assert.NoError(t, checkSchedule("draft", &past, nil), "上架时间已过时由下次调度立即上架")
+	assert.NoError(t, checkSchedule("active", nil, &later))
+	assert.NoError(t, checkSchedule("active", nil, nil))
+
+	var fieldErr *FieldError
+	err := checkSchedule("active", &later, nil)
+	if assert.ErrorAs(t, err, &fieldErr) {
+		assert.Equal(t, "publish_at", fieldErr.Field)
+	}
+	err = checkSchedule("draft", &muchLater, &later)
+	if assert.ErrorAs(t, err, &fieldErr) {
+		assert.Equal(t, "unpublish_at", fieldErr.Field)
+	}
+	assert.Error(t, checkSchedule("out_of_stock", nil, &past))
+}
+
+// TestIsPublished 测试上架状态判断
+func TestIsPublished(t *testing.T) {
+	assert.True(t, IsPublished("active"))
+	assert.True(t, IsPublished("out_of_stock"))
+	assert.False(t, IsPublished("draft"))
+	assert.False(t, IsPublished("inactive"))
+}
+
+// TestInitialStatus 测试新建商品的默认状态
+func TestInitialStatus(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	assert.Equal(t, "draft", initialStatus(nil))
+	assert.Equal(t, "draft", initialStatus(&future))
+	assert.Equal(t, "active", initialStatus(&past))
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"html"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/internal/search"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// ProductSearchResult 商品搜索结果
+type ProductSearchResult struct {
+	models.Product
+	Score     float64         `json:"score"`     // 相关度与销量综合得分
+	Fuzzy     bool            `json:"fuzzy"`     // 是否由拼写容错匹配
+	Highlight SearchHighlight `json:"highlight"` // 命中片段，命中词以 <em> 标记（已做HTML转义）
+}
+
+// SearchHighlight 搜索命中片段
+type SearchHighlight struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// 描述高亮片段的最大长度（字符数）
+const highlightFragmentRunes = 80
+
+// SearchProducts 搜索商品：关键词按同义词扩展后交给搜索引擎检索，再按命中顺序加载商品并生成高亮片段
+func (s *ProductService) SearchProducts(keyword string, page, pageSize int) ([]ProductSearchResult, int64, error) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return nil, 0, errors.New("搜索关键词不能为空")
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	q := search.Query{
+		Keyword: keyword,
+		Terms:   expandSearchTerms(keyword),
+		Offset:  (page - 1) * pageSize,
+		Limit:   pageSize,
+	}
+	if q.Empty() {
+		return nil, 0, errors.New("搜索关键词不能为空")
+	}
+
+	engine := getSearchEngine()
+	result, err := engine.Search(context.Background(), q)
+	if err != nil {
+		logger.Error("商品搜索失败", zap.String("engine", engine.Name()), zap.String("keyword", keyword), zap.Error(err))
+		return nil, 0, err
+	}
+
+	if result.Total > 0 {
+		go recordSearchQuery(keyword)
+	}
+
+	results, stale, err := loadSearchResults(result.Hits, q.Terms, result.Fuzzy)
+	if err != nil {
+		return nil, 0, err
+	}
+	// 索引滞后的命中不计入总数，并重新同步这些商品的索引
+	total := result.Total - int64(len(stale))
+	if total < int64(q.Offset+len(results)) {
+		total = int64(q.Offset + len(results))
+	}
+	indexProductsAsync(stale...)
+	return results, total, nil
+}
+
+// loadSearchResults 按检索得分顺序加载商品并生成高亮片段；索引滞后时跳过已不可搜索（下架、售罄或删除）的商品，
+// 按与建索引相同的条件过滤，并返回被跳过的商品ID
+func loadSearchResults(hits []search.Hit, terms [][]string, fuzzy bool) ([]ProductSearchResult, []uint, error) {
+	results := make([]ProductSearchResult, 0, len(hits))
+	if len(hits) == 0 {
+		return results, nil, nil
+	}
+
+	ids := make([]uint, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+
+	var products []models.Product
+	if err := database.DB.Preload("Category").
+		Where("id IN ?", ids).
+		Find(&products).Error; err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[uint]models.Product, len(products))
+	for i := range products {
+		if searchable(&products[i]) {
+			byID[products[i].ID] = products[i]
+		}
+	}
+
+	var words []string
+	for _, variants := range terms {
+		words = append(words, variants...)
+	}
+
+	var stale []uint
+	for _, hit := range hits {
+		p, ok := byID[hit.ID]
+		if !ok {
+			stale = append(stale, hit.ID)
+			continue
+		}
+		results = append(results, ProductSearchResult{
+			Product: p,
+			Score:   hit.Score,
+			Fuzzy:   fuzzy,
+			Highlight: SearchHighlight{
+				Name:        highlightText(p.Name, words, 0),
+				Description: highlightText(p.Description, words, highlightFragmentRunes),
+			},
+		})
+	}
+	return results, stale, nil
+}
+
+// expandSearchTerms 按空白拆分关键词，并为每个词追加同义词（同一词的各个写法任一命中即可）
+func expandSearchTerms(keyword string) [][]string {
+	var terms [][]string
+	for _, field := range strings.Fields(strings.ToLower(keyword)) {
+		variants := []string{field}
+		seen := map[string]bool{field: true}
+		for _, syn := range searchSynonyms.lookup(field) {
+			if !seen[syn] {
+				seen[syn] = true
+				variants = append(variants, syn)
+			}
+		}
+		terms = append(terms, variants)
+	}
+	return terms
+}
+
+// highlightText 用 <em> 标记文本中命中的检索词（忽略大小写），其余内容做HTML转义
+// maxRunes > 0 时只截取首个命中位置附近的片段
+func highlightText(text string, words []string, maxRunes int) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	marked := make([]bool, len(runes))
+	first := -1
+	for _, w := range words {
+		wr := []rune(strings.ToLower(w))
+		if len(wr) == 0 {
+			continue
+		}
+		for i := 0; i+len(wr) <= len(lower); i++ {
+			if string(lower[i:i+len(wr)]) != string(wr) {
+				continue
+			}
+			for j := i; j < i+len(wr); j++ {
+				marked[j] = true
+			}
+			if first == -1 || i < first {
+				first = i
+			}
+		}
+	}
+
+	start, end := 0, len(runes)
+	if maxRunes > 0 && len(runes) > maxRunes {
+		if first > maxRunes/4 {
+			start = first - maxRunes/4
+		}
+		end = start + maxRunes
+		if end > len(runes) {
+			end = len(runes)
+			start = end - maxRunes
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	inMark := false
+	segStart := start
+	for i := start; i <= end; i++ {
+		if i < end && marked[i] == inMark {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[segStart:i])))
+		if i == end {
+			break
+		}
+		if marked[i] {
+			b.WriteString("<em>")
+		} else {
+			b.WriteString("</em>")
+		}
+		inMark = marked[i]
+		segStart = i
+	}
+	if inMark {
+		b.WriteString("</em>")
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// synonymCache 同义词组缓存，定期从数据库刷新（多实例下修改最多延迟一个刷新周期生效）
+type synonymCache struct {
+	mu       sync.RWMutex
+	groups   map[string][]string
+	loadedAt time.Time
+}
+
+// 同义词缓存刷新周期
+const synonymRefreshInterval = time.Minute
+
+var searchSynonyms = &synonymCache{}
+
+// lookup 返回与 term 同组的所有词（含 term 本身），无同义词时返回 nil
+func (c *synonymCache) lookup(term string) []string {
+	c.mu.RLock()
+	fresh := c.groups != nil && time.Since(c.loadedAt) < synonymRefreshInterval
+	group := c.groups[term]
+	c.mu.RUnlock()
+	if fresh {
+		return group
+	}
+
+	if err := c.reload(); err != nil {
+		logger.Warn("加载搜索同义词失败", zap.Error(err))
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.groups[term]
+}
+
+// reload 从数据库重新加载同义词组
+func (c *synonymCache) reload() error {
+	var rows []models.SearchSynonym
+	if err := database.DB.Find(&rows).Error; err != nil {
+		return err
+	}
+
+	groups := make(map[string][]string)
+	for _, row := range rows {
+		group := splitSynonymTerms(row.Terms)
+		for _, term := range group {
+			groups[term] = append(groups[term], group...)
+		}
+	}
+
+	c.mu.Lock()
+	c.groups = groups
+	c.loadedAt = time.Now()
+	c.mu.Unlock()
+	return nil
+}
+
+// invalidate 同义词变更后使缓存失效
+func (c *synonymCache) invalidate() {
+	c.mu.Lock()
+	c.groups = nil
+	c.mu.Unlock()
+}
+
+// splitSynonymTerms 拆分逗号分隔的同义词组（去空、去重、转小写）
+func splitSynonymTerms(terms string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, t := range strings.FieldsFunc(terms, func(r rune) bool { return r == ',' || r == '，' }) {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		result = append(result, t)
+	}
+	return result
+}
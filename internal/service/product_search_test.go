@@ -0,0 +1,31 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/shoppee/ecommerce/pkg/tokenizer"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSearchTokenize 测试检索分词：中文写入单字及二元组，查询使用二元组
+func TestSearchTokenize(t *testing.T) {
+	assert.Equal(t, []string{"iphone", "15", "手", "手机", "机"}, tokenizer.Tokenize("iPhone-15 手机"))
+	assert.Equal(t, []string{"蓝牙", "牙耳", "耳机"}, tokenizer.QueryTokens("蓝牙耳机"))
+	assert.Equal(t, []string{"鞋"}, tokenizer.QueryTokens("鞋"))
+}
+
+// TestHighlightText 测试命中词高亮及HTML转义
+func TestHighlightText(t *testing.T) {
+	assert.Equal(t, "<em>Apple</em> &lt;iPhone&gt;", highlightText("Apple <iPhone>", []string{"apple"}, 0))
+	assert.Equal(t, "无线<em>蓝牙</em>耳机", highlightText("无线蓝牙耳机", []string{"蓝牙"}, 0))
+
+	text := "这是一段很长的商品描述文字，用于测试高亮片段截取，关键词出现在后面：降噪耳机，支持主动降噪。"
+	fragment := highlightText(text, []string{"降噪"}, 20)
+	assert.Contains(t, fragment, "<em>降噪</em>")
+	assert.True(t, len([]rune(fragment)) < len([]rune(text)))
+}
+
+// TestSplitSynonymTerms 测试同义词组拆分
+func TestSplitSynonymTerms(t *testing.T) {
+	assert.Equal(t, []string{"手机", "mobile", "phone"}, splitSynonymTerms("手机, Mobile，phone,mobile,"))
+}
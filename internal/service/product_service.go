@@ -1,9 +1,9 @@
 package service
 
 import (
-	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -12,7 +12,6 @@ import (
 	"github.com/shoppee/ecommerce/pkg/logger"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 // ProductService 商品服务
@@ -25,12 +24,26 @@ func NewProductService() *ProductService {
 
 // ProductListRequest 商品列表请求
 type ProductListRequest struct {
-	Page       int    `form:"page" binding:"omitempty,gte=1"`
-	PageSize   int    `form:"page_size" binding:"omitempty,gte=1,lte=100"`
-	CategoryID uint   `form:"category_id"`
-	Keyword    string `form:"keyword"`
-	Sort       string `form:"sort"` // price_asc, price_desc, sale_desc, new
-	Status     string `form:"status"`
+	Page       int      `form:"page" binding:"omitempty,gte=1"`
+	PageSize   int      `form:"page_size" binding:"omitempty,gte=1,lte=100"`
+	CategoryID uint     `form:"category_id"`
+	Keyword    string   `form:"keyword"`
+	Sort       string   `form:"sort"`   // price_asc, price_desc, sale_desc, new
+	Status     string   `form:"status"` // 草稿、已下架状态仅管理员可筛选，其余用户只能看到在售和缺货商品
+	MinPrice   *float64 `form:"min_price" binding:"omitempty,gte=0"`
+	MaxPrice   *float64 `form:"max_price" binding:"omitempty,gte=0"`
+	InStock    bool     `form:"in_stock"`   // 仅显示有货商品
+	Tags       string   `form:"tags"`       // 标签编码，多个以逗号分隔（任一匹配）
+	Collection string   `form:"collection"` // 合集编码，未指定排序时按合集顺序排列
+
+	// 属性筛选，键为属性 code：文本/布尔属性多个值以逗号分隔（任一匹配），
+	// 数值属性为区间 min-max（可省略一端）；不同属性之间同时满足
+	Attributes map[string]string `form:"-"`
+
+	// 管理员可按任意状态筛选（预览草稿和已下架商品），由处理器根据登录角色设置
+	ShowUnpublished bool `form:"-"`
+
+	collection *models.Collection // 已解析的合集，列表与筛选项统计共用
 }
 
 // GetProductList 获取商品列表（支持分页、筛选、排序）
@@ -43,37 +56,18 @@ func (s *ProductService) GetProductList(req *ProductListRequest) ([]models.Produ
 		req.PageSize = 20
 	}
 
-	query := database.DB.Model(&models.Product{}).Preload("Category")
-
-	// 分类筛选
-	if req.CategoryID > 0 {
-		query = query.Where("category_id = ?", req.CategoryID)
-	}
-
-	// 关键词搜索
-	if req.Keyword != "" {
-		query = query.Where("name LIKE ? OR description LIKE ?", "%"+req.Keyword+"%", "%"+req.Keyword+"%")
-	}
-
-	// 状态筛选
-	if req.Status != "" {
-		query = query.Where("status = ?", req.Status)
-	} else {
-		query = query.Where("status = ?", "active")
+	query, err := s.filterProducts(req, "")
+	if err != nil {
+		return nil, 0, err
 	}
+	// 列表只带主图
+	query = query.Preload("Category").Preload("Tags").Preload("Media", "sort = ?", 0).Preload("Media.Media.Thumbnails")
 
 	// 排序
-	switch req.Sort {
-	case "price_asc":
-		query = query.Order("price ASC")
-	case "price_desc":
-		query = query.Order("price DESC")
-	case "sale_desc":
-		query = query.Order("sale_count DESC")
-	case "new":
-		query = query.Order("created_at DESC")
-	default:
-		query = query.Order("id DESC")
+	if req.Sort == "" && req.collection != nil {
+		query = orderByCollection(query, req.collection)
+	} else {
+		query = orderProducts(query, req.Sort)
 	}
 
 	// 统计总数
@@ -88,140 +82,265 @@ func (s *ProductService) GetProductList(req *ProductListRequest) ([]models.Produ
 	if err := query.Offset(offset).Limit(req.PageSize).Find(&products).Error; err != nil {
 		return nil, 0, err
 	}
+	listed := make([]*models.Product, len(products))
+	for i := range products {
+		listed[i] = &products[i]
+	}
+	withLiveViews(listed...)
 
 	return products, total, nil
 }
 
-// GetProductByID 根据ID获取商品详情（带缓存）
-func (s *ProductService) GetProductByID(id uint) (*models.Product, error) {
-	var product models.Product
+// orderProducts 按排序方式排列商品列表
+func orderProducts(query *gorm.DB, sort string) *gorm.DB {
+	switch sort {
+	case "price_asc":
+		return query.Order("products.price ASC")
+	case "price_desc":
+		return query.Order("products.price DESC")
+	case "sale_desc":
+		return query.Order("products.sale_count DESC")
+	case "new":
+		return query.Order("products.created_at DESC")
+	default:
+		return query.Order("products.id DESC")
+	}
+}
 
-	// 先从Redis缓存获取
-	ctx := context.Background()
-	cacheKey := fmt.Sprintf("product:%d", id)
+// GetProductByID 根据ID获取商品详情（读穿缓存），浏览量取 Redis 中的实时值
+func (s *ProductService) GetProductByID(id uint) (*ProductDetail, error) {
+	detail, err := s.getProductDetail(id)
+	if err != nil {
+		return nil, err
+	}
 
-	// 尝试从缓存读取（这里简化处理，实际应序列化完整对象）
-	_, err := database.RedisClient.Get(ctx, cacheKey).Result()
-	if err == nil {
-		// 缓存命中，从数据库加载完整信息
-		if err := database.DB.Preload("Category").Preload("Reviews").First(&product, id).Error; err != nil {
-			return nil, err
-		}
+	withLiveViews(&detail.Product)
 
-		// 异步增加浏览量
-		go s.incrementViewCount(id)
+	return detail, nil
+}
 
-		return &product, nil
-	}
+// detailQuery 商品详情查询（含分类、标签、规格、规格组合、属性及图片），评价通过评价列表分页获取
+func (s *ProductService) detailQuery() *gorm.DB {
+	return database.DB.Preload("Category").Preload("Tags").
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("sort ASC, id ASC") }).
+		Preload("Options.Values", func(db *gorm.DB) *gorm.DB { return db.Order("sort ASC, id ASC") }).
+		Preload("Variants", "status = ?", "active").
+		Preload("Variants.OptionValues").
+		Preload("Attributes.Attribute").
+		Preload("Media", func(db *gorm.DB) *gorm.DB { return db.Order("sort ASC, id ASC") }).
+		Preload("Media.Media.Thumbnails")
+}
 
-	// 缓存未命中，从数据库查询
-	if err := database.DB.Preload("Category").Preload("Reviews").First(&product, id).Error; err != nil {
-		return nil, err
-	}
+// 批量更新库存模式
+const (
+	BatchStockAtomic     = "atomic"      // 整批在一个事务内执行，任一失败则全部回滚
+	BatchStockBestEffort = "best_effort" // 逐项独立执行，返回每项结果
+)
 
-	// 异步缓存到Redis（1小时过期）
-	go func() {
-		database.RedisClient.Set(ctx, cacheKey, "cached", 1*time.Hour)
-	}()
+var (
+	// ErrPartialStockUpdate 尽力模式下部分商品更新失败
+	ErrPartialStockUpdate = errors.New("部分库存更新失败")
+	// ErrBatchStockRolledBack 事务模式下整批回滚
+	ErrBatchStockRolledBack = errors.New("批量库存更新已回滚")
+)
 
-	// 异步增加浏览量
-	go s.incrementViewCount(id)
+// StockUpdateItem 单个商品（规格）的库存变更
+type StockUpdateItem struct {
+	ProductID   uint `json:"product_id" binding:"required"`
+	VariantID   uint `json:"variant_id"`              // 多规格商品必填
+	WarehouseID uint `json:"warehouse_id"`            // 按仓库管理库存的商品必填
+	Quantity    int  `json:"quantity" binding:"ne=0"` // 正数入库、负数出库
+}
 
-	return &product, nil
+// BatchStockRequest 批量更新库存请求
+type BatchStockRequest struct {
+	Mode    string            `json:"mode" binding:"omitempty,oneof=atomic best_effort"` // 默认 best_effort
+	Updates []StockUpdateItem `json:"updates" binding:"required,min=1,dive"`
 }
 
-// incrementViewCount 增加商品浏览量
-func (s *ProductService) incrementViewCount(productID uint) {
-	database.DB.Model(&models.Product{}).Where("id = ?", productID).UpdateColumn("view_count", gorm.Expr("view_count + ?", 1))
+// StockUpdateResult 单个商品（规格）的库存更新结果
+type StockUpdateResult struct {
+	ProductID uint   `json:"product_id"`
+	VariantID uint   `json:"variant_id,omitempty"`
+	Quantity  int    `json:"quantity"`
+	Success   bool   `json:"success"`
+	NewStock  *int   `json:"new_stock,omitempty"`
+	Error     string `json:"error,omitempty"`
 }
 
-// BatchUpdateStock 批量更新库存（利用Go协程+Channel实现高并发）
-func (s *ProductService) BatchUpdateStock(updates map[uint]int) error {
+// BatchUpdateStock 批量更新库存
+// atomic 模式按商品ID、规格ID顺序在同一事务内加锁更新，避免死锁；
+// best_effort 模式利用Go协程+Channel并发执行，每项独立事务
+// 两种模式都返回按商品ID、规格ID排序的逐项结果，便于调用方精确重试
+func (s *ProductService) BatchUpdateStock(operatorID uint, mode string, updates []StockUpdateItem) ([]StockUpdateResult, error) {
 	if len(updates) == 0 {
-		return errors.New("更新列表为空")
+		return nil, errors.New("更新列表为空")
 	}
 
+	items := make([]StockUpdateItem, len(updates))
+	copy(items, updates)
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].ProductID != items[j].ProductID {
+			return items[i].ProductID < items[j].ProductID
+		}
+		return items[i].VariantID < items[j].VariantID
+	})
+
+	if mode == BatchStockAtomic {
+		return s.batchUpdateStockAtomic(operatorID, items)
+	}
+	return s.batchUpdateStockBestEffort(operatorID, items)
+}
+
+// batchUpdateStockAtomic 整批在一个事务内更新库存
+func (s *ProductService) batchUpdateStockAtomic(operatorID uint, items []StockUpdateItem) ([]StockUpdateResult, error) {
+	results := make([]StockUpdateResult, len(items))
+	for i, item := range items {
+		results[i] = StockUpdateResult{ProductID: item.ProductID, VariantID: item.VariantID, Quantity: item.Quantity}
+	}
+
+	changes := make([]*stockChangeResult, 0, len(items))
+	failed := -1
+	err := database.Transaction(func(tx *gorm.DB) error {
+		for i, item := range items {
+			change, err := changeStockLocked(tx, item.ProductID, item.Quantity, stockMovementMeta{
+				Reason:      stockReasonFor(item.Quantity),
+				RefID:       "batch_stock",
+				ActorID:     operatorID,
+				WarehouseID: item.WarehouseID,
+				VariantID:   item.VariantID,
+			})
+			if err != nil {
+				failed = i
+				return err
+			}
+			changes = append(changes, change)
+		}
+		return nil
+	})
+
+	if err != nil {
+		for i := range results {
+			if i == failed {
+				results[i].Error = stockErrorMessage(err)
+			} else {
+				results[i].Error = ErrBatchStockRolledBack.Error()
+			}
+		}
+		logger.Error("批量更新库存失败，整批回滚",
+			zap.Int("count", len(items)),
+			zap.Uint("failed_product_id", items[failed].ProductID),
+			zap.Error(err),
+		)
+		return results, fmt.Errorf("%w: 商品 %d %s", ErrBatchStockRolledBack, items[failed].ProductID, stockErrorMessage(err))
+	}
+
+	for i, change := range changes {
+		newStock := change.Product.Stock
+		results[i].Success = true
+		results[i].NewStock = &newStock
+		afterStockChange(change)
+	}
+
+	logger.Info("批量更新库存成功", zap.String("mode", BatchStockAtomic), zap.Int("count", len(items)))
+	return results, nil
+}
+
+// batchUpdateStockBestEffort 协程池逐项更新库存
+func (s *ProductService) batchUpdateStockBestEffort(operatorID uint, items []StockUpdateItem) ([]StockUpdateResult, error) {
 	// 创建协程池处理批量更新
 	const workerCount = 10
-	jobs := make(chan struct {
-		productID uint
-		quantity  int
-	}, len(updates))
-	results := make(chan error, len(updates))
+	jobs := make(chan int, len(items))
+	results := make([]StockUpdateResult, len(items))
 
-	// 启动worker协程池
+	// 启动worker协程池（每个worker只写自己领取的下标，无需加锁）
 	var wg sync.WaitGroup
 	for i := 0; i < workerCount; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for job := range jobs {
-				err := s.updateStock(job.productID, job.quantity)
-				results <- err
+			for idx := range jobs {
+				item := items[idx]
+				result := StockUpdateResult{ProductID: item.ProductID, VariantID: item.VariantID, Quantity: item.Quantity}
+				change, err := s.updateStock(operatorID, item)
+				if err != nil {
+					result.Error = stockErrorMessage(err)
+				} else {
+					newStock := change.Product.Stock
+					result.Success = true
+					result.NewStock = &newStock
+				}
+				results[idx] = result
 			}
 		}()
 	}
 
 	// 发送任务到jobs channel
-	for productID, quantity := range updates {
-		jobs <- struct {
-			productID uint
-			quantity  int
-		}{productID, quantity}
+	for idx := range items {
+		jobs <- idx
 	}
 	close(jobs)
 
 	// 等待所有worker完成
 	wg.Wait()
-	close(results)
 
 	// 检查是否有错误
-	var errs []error
-	for err := range results {
-		if err != nil {
-			errs = append(errs, err)
+	failed := 0
+	for _, result := range results {
+		if !result.Success {
+			failed++
 		}
 	}
 
-	if len(errs) > 0 {
-		logger.Error("批量更新库存失败", zap.Int("error_count", len(errs)))
-		return errors.New("部分库存更新失败")
+	if failed > 0 {
+		logger.Error("批量更新库存失败", zap.Int("error_count", failed), zap.Int("count", len(items)))
+		return results, ErrPartialStockUpdate
 	}
 
-	logger.Info("批量更新库存成功", zap.Int("count", len(updates)))
-	return nil
+	logger.Info("批量更新库存成功", zap.String("mode", BatchStockBestEffort), zap.Int("count", len(items)))
+	return results, nil
 }
 
-// updateStock 更新单个商品库存（带悲观锁）
-func (s *ProductService) updateStock(productID uint, quantity int) error {
-	return database.Transaction(func(tx *gorm.DB) error {
-		var product models.Product
-
-		// 使用FOR UPDATE悲观锁防止并发问题
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
-			return err
-		}
-
-		// 检查库存是否充足
-		newStock := product.Stock + quantity
-		if newStock < 0 {
-			return errors.New("库存不足")
-		}
+// updateStock 更新单个商品（规格）库存（带悲观锁，并记录库存流水）
+func (s *ProductService) updateStock(operatorID uint, item StockUpdateItem) (*stockChangeResult, error) {
+	var result *stockChangeResult
+	err := database.Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = changeStockLocked(tx, item.ProductID, item.Quantity, stockMovementMeta{
+			Reason:      stockReasonFor(item.Quantity),
+			RefID:       "batch_stock",
+			ActorID:     operatorID,
+			WarehouseID: item.WarehouseID,
+			VariantID:   item.VariantID,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		// 更新库存
-		if err := tx.Model(&product).Update("stock", newStock).Error; err != nil {
-			return err
-		}
+	afterStockChange(result)
+	return result, nil
+}
 
-		// 如果库存为0，更新状态
-		if newStock == 0 {
-			tx.Model(&product).Update("status", "out_of_stock")
-		} else if product.Status == "out_of_stock" {
-			tx.Model(&product).Update("status", "active")
-		}
+// stockReasonFor 批量调整库存的流水原因：增加视为入库，减少视为调整
+func stockReasonFor(quantity int) string {
+	if quantity < 0 {
+		return models.StockReasonAdjustment
+	}
+	return models.StockReasonRestock
+}
 
-		return nil
-	})
+// stockErrorMessage 库存更新失败原因
+func stockErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return "商品不存在"
+	case errors.Is(err, ErrInsufficientStock):
+		return ErrInsufficientStock.Error()
+	default:
+		return err.Error()
+	}
 }
 
 // BatchCreateProducts 批量创建商品（Go协程优化）
@@ -244,8 +363,21 @@ func (s *ProductService) BatchCreateProducts(products []models.Product) error {
 		go func() {
 			defer wg.Done()
 			for batch := range batches {
-				// 批量插入
-				err := database.DB.CreateInBatches(batch, batchSize).Error
+				// 批量插入，并为初始库存写入入库流水
+				err := database.Transaction(func(tx *gorm.DB) error {
+					if err := tx.CreateInBatches(batch, batchSize).Error; err != nil {
+						return err
+					}
+					for _, p := range batch {
+						if err := recordStockMovement(tx, p.ID, 0, p.Stock, stockMovementMeta{
+							Reason: models.StockReasonRestock,
+							RefID:  "product_create",
+						}); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
 				results <- err
 			}
 		}()
@@ -273,89 +405,319 @@ func (s *ProductService) BatchCreateProducts(products []models.Product) error {
 		}
 	}
 
+	ids := make([]uint, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+	invalidateProductCache(ids...)
+	indexProductsAsync(ids...)
+	invalidateCategoryTree()
+
 	logger.Info("批量创建商品成功", zap.Int("count", len(products)))
 	return nil
 }
 
-// SearchProducts 全文搜索商品（支持高并发）
-func (s *ProductService) SearchProducts(keyword string, page, pageSize int) ([]models.Product, int64, error) {
-	if keyword == "" {
-		return nil, 0, errors.New("搜索关键词不能为空")
-	}
+var (
+	// ErrProductNotFound 商品不存在
+	ErrProductNotFound = errors.New("商品不存在")
+	// ErrProductVersionConflict 商品已被他人修改（乐观锁版本不一致）
+	ErrProductVersionConflict = errors.New("商品已被修改，请刷新后重试")
+)
 
-	query := database.DB.Model(&models.Product{}).
-		Where("name LIKE ? OR description LIKE ?", "%"+keyword+"%", "%"+keyword+"%").
-		Where("status = ?", "active")
+// FieldError 字段校验错误，Field 为请求中的字段名
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
 
-	// 统计总数
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
+// Error 实现 error 接口
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
 
-	// 分页查询
-	var products []models.Product
-	offset := (page - 1) * pageSize
-	if err := query.Preload("Category").Offset(offset).Limit(pageSize).Order("sale_count DESC").Find(&products).Error; err != nil {
-		return nil, 0, err
-	}
+// CreateProductRequest 创建商品请求（库存为初始库存，之后通过库存接口调整）
+type CreateProductRequest struct {
+	Name             string     `json:"name" binding:"required,max=200"`
+	Description      string     `json:"description"`
+	Price            float64    `json:"price" binding:"required,gt=0"`
+	OrigPrice        float64    `json:"orig_price" binding:"gte=0"`
+	Stock            int        `json:"stock" binding:"gte=0"`
+	ReorderThreshold int        `json:"reorder_threshold" binding:"gte=0"`
+	SKU              string     `json:"sku" binding:"required,max=100"`
+	CategoryID       uint       `json:"category_id" binding:"required"`
+	Status           string     `json:"status" binding:"omitempty,oneof=draft active inactive"` // 为空时为草稿；publish_at 已到时直接上架
+	Slug             string     `json:"slug" binding:"omitempty,max=80"`                        // 为空时由名称生成
+	PublishAt        *time.Time `json:"publish_at"`                                             // 定时上架
+	UnpublishAt      *time.Time `json:"unpublish_at"`                                           // 定时下架
+}
 
-	return products, total, nil
+// UpdateProductRequest 整体更新商品请求（PUT），未传的可选字段会被清空（slug 除外，为空时保持不变）；库存、销量、浏览量不可编辑
+type UpdateProductRequest struct {
+	Name             string  `json:"name" binding:"required,max=200"`
+	Description      string  `json:"description"`
+	Price            float64 `json:"price" binding:"required,gt=0"`
+	OrigPrice        float64 `json:"orig_price" binding:"gte=0"`
+	ReorderThreshold int     `json:"reorder_threshold" binding:"gte=0"`
+	SKU              string  `json:"sku" binding:"required,max=100"`
+	CategoryID       uint    `json:"category_id" binding:"required"`
+	Status           string  `json:"status" binding:"required,oneof=draft active inactive"`
+	Slug             string  `json:"slug" binding:"omitempty,max=80"`
+	Version          int     `json:"version" binding:"gte=0"` // 乐观锁版本，也可通过 If-Match 请求头传入
 }
 
-// CreateProduct 创建商品
-func (s *ProductService) CreateProduct(req interface{}) (*models.Product, error) {
-	reqMap, ok := req.(map[string]interface{})
-	if !ok {
-		return nil, errors.New("无效的请求数据")
-	}
+// PatchProductRequest 部分更新商品请求（PATCH），只修改传入的字段
+type PatchProductRequest struct {
+	Name             *string  `json:"name" binding:"omitempty,min=1,max=200"`
+	Description      *string  `json:"description"`
+	Price            *float64 `json:"price" binding:"omitempty,gt=0"`
+	OrigPrice        *float64 `json:"orig_price" binding:"omitempty,gte=0"`
+	ReorderThreshold *int     `json:"reorder_threshold" binding:"omitempty,gte=0"`
+	SKU              *string  `json:"sku" binding:"omitempty,min=1,max=100"`
+	CategoryID       *uint    `json:"category_id" binding:"omitempty,gt=0"`
+	Status           *string  `json:"status" binding:"omitempty,oneof=draft active inactive"`
+	Slug             *string  `json:"slug" binding:"omitempty,max=80"` // 传空字符串时由名称重新生成
+	Version          int      `json:"version" binding:"gte=0"`
+}
 
+// UpdateProductStatusRequest 上下架请求
+type UpdateProductStatusRequest struct {
+	Status  string `json:"status" binding:"required,oneof=draft active inactive"`
+	Version int    `json:"version" binding:"gte=0"`
+}
+
+// CreateProduct 创建商品
+func (s *ProductService) CreateProduct(req *CreateProductRequest) (*models.Product, error) {
 	product := &models.Product{
-		Name:        reqMap["name"].(string),
-		Description: reqMap["description"].(string),
-		Price:       reqMap["price"].(float64),
-		Stock:       int(reqMap["stock"].(float64)),
-		SKU:         reqMap["sku"].(string),
-		CategoryID:  uint(reqMap["category_id"].(float64)),
-		Status:      "active",
+		Name:             req.Name,
+		Description:      req.Description,
+		Price:            req.Price,
+		OrigPrice:        req.OrigPrice,
+		Stock:            req.Stock,
+		ReorderThreshold: req.ReorderThreshold,
+		SKU:              req.SKU,
+		CategoryID:       req.CategoryID,
+		Slug:             req.Slug,
+		PublishAt:        req.PublishAt,
+		UnpublishAt:      req.UnpublishAt,
+		Version:          1,
+	}
+	if req.Status != "" {
+		product.Status = req.Status
+	} else {
+		product.Status = initialStatus(req.PublishAt)
+		if IsPublished(product.Status) {
+			product.PublishAt = nil
+		}
+	}
+	product.Status = editableStatus(product.Status, product.Stock)
+	if err := checkSchedule(product.Status, product.PublishAt, product.UnpublishAt); err != nil {
+		return nil, err
 	}
 
-	if origPrice, ok := reqMap["orig_price"].(float64); ok {
-		product.OrigPrice = origPrice
+	err := database.Transaction(func(tx *gorm.DB) error {
+		if err := checkProductRefs(tx, 0, product.SKU, product.CategoryID); err != nil {
+			return err
+		}
+		if product.Slug != "" {
+			if err := checkSlug(tx, models.SlugEntityProduct, 0, product.Slug); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		return recordStockMovement(tx, product.ID, 0, product.Stock, stockMovementMeta{
+			Reason: models.StockReasonRestock,
+			RefID:  "product_create",
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if status, ok := reqMap["status"].(string); ok {
-		product.Status = status
+	// 清除可能存在的“商品不存在”占位缓存
+	invalidateProductCache(product.ID)
+	indexProductsAsync(product.ID)
+	if IsPublished(product.Status) {
+		invalidateCategoryTree()
 	}
 
-	if err := database.DB.Create(product).Error; err != nil {
+	logger.Info("创建商品成功", zap.Uint("product_id", product.ID))
+	return product, nil
+}
+
+// UpdateProduct 整体更新商品的可编辑字段，version 大于 0 时校验乐观锁版本
+func (s *ProductService) UpdateProduct(id uint, version int, req *UpdateProductRequest) (*models.Product, error) {
+	return s.editProduct(id, version, func(product *models.Product) map[string]interface{} {
+		updates := map[string]interface{}{
+			"name":              req.Name,
+			"description":       req.Description,
+			"price":             req.Price,
+			"orig_price":        req.OrigPrice,
+			"reorder_threshold": req.ReorderThreshold,
+			"sku":               req.SKU,
+			"category_id":       req.CategoryID,
+			"status":            editableStatus(req.Status, product.Stock),
+		}
+		if req.Slug != "" {
+			updates["slug"] = req.Slug
+		}
+		return updates
+	})
+}
+
+// PatchProduct 部分更新商品，version 大于 0 时校验乐观锁版本
+func (s *ProductService) PatchProduct(id uint, version int, req *PatchProductRequest) (*models.Product, error) {
+	return s.editProduct(id, version, func(product *models.Product) map[string]interface{} {
+		updates := make(map[string]interface{})
+		if req.Name != nil {
+			updates["name"] = *req.Name
+		}
+		if req.Description != nil {
+			updates["description"] = *req.Description
+		}
+		if req.Price != nil {
+			updates["price"] = *req.Price
+		}
+		if req.OrigPrice != nil {
+			updates["orig_price"] = *req.OrigPrice
+		}
+		if req.ReorderThreshold != nil {
+			updates["reorder_threshold"] = *req.ReorderThreshold
+		}
+		if req.SKU != nil {
+			updates["sku"] = *req.SKU
+		}
+		if req.CategoryID != nil {
+			updates["category_id"] = *req.CategoryID
+		}
+		if req.Status != nil {
+			updates["status"] = editableStatus(*req.Status, product.Stock)
+		}
+		if req.Slug != nil {
+			updates["slug"] = *req.Slug
+		}
+		return updates
+	})
+}
+
+// UpdateProductStatus 更新商品上下架状态，version 大于 0 时校验乐观锁版本
+func (s *ProductService) UpdateProductStatus(id uint, version int, status string) (*models.Product, error) {
+	product, err := s.editProduct(id, version, func(product *models.Product) map[string]interface{} {
+		return map[string]interface{}{"status": editableStatus(status, product.Stock)}
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	logger.Info("创建商品成功", zap.Uint("product_id", product.ID))
+	logger.Info("更新商品状态成功", zap.Uint("product_id", id), zap.String("status", product.Status))
 	return product, nil
 }
 
-// UpdateProduct 更新商品
-func (s *ProductService) UpdateProduct(id uint, updates map[string]interface{}) error {
-	var product models.Product
-	if err := database.DB.First(&product, id).Error; err != nil {
-		return err
+// editProduct 在事务内按版本号更新商品；build 根据当前商品生成要更新的字段（只含可编辑字段）
+// 更新条件带上读取时的版本号，读取与写入之间被他人修改时同样返回版本冲突；slug 变更时旧 slug 记入历史
+func (s *ProductService) editProduct(id uint, version int, build func(product *models.Product) map[string]interface{}) (*models.Product, error) {
+	var product, before models.Product
+	err := database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&product, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProductNotFound
+			}
+			return err
+		}
+		if version > 0 && product.Version != version {
+			return ErrProductVersionConflict
+		}
+		before = product
+
+		updates := build(&product)
+		if len(updates) == 0 {
+			return nil
+		}
+		sku, _ := updates["sku"].(string)
+		categoryID, _ := updates["category_id"].(uint)
+		if sku == product.SKU {
+			sku = ""
+		}
+		if categoryID == product.CategoryID {
+			categoryID = 0
+		}
+		if err := checkProductRefs(tx, product.ID, sku, categoryID); err != nil {
+			return err
+		}
+		if requested, ok := updates["slug"].(string); ok {
+			name, ok := updates["name"].(string)
+			if !ok {
+				name = product.Name
+			}
+			newSlug, err := changeSlug(tx, models.SlugEntityProduct, product.ID, product.Slug, requested, name)
+			if err != nil {
+				return err
+			}
+			updates["slug"] = newSlug
+		}
+
+		// 手动上架后不再需要定时上架
+		if status, ok := updates["status"].(string); ok && IsPublished(status) {
+			updates["publish_at"] = nil
+		}
+
+		updates["version"] = gorm.Expr("version + 1")
+		result := tx.Model(&product).Where("version = ?", product.Version).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrProductVersionConflict
+		}
+		return tx.First(&product, id).Error
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := database.DB.Model(&product).Updates(updates).Error; err != nil {
-		return err
+	invalidateProductCache(id)
+	indexProductsAsync(id)
+	if categoryCountChanged(&before, &product) {
+		invalidateCategoryTree()
 	}
 
-	// 清除缓存
-	ctx := context.Background()
-	cacheKey := fmt.Sprintf("product:%d", id)
-	database.RedisClient.Del(ctx, cacheKey)
+	logger.Info("更新商品成功", zap.Uint("product_id", id), zap.Int("version", product.Version))
+	return &product, nil
+}
 
-	logger.Info("更新商品成功", zap.Uint("product_id", id))
+// checkProductRefs 校验 SKU 唯一（含已删除商品，与唯一索引一致）及分类存在，为空/0 的参数不校验
+func checkProductRefs(tx *gorm.DB, productID uint, sku string, categoryID uint) error {
+	if sku != "" {
+		var count int64
+		if err := tx.Unscoped().Model(&models.Product{}).
+			Where("sku = ? AND id <> ?", sku, productID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return &FieldError{Field: "sku", Message: "SKU 已存在"}
+		}
+	}
+	if categoryID > 0 {
+		var count int64
+		if err := tx.Model(&models.Category{}).Where("id = ?", categoryID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return &FieldError{Field: "category_id", Message: "分类不存在"}
+		}
+	}
 	return nil
 }
 
+// editableStatus 上架但无库存的商品记为缺货，与库存变更时的状态维护一致
+func editableStatus(status string, stock int) string {
+	if status == "active" && stock == 0 {
+		return "out_of_stock"
+	}
+	return status
+}
+
 // DeleteProduct 删除商品（软删除）
 func (s *ProductService) DeleteProduct(id uint) error {
 	var product models.Product
@@ -368,25 +730,12 @@ func (s *ProductService) DeleteProduct(id uint) error {
 	}
 
 	// 清除缓存
-	ctx := context.Background()
-	cacheKey := fmt.Sprintf("product:%d", id)
-	database.RedisClient.Del(ctx, cacheKey)
-
-	logger.Info("删除商品成功", zap.Uint("product_id", id))
-	return nil
-}
-
-// UpdateProductStatus 更新商品状态
-func (s *ProductService) UpdateProductStatus(id uint, status string) error {
-	if err := database.DB.Model(&models.Product{}).Where("id = ?", id).Update("status", status).Error; err != nil {
-		return err
+	invalidateProductCache(id)
+	indexProductsAsync(id)
+	if IsPublished(product.Status) {
+		invalidateCategoryTree()
 	}
 
-	// 清除缓存
-	ctx := context.Background()
-	cacheKey := fmt.Sprintf("product:%d", id)
-	database.RedisClient.Del(ctx, cacheKey)
-
-	logger.Info("更新商品状态成功", zap.Uint("product_id", id), zap.String("status", status))
+	logger.Info("删除商品成功", zap.Uint("product_id", id))
 	return nil
 }
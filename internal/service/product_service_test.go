@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/shoppee/ecommerce/internal/database"
 	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/slug"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -26,22 +28,22 @@ func TestBatchUpdateStock(t *testing.T) {
 
 	tests := []struct {
 		name    string
-		updates map[uint]int
+		updates []StockUpdateItem
 		wantErr bool
 	}{
 		{
 			name: "正常更新",
-			updates: map[uint]int{
-				1: -10,
-				2: 20,
-				3: -5,
+			updates: []StockUpdateItem{
+				{ProductID: 1, Quantity: -10},
+				{ProductID: 2, Quantity: 20},
+				{ProductID: 3, Quantity: -5},
 			},
 			wantErr: false,
 		},
 		{
 			name: "库存不足",
-			updates: map[uint]int{
-				1: -200, // 超过库存
+			updates: []StockUpdateItem{
+				{ProductID: 1, Quantity: -200}, // 超过库存
 			},
 			wantErr: true,
 		},
@@ -49,7 +51,7 @@ func TestBatchUpdateStock(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := productService.BatchUpdateStock(tt.updates)
+			_, err := productService.BatchUpdateStock(0, BatchStockBestEffort, tt.updates)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -59,6 +61,45 @@ func TestBatchUpdateStock(t *testing.T) {
 	}
 }
 
+// TestBatchUpdateStockModes 测试事务模式整批回滚与尽力模式逐项结果
+func TestBatchUpdateStockModes(t *testing.T) {
+	setupTest()
+
+	productService := NewProductService()
+
+	run := time.Now().UnixNano()
+	p1 := models.Product{Name: "批量商品A", Price: 10, Stock: 10, SKU: fmt.Sprintf("BATCH_A_%d", run), Status: "active"}
+	p2 := models.Product{Name: "批量商品B", Price: 10, Stock: 5, SKU: fmt.Sprintf("BATCH_B_%d", run), Status: "active"}
+	database.DB.Create(&p1)
+	database.DB.Create(&p2)
+
+	stockOf := func(id uint) int {
+		var p models.Product
+		database.DB.First(&p, id)
+		return p.Stock
+	}
+
+	// 事务模式：B 库存不足，A 的扣减也必须回滚
+	results, err := productService.BatchUpdateStock(0, BatchStockAtomic, []StockUpdateItem{{ProductID: p1.ID, Quantity: -3}, {ProductID: p2.ID, Quantity: -6}})
+	assert.ErrorIs(t, err, ErrBatchStockRolledBack)
+	assert.Len(t, results, 2)
+	assert.Equal(t, p1.ID, results[0].ProductID)
+	assert.False(t, results[0].Success)
+	assert.Equal(t, ErrInsufficientStock.Error(), results[1].Error)
+	assert.Equal(t, 10, stockOf(p1.ID))
+	assert.Equal(t, 5, stockOf(p2.ID))
+
+	// 尽力模式：A 成功、B 失败，逐项返回
+	results, err = productService.BatchUpdateStock(0, BatchStockBestEffort, []StockUpdateItem{{ProductID: p2.ID, Quantity: -6}, {ProductID: p1.ID, Quantity: -3}})
+	assert.ErrorIs(t, err, ErrPartialStockUpdate)
+	assert.True(t, results[0].Success)
+	assert.Equal(t, 7, *results[0].NewStock)
+	assert.False(t, results[1].Success)
+	assert.Equal(t, ErrInsufficientStock.Error(), results[1].Error)
+	assert.Equal(t, 7, stockOf(p1.ID))
+	assert.Equal(t, 5, stockOf(p2.ID))
+}
+
 // TestBatchUpdateStockConcurrency 测试批量更新库存的并发安全性
 func TestBatchUpdateStockConcurrency(t *testing.T) {
 	setupTest()
@@ -83,8 +124,8 @@ func TestBatchUpdateStockConcurrency(t *testing.T) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			updates := map[uint]int{product.ID: -1}
-			productService.BatchUpdateStock(updates)
+			updates := []StockUpdateItem{{ProductID: product.ID, Quantity: -1}}
+			productService.BatchUpdateStock(0, BatchStockBestEffort, updates)
 		}()
 	}
 
@@ -98,6 +139,49 @@ func TestBatchUpdateStockConcurrency(t *testing.T) {
 	assert.Equal(t, 900, updatedProduct.Stock)
 }
 
+// TestProductEditVersion 测试商品编辑的字段校验与乐观锁
+func TestProductEditVersion(t *testing.T) {
+	setupTest()
+
+	productService := NewProductService()
+
+	category := models.Category{Name: fmt.Sprintf("编辑分类_%d", time.Now().UnixNano())}
+	database.DB.Create(&category)
+
+	run := time.Now().UnixNano()
+	product, err := productService.CreateProduct(&CreateProductRequest{
+		Name: "编辑商品", Price: 10, Stock: 5, SKU: fmt.Sprintf("EDIT_%d", run), CategoryID: category.ID,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, product.Version)
+
+	_, err = productService.CreateProduct(&CreateProductRequest{
+		Name: "编辑商品", Price: 10, SKU: product.SKU, CategoryID: category.ID,
+	})
+	var fieldErr *FieldError
+	if assert.ErrorAs(t, err, &fieldErr) {
+		assert.Equal(t, "sku", fieldErr.Field)
+	}
+
+	name := "编辑商品-新"
+	updated, err := productService.PatchProduct(product.ID, 1, &PatchProductRequest{Name: &name})
+	assert.NoError(t, err)
+	assert.Equal(t, name, updated.Name)
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, 5, updated.Stock)
+
+	// 使用过期版本号更新
+	price := 20.0
+	_, err = productService.PatchProduct(product.ID, 1, &PatchProductRequest{Price: &price})
+	assert.ErrorIs(t, err, ErrProductVersionConflict)
+
+	missing := uint(999999)
+	_, err = productService.PatchProduct(product.ID, 0, &PatchProductRequest{CategoryID: &missing})
+	if assert.ErrorAs(t, err, &fieldErr) {
+		assert.Equal(t, "category_id", fieldErr.Field)
+	}
+}
+
 // BenchmarkBatchUpdateStock 批量更新库存性能基准测试
 func BenchmarkBatchUpdateStock(b *testing.B) {
 	setupTest()
@@ -118,13 +202,54 @@ func BenchmarkBatchUpdateStock(b *testing.B) {
 	productService.BatchCreateProducts(products)
 
 	// 准备更新数据
-	updates := make(map[uint]int)
+	updates := make([]StockUpdateItem, 0, 100)
 	for i := uint(1); i <= 100; i++ {
-		updates[i] = -1
+		updates = append(updates, StockUpdateItem{ProductID: i, Quantity: -1})
 	}
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		productService.BatchUpdateStock(updates)
+		productService.BatchUpdateStock(0, BatchStockBestEffort, updates)
+	}
+}
+
+// TestCreateProductSameNameConcurrent 并发创建同名商品：全部创建成功且 slug 互不相同
+func TestCreateProductSameNameConcurrent(t *testing.T) {
+	setupTest()
+
+	productService := NewProductService()
+
+	category := models.Category{Name: fmt.Sprintf("并发分类_%d", time.Now().UnixNano())}
+	database.DB.Create(&category)
+
+	run := time.Now().UnixNano()
+	name := fmt.Sprintf("Race Product %d", run)
+
+	const n = 10
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	slugs := make([]string, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			product, err := productService.CreateProduct(&CreateProductRequest{
+				Name: name, Price: 10, SKU: fmt.Sprintf("RACE_%d_%d", run, i), CategoryID: category.ID,
+			})
+			if assert.NoError(t, err) {
+				slugs[i] = product.Slug
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for _, s := range slugs {
+		assert.NotEmpty(t, s)
+		assert.False(t, seen[s], "slug 重复: %s", s)
+		seen[s] = true
 	}
+	assert.True(t, seen[slug.Make(name)], "其中一个商品使用不带ID的 slug")
 }
@@ -0,0 +1,421 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+// 浏览量先累加到 Redis 哈希（商品ID → 增量），由后台任务定期批量写回数据库。
+// 写回时先把待写哈希改名为写回中哈希并分配随机批次号，浏览量更新与批次记录在同一事务内提交，
+// 成功后再删除写回中哈希；写库失败则保留下轮重试，删除失败或多个实例重叠写回时同一批次不会重复计入。
+// 展示用的实时浏览量单独保存在 Redis，不依赖详情缓存中的数据库值，写回后无需清除商品缓存。
+// 各键使用相同的哈希标签，Redis Cluster 下位于同一槽位，RENAME 和脚本才能执行
+const (
+	viewPendingKey   = "{product:views}:pending"
+	viewFlushingKey  = "{product:views}:flushing"
+	viewLiveKey      = "{product:views}:live" // 商品ID → 实时浏览量（含尚未写回的部分），首次写回后初始化
+	viewFlushLockKey = "{product:views}:lock"
+	viewBatchField   = "batch" // 写回中哈希内记录批次号的字段，商品字段均为数字ID
+
+	viewFlushBatchSize = 500            // 单条 UPDATE 最多写回的商品数
+	viewBatchRetention = 24 * time.Hour // 已写回批次记录的保留时间
+	viewBufferShards   = 32
+)
+
+// viewBuffer Redis 不可用时的进程内浏览量缓冲，按商品ID分片以降低锁竞争
+type viewBuffer struct {
+	shards [viewBufferShards]viewBufferShard
+}
+
+type viewBufferShard struct {
+	mu     sync.Mutex
+	counts map[uint]int64
+}
+
+var localViews = &viewBuffer{}
+
+// 累加待写回增量，商品的实时浏览量已初始化时同步累加
+var recordViewScript = redis.NewScript(`
+redis.call("HINCRBY", KEYS[1], ARGV[1], 1)
+if redis.call("HEXISTS", KEYS[2], ARGV[1]) == 1 then
+	redis.call("HINCRBY", KEYS[2], ARGV[1], 1)
+end
+return 1
+`)
+
+// 删除写回中哈希，并用写回后的数据库值加上新的待写增量初始化尚无实时浏览量的商品；
+// 两步原子执行，期间记录的浏览不会被漏计或重复计入
+var finishViewFlushScript = redis.NewScript(`
+redis.call("DEL", KEYS[1])
+for i = 1, #ARGV, 2 do
+	if redis.call("HEXISTS", KEYS[2], ARGV[i]) == 0 then
+		local pending = tonumber(redis.call("HGET", KEYS[3], ARGV[i]) or "0")
+		redis.call("HSET", KEYS[2], ARGV[i], tonumber(ARGV[i + 1]) + pending)
+	end
+end
+return 1
+`)
+
+// 把进程内缓冲写回的增量计入已初始化的实时浏览量
+var addLiveViewsScript = redis.NewScript(`
+for i = 1, #ARGV, 2 do
+	if redis.call("HEXISTS", KEYS[1], ARGV[i]) == 1 then
+		redis.call("HINCRBY", KEYS[1], ARGV[i], ARGV[i + 1])
+	end
+end
+return 1
+`)
+
+// add 累加商品浏览量
+func (b *viewBuffer) add(productID uint, delta int64) {
+	shard := &b.shards[productID%viewBufferShards]
+	shard.mu.Lock()
+	if shard.counts == nil {
+		shard.counts = make(map[uint]int64)
+	}
+	shard.counts[productID] += delta
+	shard.mu.Unlock()
+}
+
+// get 读取商品尚未写回的浏览量
+func (b *viewBuffer) get(productID uint) int64 {
+	shard := &b.shards[productID%viewBufferShards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	return shard.counts[productID]
+}
+
+// drain 取出并清空全部缓冲
+func (b *viewBuffer) drain() map[uint]int64 {
+	deltas := make(map[uint]int64)
+	for i := range b.shards {
+		shard := &b.shards[i]
+		shard.mu.Lock()
+		for id, n := range shard.counts {
+			deltas[id] += n
+		}
+		shard.counts = nil
+		shard.mu.Unlock()
+	}
+	return deltas
+}
+
+// RecordView 记录一次商品浏览（调用方须已确认商品对访问者可见）；Redis 不可用时先记在进程内，不阻塞请求也不直接写库
+func (s *ProductService) RecordView(productID uint) {
+	if database.RedisClient != nil {
+		field := strconv.FormatUint(uint64(productID), 10)
+		err := recordViewScript.Run(context.Background(), database.RedisClient,
+			[]string{viewPendingKey, viewLiveKey}, field).Err()
+		if err == nil {
+			return
+		}
+		logger.Warn("记录浏览量到Redis失败，改用本地缓冲", zap.Uint("product_id", productID), zap.Error(err))
+	}
+	localViews.add(productID, 1)
+}
+
+// withLiveViews 用 Redis 中的实时浏览量替换商品上的数据库值；尚未初始化的商品在数据库值上叠加待写回增量
+func withLiveViews(products ...*models.Product) {
+	if len(products) == 0 {
+		return
+	}
+	live, pending := liveViews(products)
+	for _, product := range products {
+		count, ok := live[product.ID]
+		if !ok {
+			count = int64(product.ViewCount) + pending[product.ID]
+		}
+		product.ViewCount = int(count + localViews.get(product.ID))
+	}
+}
+
+// liveViews 读取 Redis 中的实时浏览量，以及待写回和写回中的浏览量增量
+func liveViews(products []*models.Product) (live, pending map[uint]int64) {
+	live = make(map[uint]int64, len(products))
+	pending = make(map[uint]int64, len(products))
+	if database.RedisClient == nil {
+		return live, pending
+	}
+
+	fields := make([]string, len(products))
+	for i, product := range products {
+		fields[i] = strconv.FormatUint(uint64(product.ID), 10)
+	}
+	ctx := context.Background()
+	pipe := database.RedisClient.Pipeline()
+	liveCmd := pipe.HMGet(ctx, viewLiveKey, fields...)
+	pendingCmds := []*redis.SliceCmd{
+		pipe.HMGet(ctx, viewPendingKey, fields...),
+		pipe.HMGet(ctx, viewFlushingKey, fields...),
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return live, pending
+	}
+	for i, v := range liveCmd.Val() {
+		if n, ok := parseViewCount(v); ok {
+			live[products[i].ID] = n
+		}
+	}
+	for _, cmd := range pendingCmds {
+		for i, v := range cmd.Val() {
+			if n, ok := parseViewCount(v); ok {
+				pending[products[i].ID] += n
+			}
+		}
+	}
+	return live, pending
+}
+
+// parseViewCount 解析 HMGET 返回的单个值，字段不存在时 ok 为 false
+func parseViewCount(v interface{}) (int64, bool) {
+	str, ok := v.(string)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(str, 10, 64)
+	return n, err == nil
+}
+
+// RunViewCounterFlusher 定期把缓冲的浏览量批量写回数据库，直到 ctx 取消；退出前再写回一次
+func (s *ProductService) RunViewCounterFlusher(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.FlushViewCounts(context.Background(), interval)
+			return
+		case <-ticker.C:
+			s.FlushViewCounts(ctx, interval)
+		}
+	}
+}
+
+// FlushViewCounts 把本地缓冲和 Redis 中的浏览量增量写回数据库；Redis 部分同一时刻只由一个实例写回
+func (s *ProductService) FlushViewCounts(ctx context.Context, interval time.Duration) {
+	if local := localViews.drain(); len(local) > 0 {
+		if _, err := applyViewDeltas(ctx, "", local); err != nil {
+			logger.Error("写回本地浏览量失败", zap.Int("products", len(local)), zap.Error(err))
+			for id, n := range local {
+				localViews.add(id, n)
+			}
+		} else {
+			addLiveViews(ctx, local)
+		}
+	}
+
+	if database.RedisClient == nil || !s.acquireViewFlushLock(interval) {
+		return
+	}
+	batch, deltas, err := takeRedisViews(ctx)
+	if err != nil {
+		logger.Error("读取待写回浏览量失败", zap.Error(err))
+		return
+	}
+	if batch == "" {
+		return
+	}
+	counts, err := applyViewDeltas(ctx, batch, deltas)
+	if err != nil {
+		logger.Error("写回浏览量失败，下轮重试", zap.String("batch", batch), zap.Int("products", len(deltas)), zap.Error(err))
+		return
+	}
+	if err := finishViewFlushScript.Run(ctx, database.RedisClient,
+		[]string{viewFlushingKey, viewLiveKey, viewPendingKey}, viewCountArgs(counts)...).Err(); err != nil {
+		logger.Error("清除已写回浏览量失败，下轮按批次号跳过", zap.String("batch", batch), zap.Error(err))
+	}
+}
+
+// addLiveViews 把进程内缓冲写回的增量计入实时浏览量
+func addLiveViews(ctx context.Context, deltas map[uint]int64) {
+	if database.RedisClient == nil {
+		return
+	}
+	if err := addLiveViewsScript.Run(ctx, database.RedisClient,
+		[]string{viewLiveKey}, viewCountArgs(deltas)...).Err(); err != nil {
+		logger.Warn("更新实时浏览量失败", zap.Int("products", len(deltas)), zap.Error(err))
+	}
+}
+
+// viewCountArgs 把商品ID与数量展开为脚本参数 id1, n1, id2, n2, ...
+func viewCountArgs(counts map[uint]int64) []interface{} {
+	args := make([]interface{}, 0, len(counts)*2)
+	for id, n := range counts {
+		args = append(args, id, n)
+	}
+	return args
+}
+
+// acquireViewFlushLock 获取本轮写回的执行权（锁在一个周期后过期）；写回耗时超过周期导致重叠执行时，由批次号保证不重复计入
+func (s *ProductService) acquireViewFlushLock(interval time.Duration) bool {
+	ttl := interval - time.Second
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	ok, err := database.RedisClient.SetNX(context.Background(), viewFlushLockKey, 1, ttl).Result()
+	return err == nil && ok
+}
+
+// takeRedisViews 取出本轮要写回的批次：上轮遗留的写回中哈希优先重试，否则把待写哈希改名后分配批次号。
+// 没有待写回的浏览量时 batch 为空
+func takeRedisViews(ctx context.Context) (string, map[uint]int64, error) {
+	// 写回中哈希已存在时 RENAMENX 不覆盖它，待写哈希不存在时返回 no such key
+	if err := database.RedisClient.RenameNX(ctx, viewPendingKey, viewFlushingKey).Err(); err != nil &&
+		!strings.Contains(err.Error(), "no such key") {
+		return "", nil, err
+	}
+
+	raw, err := database.RedisClient.HGetAll(ctx, viewFlushingKey).Result()
+	if err != nil || len(raw) == 0 {
+		return "", nil, err
+	}
+	if raw[viewBatchField] == "" {
+		id, err := newViewBatchID()
+		if err != nil {
+			return "", nil, err
+		}
+		// 并发分配时以先写入的批次号为准
+		if err := database.RedisClient.HSetNX(ctx, viewFlushingKey, viewBatchField, id).Err(); err != nil {
+			return "", nil, err
+		}
+		if raw[viewBatchField], err = database.RedisClient.HGet(ctx, viewFlushingKey, viewBatchField).Result(); err != nil {
+			return "", nil, err
+		}
+	}
+	return raw[viewBatchField], parseViewDeltas(raw), nil
+}
+
+// newViewBatchID 生成随机批次号（128 位），不依赖 Redis 计数器，Redis 重启或清空后也不会与已记录的批次冲突
+func newViewBatchID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成浏览量批次号失败: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// parseViewDeltas 解析 Redis 哈希中的商品ID与增量，忽略批次号字段及无法解析或非正的项
+func parseViewDeltas(raw map[string]string) map[uint]int64 {
+	deltas := make(map[uint]int64, len(raw))
+	for field, value := range raw {
+		id, err := strconv.ParseUint(field, 10, 64)
+		if err != nil || id == 0 {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n <= 0 {
+			continue
+		}
+		deltas[uint(id)] += n
+	}
+	return deltas
+}
+
+// applyViewDeltas 分批执行 UPDATE ... FROM (VALUES ...) 写回浏览量，整体在一个事务内，返回写回后各商品的浏览量。
+// batch 非空时同一事务内记录批次号，批次已写回过则跳过并返回当前浏览量；进程内缓冲不会重复取出，batch 为空
+func applyViewDeltas(ctx context.Context, batch string, deltas map[uint]int64) (map[uint]int64, error) {
+	ids := make([]uint, 0, len(deltas))
+	for id := range deltas {
+		ids = append(ids, id)
+	}
+	// 固定加锁顺序，避免与其他批量更新互相死锁
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	tx := database.DB.WithContext(ctx).Begin()
+	if batch != "" {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ViewFlushBatch{ID: batch})
+		if result.Error != nil {
+			tx.Rollback()
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			tx.Rollback()
+			logger.Info("浏览量批次已写回，跳过", zap.String("batch", batch))
+			return queryViewCounts(ctx, ids)
+		}
+		if err := tx.Where("created_at < ?", time.Now().Add(-viewBatchRetention)).
+			Delete(&models.ViewFlushBatch{}).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	counts := make(map[uint]int64, len(ids))
+	for start := 0; start < len(ids); start += viewFlushBatchSize {
+		end := start + viewFlushBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		var rows []viewCountRow
+		sql, args := viewDeltaUpdate(ids[start:end], deltas)
+		if err := tx.Raw(sql, args...).Scan(&rows).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		for _, row := range rows {
+			counts[row.ID] = row.ViewCount
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+type viewCountRow struct {
+	ID        uint
+	ViewCount int64
+}
+
+// queryViewCounts 分批读取商品当前的浏览量
+func queryViewCounts(ctx context.Context, ids []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(ids))
+	for start := 0; start < len(ids); start += viewFlushBatchSize {
+		end := start + viewFlushBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		var rows []viewCountRow
+		if err := database.DB.WithContext(ctx).Model(&models.Product{}).
+			Select("id, view_count").
+			Where("id IN ?", ids[start:end]).
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			counts[row.ID] = row.ViewCount
+		}
+	}
+	return counts, nil
+}
+
+// viewDeltaUpdate 构造一批商品浏览量增量的 UPDATE 语句，返回更新后的浏览量
+func viewDeltaUpdate(ids []uint, deltas map[uint]int64) (string, []interface{}) {
+	values := make([]string, len(ids))
+	args := make([]interface{}, 0, len(ids)*2)
+	for i, id := range ids {
+		values[i] = "(?::bigint, ?::bigint)"
+		args = append(args, id, deltas[id])
+	}
+	return "UPDATE products SET view_count = products.view_count + v.delta FROM (VALUES " +
+		strings.Join(values, ", ") + ") AS v(id, delta) WHERE products.id = v.id" +
+		" RETURNING products.id, products.view_count", args
+}
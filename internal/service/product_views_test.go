@@ -0,0 +1,83 @@
+package service
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseViewDeltas 测试解析 Redis 中的浏览量增量
+func TestParseViewDeltas(t *testing.T) {
+	deltas := parseViewDeltas(map[string]string{
+		"1":   "3",
+		"42":  "10",
+		"abc": "5",
+		"0":   "7",
+		"9":   "-2",
+		"8":   "x",
+
+		viewBatchField: "9f86d081884c7d659a2feaa0c55ad015",
+	})
+
+	assert.Equal(t, map[uint]int64{1: 3, 42: 10}, deltas)
+}
+
+// TestNewViewBatchID 测试批次号随机生成且互不重复
+func TestNewViewBatchID(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id, err := newViewBatchID()
+		assert.NoError(t, err)
+		assert.Len(t, id, 32)
+		assert.False(t, seen[id], "批次号重复")
+		seen[id] = true
+	}
+}
+
+// TestViewDeltaUpdate 测试批量写回语句的构造
+func TestViewDeltaUpdate(t *testing.T) {
+	sql, args := viewDeltaUpdate([]uint{3, 7}, map[uint]int64{3: 5, 7: 1})
+
+	assert.Equal(t, "UPDATE products SET view_count = products.view_count + v.delta FROM "+
+		"(VALUES (?::bigint, ?::bigint), (?::bigint, ?::bigint)) AS v(id, delta) WHERE products.id = v.id"+
+		" RETURNING products.id, products.view_count", sql)
+	assert.Equal(t, []interface{}{uint(3), int64(5), uint(7), int64(1)}, args)
+}
+
+// TestParseViewCount 测试解析 HMGET 返回值
+func TestParseViewCount(t *testing.T) {
+	n, ok := parseViewCount("12")
+	assert.True(t, ok)
+	assert.Equal(t, int64(12), n)
+
+	_, ok = parseViewCount(nil)
+	assert.False(t, ok, "字段不存在")
+	_, ok = parseViewCount("x")
+	assert.False(t, ok)
+}
+
+// TestViewBuffer 测试本地浏览量缓冲的并发累加与取出
+func TestViewBuffer(t *testing.T) {
+	buf := &viewBuffer{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := uint(1); id <= 40; id++ {
+				buf.add(id, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(50), buf.get(33))
+
+	deltas := buf.drain()
+	assert.Len(t, deltas, 40)
+	assert.Equal(t, int64(50), deltas[1])
+	assert.Equal(t, int64(0), buf.get(1), "取出后缓冲应清空")
+	assert.Empty(t, buf.drain())
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 最近浏览记录
+const (
+	recentlyViewedLimit      = 50                  // 每人最多保留的商品数
+	recentlyViewedUserTTL    = 90 * 24 * time.Hour // 登录用户记录的保留时间（每次浏览续期）
+	recentlyViewedVisitorTTL = 7 * 24 * time.Hour  // 匿名访客记录的保留时间，登录时合并到用户
+)
+
+// visitorIDPattern 匿名访客ID（客户端生成，如 UUID）
+var visitorIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{8,64}$`)
+
+// RecentlyViewedService 最近浏览服务（Redis 有序集合，成员为商品ID，分值为浏览时间）
+type RecentlyViewedService struct{}
+
+// NewRecentlyViewedService 创建最近浏览服务实例
+func NewRecentlyViewedService() *RecentlyViewedService {
+	return &RecentlyViewedService{}
+}
+
+// RecentlyViewedItem 最近浏览的商品
+type RecentlyViewedItem struct {
+	Product  models.Product `json:"product"`
+	ViewedAt time.Time      `json:"viewed_at"`
+}
+
+// ValidVisitorID 校验匿名访客ID格式
+func ValidVisitorID(visitorID string) bool {
+	return visitorIDPattern.MatchString(visitorID)
+}
+
+// recentlyViewedKey 浏览记录键：登录用户按用户ID，匿名访客按访客ID
+func recentlyViewedKey(userID uint, visitorID string) (string, time.Duration, bool) {
+	if userID > 0 {
+		return fmt.Sprintf("recently_viewed:user:%d", userID), recentlyViewedUserTTL, true
+	}
+	if ValidVisitorID(visitorID) {
+		return "recently_viewed:visitor:" + visitorID, recentlyViewedVisitorTTL, true
+	}
+	return "", 0, false
+}
+
+// Record 记录一次浏览：重复浏览只更新时间，超出上限时淘汰最早的记录
+func (s *RecentlyViewedService) Record(userID uint, visitorID string, productID uint) {
+	key, ttl, ok := recentlyViewedKey(userID, visitorID)
+	if !ok || database.RedisClient == nil {
+		return
+	}
+
+	ctx := context.Background()
+	pipe := database.RedisClient.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(time.Now().UnixMilli()), Member: strconv.FormatUint(uint64(productID), 10)})
+	pipe.ZRemRangeByRank(ctx, key, 0, -recentlyViewedLimit-1)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Warn("记录最近浏览失败", zap.Uint("product_id", productID), zap.Error(err))
+	}
+}
+
+// List 获取最近浏览的商品（按浏览时间倒序），已删除的商品会从记录中移除；Redis 不可用时返回空列表
+func (s *RecentlyViewedService) List(userID uint, limit int) ([]RecentlyViewedItem, error) {
+	if database.RedisClient == nil {
+		return []RecentlyViewedItem{}, nil
+	}
+	if limit <= 0 || limit > recentlyViewedLimit {
+		limit = recentlyViewedLimit
+	}
+	key, _, _ := recentlyViewedKey(userID, "")
+
+	ctx := context.Background()
+	entries, err := database.RedisClient.ZRevRangeWithScores(ctx, key, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(entries))
+	for _, e := range entries {
+		if id, err := strconv.ParseUint(e.Member, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	products, err := loadListProducts(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]RecentlyViewedItem, 0, len(entries))
+	var missing []interface{}
+	for _, e := range entries {
+		id, _ := strconv.ParseUint(e.Member, 10, 64)
+		product, ok := products[uint(id)]
+		if !ok {
+			missing = append(missing, e.Member)
+			continue
+		}
+		items = append(items, RecentlyViewedItem{Product: product, ViewedAt: time.UnixMilli(int64(e.Score))})
+	}
+	if len(missing) > 0 {
+		database.RedisClient.ZRem(ctx, key, missing...)
+	}
+	return items, nil
+}
+
+// Clear 清空最近浏览记录
+func (s *RecentlyViewedService) Clear(userID uint) error {
+	if database.RedisClient == nil {
+		return nil
+	}
+	key, _, _ := recentlyViewedKey(userID, "")
+	return database.RedisClient.Del(context.Background(), key).Err()
+}
+
+// MergeVisitor 登录时把匿名访客的浏览记录合并到用户：同一商品取较晚的浏览时间，合并后仍按上限截断
+func (s *RecentlyViewedService) MergeVisitor(userID uint, visitorID string) error {
+	if userID == 0 || database.RedisClient == nil {
+		return nil
+	}
+	if !ValidVisitorID(visitorID) {
+		return errors.New("无效的访客ID")
+	}
+	userKey, ttl, _ := recentlyViewedKey(userID, "")
+	visitorKey, _, _ := recentlyViewedKey(0, visitorID)
+
+	ctx := context.Background()
+	pipe := database.RedisClient.TxPipeline()
+	pipe.ZUnionStore(ctx, userKey, &redis.ZStore{Keys: []string{userKey, visitorKey}, Aggregate: "MAX"})
+	pipe.ZRemRangeByRank(ctx, userKey, 0, -recentlyViewedLimit-1)
+	pipe.Expire(ctx, userKey, ttl)
+	pipe.Del(ctx, visitorKey)
+	_, err := pipe.Exec(ctx)
+	return err
+}
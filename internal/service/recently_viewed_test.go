@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/shoppee/ecommerce/internal/config"
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRecentlyViewedKey 测试登录用户与匿名访客的浏览记录键
+func TestRecentlyViewedKey(t *testing.T) {
+	key, ttl, ok := recentlyViewedKey(42, "lq1x2y3z-abcdef1234")
+	assert.True(t, ok)
+	assert.Equal(t, "recently_viewed:user:42", key, "登录用户优先按用户记录")
+	assert.Equal(t, recentlyViewedUserTTL, ttl)
+
+	key, ttl, ok = recentlyViewedKey(0, "lq1x2y3z-abcdef1234")
+	assert.True(t, ok)
+	assert.Equal(t, "recently_viewed:visitor:lq1x2y3z-abcdef1234", key)
+	assert.Equal(t, recentlyViewedVisitorTTL, ttl)
+
+	for _, visitorID := range []string{"", "short", "has space 123", "user:1:injected"} {
+		_, _, ok = recentlyViewedKey(0, visitorID)
+		assert.False(t, ok, visitorID)
+	}
+}
+
+// setupRedisTest 连接测试用 Redis，不可用时跳过
+func setupRedisTest(t *testing.T) {
+	t.Helper()
+	config.InitConfig()
+	if err := database.InitRedis(); err != nil {
+		database.RedisClient = nil
+		t.Skipf("Redis 不可用: %v", err)
+	}
+}
+
+// recentlyViewedIDs 按浏览时间倒序读取记录中的商品ID
+func recentlyViewedIDs(t *testing.T, key string) []uint {
+	t.Helper()
+	members, err := database.RedisClient.ZRevRange(context.Background(), key, 0, -1).Result()
+	require.NoError(t, err)
+	ids := make([]uint, 0, len(members))
+	for _, m := range members {
+		id, err := strconv.ParseUint(m, 10, 64)
+		require.NoError(t, err)
+		ids = append(ids, uint(id))
+	}
+	return ids
+}
+
+// recordViews 依次浏览商品，间隔保证毫秒级的浏览时间互不相同
+func recordViews(s *RecentlyViewedService, userID uint, visitorID string, productIDs ...uint) {
+	for _, id := range productIDs {
+		s.Record(userID, visitorID, id)
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+// TestRecentlyViewedWithoutRedis Redis 不可用时列表为空、清空不报错
+func TestRecentlyViewedWithoutRedis(t *testing.T) {
+	saved := database.RedisClient
+	database.RedisClient = nil
+	defer func() { database.RedisClient = saved }()
+
+	s := NewRecentlyViewedService()
+	s.Record(1, "", 1)
+
+	items, err := s.List(1, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+	assert.NoError(t, s.Clear(1))
+	assert.NoError(t, s.MergeVisitor(1, "lq1x2y3z-abcdef1234"))
+}
+
+// TestRecentlyViewedRecord 重复浏览移到最前且不重复，超出上限时淘汰最早的记录
+func TestRecentlyViewedRecord(t *testing.T) {
+	setupRedisTest(t)
+
+	s := NewRecentlyViewedService()
+	userID := uint(time.Now().UnixNano()%1000000000) + 1
+	key, _, _ := recentlyViewedKey(userID, "")
+	defer s.Clear(userID)
+
+	recordViews(s, userID, "", 1, 2, 3, 1)
+	assert.Equal(t, []uint{1, 3, 2}, recentlyViewedIDs(t, key), "重复浏览只更新时间")
+
+	for id := uint(100); id < 100+recentlyViewedLimit; id++ {
+		recordViews(s, userID, "", id)
+	}
+	ids := recentlyViewedIDs(t, key)
+	assert.Len(t, ids, recentlyViewedLimit)
+	assert.Equal(t, uint(100+recentlyViewedLimit-1), ids[0])
+	assert.NotContains(t, ids, uint(1), "超出上限后最早的记录被淘汰")
+	assert.NotContains(t, ids, uint(2))
+}
+
+// TestRecentlyViewedMergeVisitor 登录时合并访客记录：同一商品取较晚的浏览时间，合并后删除访客记录
+func TestRecentlyViewedMergeVisitor(t *testing.T) {
+	setupRedisTest(t)
+
+	s := NewRecentlyViewedService()
+	run := time.Now().UnixNano()
+	userID := uint(run%1000000000) + 1
+	visitorID := "visitor-" + strconv.FormatInt(run, 36)
+	userKey, _, _ := recentlyViewedKey(userID, "")
+	visitorKey, _, _ := recentlyViewedKey(0, visitorID)
+	defer s.Clear(userID)
+
+	recordViews(s, userID, "", 1, 2)
+	recordViews(s, 0, visitorID, 3, 1)
+
+	require.NoError(t, s.MergeVisitor(userID, visitorID))
+	assert.Equal(t, []uint{1, 3, 2}, recentlyViewedIDs(t, userKey))
+
+	exists, err := database.RedisClient.Exists(context.Background(), visitorKey).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), exists, "合并后删除访客记录")
+
+	assert.Error(t, s.MergeVisitor(userID, "bad id"))
+}
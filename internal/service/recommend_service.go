@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/shoppee/ecommerce/internal/config"
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 关联商品来源
+const (
+	RelatedReasonBoughtTogether = "bought_together" // 经常一起购买
+	RelatedReasonSameCategory   = "same_category"   // 关联数据不足时以同分类热销商品补足
+)
+
+// 计算关联时统计的订单状态（已付款及之后）
+var relationOrderStatuses = []string{"paid", "shipped", "completed"}
+
+// 多实例部署时只允许一个实例执行离线计算
+const relationRefreshLockKey = "recommend:relations:lock"
+
+// RecommendService 商品推荐服务
+type RecommendService struct{}
+
+// NewRecommendService 创建商品推荐服务实例
+func NewRecommendService() *RecommendService {
+	return &RecommendService{}
+}
+
+// RelatedProduct 关联商品
+type RelatedProduct struct {
+	models.Product
+	Reason string  `json:"reason"`          // bought_together, same_category
+	Score  float64 `json:"score,omitempty"` // 关联度（仅 bought_together）
+}
+
+// coOccurrence 两个商品同时出现的订单数
+type coOccurrence struct {
+	ProductID        uint
+	RelatedProductID uint
+	Support          int
+}
+
+// GetRelatedProducts 获取经常一起购买的商品，不足 limit 个时以同分类热销商品补足
+func (s *RecommendService) GetRelatedProducts(productID uint, limit int, admin bool) ([]RelatedProduct, error) {
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	var product models.Product
+	if err := database.DB.Select("id", "category_id", "status").First(&product, productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	// 草稿和已下架商品只有管理员可预览
+	if !admin && !IsPublished(product.Status) {
+		return nil, ErrProductNotFound
+	}
+
+	var relations []models.ProductRelation
+	if err := database.DB.
+		Joins("JOIN products ON products.id = product_relations.related_product_id AND products.deleted_at IS NULL AND products.status = ?", "active").
+		Where("product_relations.product_id = ?", productID).
+		Order("product_relations.score DESC, product_relations.related_product_id ASC").
+		Limit(limit).
+		Find(&relations).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(relations))
+	for _, r := range relations {
+		ids = append(ids, r.RelatedProductID)
+	}
+	products, err := loadListProducts(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]RelatedProduct, 0, limit)
+	exclude := []uint{productID}
+	for _, r := range relations {
+		p, ok := products[r.RelatedProductID]
+		if !ok {
+			continue
+		}
+		result = append(result, RelatedProduct{Product: p, Reason: RelatedReasonBoughtTogether, Score: r.Score})
+		exclude = append(exclude, p.ID)
+	}
+	if len(result) >= limit || product.CategoryID == 0 {
+		return result, nil
+	}
+
+	// 同分类热销商品补足
+	var fallback []models.Product
+	if err := database.DB.Preload("Media", "sort = ?", 0).Preload("Media.Media.Thumbnails").
+		Where("category_id = ? AND status = ? AND id NOT IN ?", product.CategoryID, "active", exclude).
+		Order("sale_count DESC, id DESC").
+		Limit(limit - len(result)).
+		Find(&fallback).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range fallback {
+		result = append(result, RelatedProduct{Product: p, Reason: RelatedReasonSameCategory})
+	}
+	return result, nil
+}
+
+// loadListProducts 按ID加载列表展示用的商品（含主图）
+func loadListProducts(ids []uint) (map[uint]models.Product, error) {
+	result := make(map[uint]models.Product, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var products []models.Product
+	if err := database.DB.Preload("Media", "sort = ?", 0).Preload("Media.Media.Thumbnails").
+		Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range products {
+		result[p.ID] = p
+	}
+	return result, nil
+}
+
+// RunRelationRefresher 启动时及之后每隔 interval 重新计算商品关联
+func (s *RecommendService) RunRelationRefresher(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if s.acquireRefreshLock(interval) {
+			if _, err := s.RefreshRelations(ctx); err != nil && ctx.Err() == nil {
+				logger.Error("计算商品关联失败", zap.Error(err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// acquireRefreshLock 获取本轮计算的执行权（锁在一个周期后过期）；Redis 不可用时各实例各自计算
+func (s *RecommendService) acquireRefreshLock(interval time.Duration) bool {
+	if database.RedisClient == nil {
+		return true
+	}
+	ok, err := database.RedisClient.SetNX(context.Background(), relationRefreshLockKey, 1, interval-time.Second).Result()
+	if err != nil {
+		return true
+	}
+	return ok
+}
+
+// RefreshRelations 根据近期订单的订单项共现重新计算并整体替换商品关联表，返回写入的关联数
+func (s *RecommendService) RefreshRelations(ctx context.Context) (int, error) {
+	cfg := config.AppConfig.Recommend
+	since := time.Now().AddDate(0, 0, -cfg.LookbackDays)
+	minSupport := cfg.MinSupport
+	if minSupport < 1 {
+		minSupport = 1
+	}
+	start := time.Now()
+	db := database.DB.WithContext(ctx)
+
+	// 订单中同一商品可能出现多行（不同规格），按订单去重
+	orderProducts := db.Table("order_items").
+		Select("DISTINCT order_items.order_id, order_items.product_id").
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Where("order_items.deleted_at IS NULL AND orders.status IN ? AND orders.created_at >= ?", relationOrderStatuses, since)
+
+	var counts []struct {
+		ProductID uint
+		Orders    int
+	}
+	if err := db.Table("(?) AS op", orderProducts).
+		Select("op.product_id, COUNT(*) AS orders").
+		Group("op.product_id").
+		Scan(&counts).Error; err != nil {
+		return 0, err
+	}
+	orderCounts := make(map[uint]int, len(counts))
+	for _, c := range counts {
+		orderCounts[c.ProductID] = c.Orders
+	}
+
+	var pairs []coOccurrence
+	if err := db.Table("(?) AS a", orderProducts).
+		Select("a.product_id, b.product_id AS related_product_id, COUNT(*) AS support").
+		Joins("JOIN (?) AS b ON b.order_id = a.order_id AND b.product_id <> a.product_id", orderProducts).
+		Group("a.product_id, b.product_id").
+		Having("COUNT(*) >= ?", minSupport).
+		Scan(&pairs).Error; err != nil {
+		return 0, err
+	}
+
+	relations := buildRelations(pairs, orderCounts, cfg.MaxRelated)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM product_relations").Error; err != nil {
+			return err
+		}
+		if len(relations) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&relations, 500).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Info("商品关联计算完成",
+		zap.Int("products", len(orderCounts)),
+		zap.Int("relations", len(relations)),
+		zap.Duration("elapsed", time.Since(start)))
+	return len(relations), nil
+}
+
+// buildRelations 由共现次数计算关联度，每个商品保留关联度最高的 maxRelated 个
+// 关联度采用余弦相似度，避免热销商品与所有商品都高度关联
+func buildRelations(pairs []coOccurrence, orderCounts map[uint]int, maxRelated int) []models.ProductRelation {
+	byProduct := make(map[uint][]models.ProductRelation)
+	for _, p := range pairs {
+		a, b := orderCounts[p.ProductID], orderCounts[p.RelatedProductID]
+		if a == 0 || b == 0 {
+			continue
+		}
+		byProduct[p.ProductID] = append(byProduct[p.ProductID], models.ProductRelation{
+			ProductID:        p.ProductID,
+			RelatedProductID: p.RelatedProductID,
+			Support:          p.Support,
+			Score:            math.Round(float64(p.Support)/math.Sqrt(float64(a)*float64(b))*1e6) / 1e6,
+		})
+	}
+
+	productIDs := make([]uint, 0, len(byProduct))
+	for id := range byProduct {
+		productIDs = append(productIDs, id)
+	}
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+
+	var result []models.ProductRelation
+	for _, id := range productIDs {
+		related := byProduct[id]
+		sort.Slice(related, func(i, j int) bool {
+			if related[i].Score != related[j].Score {
+				return related[i].Score > related[j].Score
+			}
+			if related[i].Support != related[j].Support {
+				return related[i].Support > related[j].Support
+			}
+			return related[i].RelatedProductID < related[j].RelatedProductID
+		})
+		if maxRelated > 0 && len(related) > maxRelated {
+			related = related[:maxRelated]
+		}
+		result = append(result, related...)
+	}
+	return result
+}
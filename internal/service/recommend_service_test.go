@@ -0,0 +1,32 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBuildRelations 测试由共现次数计算关联度与截断
+func TestBuildRelations(t *testing.T) {
+	// 商品1出现在4个订单中，其中2个含商品2、1个含商品3；商品3是热销商品
+	pairs := []coOccurrence{
+		{ProductID: 1, RelatedProductID: 2, Support: 2},
+		{ProductID: 1, RelatedProductID: 3, Support: 2},
+		{ProductID: 1, RelatedProductID: 4, Support: 1},
+		{ProductID: 2, RelatedProductID: 1, Support: 2},
+		{ProductID: 3, RelatedProductID: 1, Support: 2},
+	}
+	counts := map[uint]int{1: 4, 2: 2, 3: 100, 4: 1}
+
+	relations := buildRelations(pairs, counts, 2)
+	assert.Len(t, relations, 4)
+
+	// 商品2与商品1的关联度高于热销商品3
+	assert.Equal(t, uint(1), relations[0].ProductID)
+	assert.Equal(t, uint(2), relations[0].RelatedProductID)
+	assert.InDelta(t, 2/(2*1.4142135), relations[0].Score, 1e-4)
+	assert.Equal(t, uint(4), relations[1].RelatedProductID, "截断到每个商品2个关联")
+
+	assert.Equal(t, uint(2), relations[2].ProductID)
+	assert.Equal(t, uint(3), relations[3].ProductID)
+}
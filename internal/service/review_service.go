@@ -8,6 +8,7 @@ import (
 	"github.com/shoppee/ecommerce/internal/models"
 	"github.com/shoppee/ecommerce/pkg/logger"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // ReviewService 评价服务
@@ -18,47 +19,100 @@ func NewReviewService() *ReviewService {
 	return &ReviewService{}
 }
 
+// CreateReviewRequest 创建评价请求
+type CreateReviewRequest struct {
+	ProductID uint   `json:"product_id" binding:"required"`
+	VariantID uint   `json:"variant_id"` // 多规格商品评价的规格，关联订单时以订单项为准
+	OrderID   uint   `json:"order_id"`
+	Rating    int    `json:"rating" binding:"required,gte=1,lte=5"`
+	Content   string `json:"content"`
+	MediaIDs  []uint `json:"media_ids" binding:"max=9"` // 晒图，需先通过上传接口上传，按数组顺序展示
+}
+
 // CreateReview 创建评价
-func (s *ReviewService) CreateReview(userID uint, req interface{}) (*models.Review, error) {
-	type ReviewReq struct {
-		ProductID uint   `json:"product_id"`
-		OrderID   uint   `json:"order_id"`
-		Rating    int    `json:"rating"`
-		Content   string `json:"content"`
-		Images    string `json:"images"`
+func (s *ReviewService) CreateReview(userID uint, req *CreateReviewRequest) (*models.Review, error) {
+	variantID, err := s.resolveReviewVariant(userID, req)
+	if err != nil {
+		return nil, err
 	}
-	
-	reqStruct, ok := req.(*ReviewReq)
-	if !ok {
-		return nil, errors.New("无效的请求数据")
+	if err := checkMediaIDs(req.MediaIDs, userID, maxReviewMedia); err != nil {
+		return nil, err
 	}
 	
-	// 检查是否已评价
+	// 检查是否已评价（同一订单中的不同规格分别评价）
+	query := database.DB.Where("user_id = ? AND product_id = ? AND order_id = ?", userID, req.ProductID, req.OrderID)
+	if variantID == nil {
+		query = query.Where("variant_id IS NULL")
+	} else {
+		query = query.Where("variant_id = ?", *variantID)
+	}
 	var existingReview models.Review
-	if err := database.DB.Where("user_id = ? AND product_id = ? AND order_id = ?", 
-		userID, reqStruct.ProductID, reqStruct.OrderID).First(&existingReview).Error; err == nil {
+	if err := query.First(&existingReview).Error; err == nil {
 		return nil, errors.New("该订单商品已评价")
 	}
 	
 	// 创建评价
 	review := &models.Review{
 		UserID:    userID,
-		ProductID: reqStruct.ProductID,
-		OrderID:   reqStruct.OrderID,
-		Rating:    reqStruct.Rating,
-		Content:   reqStruct.Content,
-		Images:    reqStruct.Images,
+		ProductID: req.ProductID,
+		VariantID: variantID,
+		OrderID:   req.OrderID,
+		Rating:    req.Rating,
+		Content:   req.Content,
 		Status:    "published",
 	}
 	
-	if err := database.DB.Create(review).Error; err != nil {
+	err = database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(review).Error; err != nil {
+			return err
+		}
+		for i, mediaID := range req.MediaIDs {
+			if err := tx.Create(&models.ReviewMedia{ReviewID: review.ID, MediaID: mediaID, Sort: i}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
+	invalidateProductCache(review.ProductID)
 	
 	logger.Info("创建评价成功", zap.Uint("user_id", userID), zap.Uint("review_id", review.ID))
 	return review, nil
 }
 
+// resolveReviewVariant 确定评价对应的规格：关联订单时取订单项中的规格，否则校验请求中的规格
+func (s *ReviewService) resolveReviewVariant(userID uint, req *CreateReviewRequest) (*uint, error) {
+	if req.OrderID != 0 {
+		query := database.DB.Joins("JOIN orders ON orders.id = order_items.order_id").
+			Where("order_items.order_id = ? AND order_items.product_id = ? AND orders.user_id = ?", req.OrderID, req.ProductID, userID)
+		if req.VariantID != 0 {
+			query = query.Where("order_items.variant_id = ?", req.VariantID)
+		}
+		var items []models.OrderItem
+		if err := query.Find(&items).Error; err != nil {
+			return nil, err
+		}
+		if len(items) == 0 {
+			return nil, errors.New("订单中没有该商品")
+		}
+		if len(items) > 1 {
+			return nil, ErrVariantRequired
+		}
+		return items[0].VariantID, nil
+	}
+	
+	if req.VariantID == 0 {
+		return nil, nil
+	}
+	if _, err := findVariant(database.DB, req.ProductID, req.VariantID); err != nil {
+		return nil, err
+	}
+	variantID := req.VariantID
+	return &variantID, nil
+}
+
 // GetProductReviews 获取商品评价列表
 func (s *ReviewService) GetProductReviews(productID uint, page, pageSize int) ([]models.Review, int64, error) {
 	query := database.DB.Model(&models.Review{}).
@@ -71,7 +125,9 @@ func (s *ReviewService) GetProductReviews(productID uint, page, pageSize int) ([
 	
 	var reviews []models.Review
 	offset := (page - 1) * pageSize
-	if err := query.Preload("User").
+	if err := query.Preload("User").Preload("Variant").
+		Preload("Media", func(db *gorm.DB) *gorm.DB { return db.Order("sort ASC, id ASC") }).
+		Preload("Media.Media.Thumbnails").
 		Order("created_at DESC").
 		Offset(offset).Limit(pageSize).
 		Find(&reviews).Error; err != nil {
@@ -93,6 +149,8 @@ func (s *ReviewService) GetUserReviews(userID uint, page, pageSize int) ([]model
 	var reviews []models.Review
 	offset := (page - 1) * pageSize
 	if err := query.Preload("Product").
+		Preload("Media", func(db *gorm.DB) *gorm.DB { return db.Order("sort ASC, id ASC") }).
+		Preload("Media.Media.Thumbnails").
 		Order("created_at DESC").
 		Offset(offset).Limit(pageSize).
 		Find(&reviews).Error; err != nil {
@@ -104,13 +162,18 @@ func (s *ReviewService) GetUserReviews(userID uint, page, pageSize int) ([]model
 
 // DeleteReview 删除评价
 func (s *ReviewService) DeleteReview(reviewID, userID uint) error {
-	result := database.DB.Where("id = ? AND user_id = ?", reviewID, userID).Delete(&models.Review{})
+	var review models.Review
+	if err := database.DB.Where("id = ? AND user_id = ?", reviewID, userID).First(&review).Error; err != nil {
+		return errors.New("评价不存在")
+	}
+	result := database.DB.Delete(&review)
 	if result.Error != nil {
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
 		return errors.New("评价不存在")
 	}
+	invalidateProductCache(review.ProductID)
 	
 	logger.Info("删除评价成功", zap.Uint("review_id", reviewID))
 	return nil
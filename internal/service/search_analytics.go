@@ -0,0 +1,214 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 搜索后点击、下单归因到该次搜索的时间窗口
+const searchAttributionWindow = 7 * 24 * time.Hour
+
+// 搜索报表排序方式
+const (
+	SearchReportSortSearches   = "searches"
+	SearchReportSortClickRate  = "click_rate"
+	SearchReportSortConversion = "conversion"
+)
+
+// SearchClickRequest 搜索结果点击上报请求
+type SearchClickRequest struct {
+	ProductID uint `json:"product_id" binding:"required"`
+}
+
+// SearchReportRequest 搜索报表请求
+type SearchReportRequest struct {
+	Days        int    `form:"days" binding:"omitempty,gte=1,lte=90"`   // 统计最近天数，默认7天
+	Limit       int    `form:"limit" binding:"omitempty,gte=1,lte=200"` // 默认50
+	Sort        string `form:"sort" binding:"omitempty,oneof=searches click_rate conversion"`
+	MinSearches int    `form:"min_searches" binding:"omitempty,gte=1"` // 按比率排序时的最少搜索次数，默认5，避免偶发搜索词排在前面
+}
+
+// SearchQueryStat 单个搜索词的统计
+type SearchQueryStat struct {
+	Keyword        string    `json:"keyword"`
+	Searches       int64     `json:"searches"`
+	ZeroResults    int64     `json:"zero_results"` // 无结果的次数
+	AvgResults     float64   `json:"avg_results"`
+	Clicks         int64     `json:"clicks"`
+	Purchases      int64     `json:"purchases"`
+	ClickRate      float64   `json:"click_rate"`      // 点击次数 / 搜索次数
+	ConversionRate float64   `json:"conversion_rate"` // 下单次数 / 搜索次数
+	LastSearchedAt time.Time `json:"last_searched_at"`
+}
+
+// SearchSummary 搜索总体统计
+type SearchSummary struct {
+	Searches       int64   `json:"searches"`
+	ZeroResults    int64   `json:"zero_results"`
+	Clicks         int64   `json:"clicks"`
+	Purchases      int64   `json:"purchases"`
+	ZeroResultRate float64 `json:"zero_result_rate"`
+	ClickRate      float64 `json:"click_rate"`
+	ConversionRate float64 `json:"conversion_rate"`
+}
+
+// SearchReport 搜索报表
+type SearchReport struct {
+	Since   time.Time         `json:"since"`
+	Summary SearchSummary     `json:"summary"`
+	Queries []SearchQueryStat `json:"queries"`
+}
+
+// searchStatColumns 搜索统计的聚合列
+const searchStatColumns = "COUNT(*) AS searches, " +
+	"SUM(CASE WHEN result_count = 0 THEN 1 ELSE 0 END) AS zero_results, " +
+	"SUM(CASE WHEN clicked THEN 1 ELSE 0 END) AS clicks, " +
+	"SUM(CASE WHEN purchased THEN 1 ELSE 0 END) AS purchases"
+
+// LogSearch 记录一次搜索，返回搜索日志ID（用于后续上报点击）
+// 只记录首页请求，翻页不重复计数；记录失败不影响搜索本身
+func (s *SearchService) LogSearch(keyword, source string, resultCount int64, userID uint) uint {
+	keyword = normalizeSearchQuery(keyword)
+	if keyword == "" {
+		return 0
+	}
+	if runes := []rune(keyword); len(runes) > searchQueryMaxRunes {
+		keyword = string(runes[:searchQueryMaxRunes])
+	}
+
+	log := &models.SearchLog{Keyword: keyword, Source: source, ResultCount: resultCount}
+	if userID > 0 {
+		log.UserID = &userID
+	}
+	if err := database.DB.Create(log).Error; err != nil {
+		logger.Warn("记录搜索日志失败", zap.String("keyword", keyword), zap.Error(err))
+		return 0
+	}
+	return log.ID
+}
+
+// RecordSearchClick 记录搜索结果的点击；登录用户的点击会关联到用户，用于下单归因
+func (s *SearchService) RecordSearchClick(searchID, productID, userID uint) error {
+	var log models.SearchLog
+	if err := database.DB.First(&log, searchID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("搜索记录不存在")
+		}
+		return err
+	}
+	if time.Since(log.CreatedAt) > searchAttributionWindow {
+		return errors.New("搜索记录已过期")
+	}
+
+	updates := map[string]interface{}{
+		"clicked":            true,
+		"clicked_product_id": productID,
+	}
+	if log.UserID == nil && userID > 0 {
+		updates["user_id"] = userID
+	}
+	return database.DB.Model(&log).Updates(updates).Error
+}
+
+// attributeSearchPurchase 下单后把订单归因到用户近期点击过这些商品的搜索
+func attributeSearchPurchase(userID, orderID uint, productIDs []uint) {
+	if len(productIDs) == 0 {
+		return
+	}
+
+	err := database.DB.Model(&models.SearchLog{}).
+		Where("user_id = ? AND clicked_product_id IN ? AND purchased = ? AND created_at >= ?",
+			userID, productIDs, false, time.Now().Add(-searchAttributionWindow)).
+		Updates(map[string]interface{}{"purchased": true, "order_id": orderID}).Error
+	if err != nil {
+		logger.Warn("搜索下单归因失败", zap.Uint("order_id", orderID), zap.Error(err))
+	}
+}
+
+// GetQueryReport 搜索词报表：热门搜索词，或按点击率、转化率排序
+func (s *SearchService) GetQueryReport(req *SearchReportRequest) (*SearchReport, error) {
+	report, query, err := s.newSearchReport(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.Sort {
+	case SearchReportSortClickRate:
+		query = query.Having("COUNT(*) >= ?", req.MinSearches).
+			Order("SUM(CASE WHEN clicked THEN 1 ELSE 0 END) * 1.0 / COUNT(*) DESC")
+	case SearchReportSortConversion:
+		query = query.Having("COUNT(*) >= ?", req.MinSearches).
+			Order("SUM(CASE WHEN purchased THEN 1 ELSE 0 END) * 1.0 / COUNT(*) DESC")
+	}
+	query = query.Order("searches DESC, keyword ASC")
+
+	return s.fillSearchReport(report, query, req.Limit)
+}
+
+// GetZeroResultReport 无结果搜索词报表，按无结果次数排序
+func (s *SearchService) GetZeroResultReport(req *SearchReportRequest) (*SearchReport, error) {
+	report, query, err := s.newSearchReport(req)
+	if err != nil {
+		return nil, err
+	}
+
+	query = query.Having("SUM(CASE WHEN result_count = 0 THEN 1 ELSE 0 END) > 0").
+		Order("zero_results DESC, searches DESC, keyword ASC")
+
+	return s.fillSearchReport(report, query, req.Limit)
+}
+
+// newSearchReport 补全请求默认值，统计总体数据，并返回按搜索词分组的查询
+func (s *SearchService) newSearchReport(req *SearchReportRequest) (*SearchReport, *gorm.DB, error) {
+	if req.Days == 0 {
+		req.Days = 7
+	}
+	if req.Limit == 0 {
+		req.Limit = 50
+	}
+	if req.MinSearches == 0 {
+		req.MinSearches = 5
+	}
+
+	report := &SearchReport{
+		Since:   time.Now().AddDate(0, 0, -req.Days),
+		Queries: make([]SearchQueryStat, 0),
+	}
+	base := database.DB.Model(&models.SearchLog{}).Where("created_at >= ?", report.Since)
+
+	if err := base.Session(&gorm.Session{}).Select(searchStatColumns).Scan(&report.Summary).Error; err != nil {
+		return nil, nil, err
+	}
+	sum := &report.Summary
+	if sum.Searches > 0 {
+		sum.ZeroResultRate = float64(sum.ZeroResults) / float64(sum.Searches)
+		sum.ClickRate = float64(sum.Clicks) / float64(sum.Searches)
+		sum.ConversionRate = float64(sum.Purchases) / float64(sum.Searches)
+	}
+
+	query := base.Select("keyword, " + searchStatColumns +
+		", AVG(result_count) AS avg_results, MAX(created_at) AS last_searched_at").
+		Group("keyword")
+	return report, query, nil
+}
+
+// fillSearchReport 执行分组查询并计算各搜索词的比率
+func (s *SearchService) fillSearchReport(report *SearchReport, query *gorm.DB, limit int) (*SearchReport, error) {
+	if err := query.Limit(limit).Scan(&report.Queries).Error; err != nil {
+		return nil, err
+	}
+	for i := range report.Queries {
+		q := &report.Queries[i]
+		if q.Searches > 0 {
+			q.ClickRate = float64(q.Clicks) / float64(q.Searches)
+			q.ConversionRate = float64(q.Purchases) / float64(q.Searches)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSearchAnalytics 测试搜索日志、点击上报、下单归因及报表统计
+func TestSearchAnalytics(t *testing.T) {
+	setupTest()
+
+	searchService := NewSearchService()
+	keyword := fmt.Sprintf("analytics-%d", time.Now().UnixNano())
+	userID := uint(time.Now().UnixNano() % 100000)
+
+	hitID := searchService.LogSearch("  "+keyword+" ", models.SearchSourceSearch, 3, 0)
+	require.NotZero(t, hitID)
+	missID := searchService.LogSearch(keyword, models.SearchSourceList, 0, 0)
+	require.NotZero(t, missID)
+
+	// 匿名搜索、登录后点击，点击时关联用户
+	require.NoError(t, searchService.RecordSearchClick(hitID, 42, userID))
+	attributeSearchPurchase(userID, 1001, []uint{42})
+
+	var log models.SearchLog
+	require.NoError(t, database.DB.First(&log, hitID).Error)
+	assert.Equal(t, keyword, log.Keyword)
+	assert.True(t, log.Clicked)
+	assert.True(t, log.Purchased)
+
+	report, err := searchService.GetZeroResultReport(&SearchReportRequest{Days: 1, Limit: 200})
+	require.NoError(t, err)
+	var stat *SearchQueryStat
+	for i := range report.Queries {
+		if report.Queries[i].Keyword == keyword {
+			stat = &report.Queries[i]
+		}
+	}
+	require.NotNil(t, stat)
+	assert.Equal(t, int64(2), stat.Searches)
+	assert.Equal(t, int64(1), stat.ZeroResults)
+	assert.Equal(t, 0.5, stat.ConversionRate)
+
+	assert.Error(t, searchService.RecordSearchClick(0, 42, userID))
+}
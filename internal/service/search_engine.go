@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shoppee/ecommerce/internal/config"
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/internal/search"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 商品变更后同步搜索索引的超时时间
+const searchIndexTimeout = 10 * time.Second
+
+var (
+	searchEngineMu sync.RWMutex
+	searchEngine   search.Engine
+)
+
+// InitSearchEngine 按配置初始化搜索引擎
+func InitSearchEngine() error {
+	engine, err := newSearchEngine(config.AppConfig.Search)
+	if err != nil {
+		return err
+	}
+
+	SetSearchEngine(engine)
+	logger.Info("搜索引擎初始化成功", zap.String("engine", engine.Name()))
+	return nil
+}
+
+// newSearchEngine 创建配置指定的搜索引擎
+func newSearchEngine(cfg config.SearchConfig) (search.Engine, error) {
+	switch cfg.Engine {
+	case "", "postgres":
+		return search.NewPostgresEngine(database.DB, cfg.SalesWeight, cfg.FuzzyThreshold), nil
+	case "memory":
+		return search.NewMemoryEngine(cfg.SalesWeight), nil
+	case "meilisearch":
+		if cfg.EngineURL == "" {
+			return nil, fmt.Errorf("未配置搜索引擎地址 SEARCH_ENGINE_URL")
+		}
+		return search.NewMeilisearchEngine(cfg.EngineURL, cfg.EngineAPIKey, cfg.EngineIndex), nil
+	default:
+		return nil, fmt.Errorf("不支持的搜索引擎: %s", cfg.Engine)
+	}
+}
+
+// SetSearchEngine 替换当前搜索引擎（测试时可注入内存引擎）
+func SetSearchEngine(engine search.Engine) {
+	searchEngineMu.Lock()
+	defer searchEngineMu.Unlock()
+	searchEngine = engine
+}
+
+// getSearchEngine 获取当前搜索引擎，未初始化时使用 Postgres 引擎
+func getSearchEngine() search.Engine {
+	searchEngineMu.RLock()
+	engine := searchEngine
+	searchEngineMu.RUnlock()
+	if engine != nil {
+		return engine
+	}
+
+	cfg := config.AppConfig.Search
+	return search.NewPostgresEngine(database.DB, cfg.SalesWeight, cfg.FuzzyThreshold)
+}
+
+// searchDocument 由商品生成索引文档
+func searchDocument(p *models.Product) search.Document {
+	return search.Document{
+		ID:          p.ID,
+		Name:        p.Name,
+		SKU:         p.SKU,
+		Description: p.Description,
+		CategoryID:  p.CategoryID,
+		Price:       p.Price,
+		SaleCount:   p.SaleCount,
+	}
+}
+
+// searchable 商品是否应出现在搜索结果中
+func searchable(p *models.Product) bool {
+	return p.Status == "active" && !p.DeletedAt.Valid
+}
+
+// applySearchIndex 按商品当前状态更新索引：上架商品写入，其余移除
+func applySearchIndex(ctx context.Context, engine search.Engine, products []models.Product) (int, error) {
+	var docs []search.Document
+	var removed []uint
+	for i := range products {
+		if searchable(&products[i]) {
+			docs = append(docs, searchDocument(&products[i]))
+		} else {
+			removed = append(removed, products[i].ID)
+		}
+	}
+
+	if err := engine.Index(ctx, docs); err != nil {
+		return 0, err
+	}
+	if err := engine.Delete(ctx, removed); err != nil {
+		return 0, err
+	}
+	return len(docs), nil
+}
+
+// syncSearchIndex 按商品ID同步索引（含已删除商品，数据库中不存在的商品直接移除）
+func syncSearchIndex(ctx context.Context, ids []uint) error {
+	var products []models.Product
+	if err := database.DB.Unscoped().Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return err
+	}
+
+	engine := getSearchEngine()
+	if _, err := applySearchIndex(ctx, engine, products); err != nil {
+		return err
+	}
+
+	found := make(map[uint]bool, len(products))
+	for _, p := range products {
+		found[p.ID] = true
+	}
+	var missing []uint
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return engine.Delete(ctx, missing)
+}
+
+// indexProductsAsync 商品变更后异步同步搜索索引，失败只记录日志（可通过重建索引修复）
+func indexProductsAsync(ids ...uint) {
+	if len(ids) == 0 {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), searchIndexTimeout)
+		defer cancel()
+		if err := syncSearchIndex(ctx, ids); err != nil {
+			logger.Warn("同步搜索索引失败", zap.Uints("product_ids", ids), zap.Error(err))
+		}
+	}()
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// SearchService 搜索管理服务（同义词、索引重建）
+type SearchService struct{}
+
+// NewSearchService 创建搜索管理服务实例
+func NewSearchService() *SearchService {
+	return &SearchService{}
+}
+
+// SynonymRequest 创建同义词组请求
+type SynonymRequest struct {
+	Terms []string `json:"terms" binding:"required,min=2,dive,required,max=50"`
+}
+
+// GetSynonyms 获取同义词组列表
+func (s *SearchService) GetSynonyms() ([]models.SearchSynonym, error) {
+	var synonyms []models.SearchSynonym
+	if err := database.DB.Order("id ASC").Find(&synonyms).Error; err != nil {
+		return nil, err
+	}
+	return synonyms, nil
+}
+
+// CreateSynonym 创建同义词组
+func (s *SearchService) CreateSynonym(req *SynonymRequest) (*models.SearchSynonym, error) {
+	terms := splitSynonymTerms(strings.Join(req.Terms, ","))
+	if len(terms) < 2 {
+		return nil, errors.New("同义词组至少需要两个不同的词")
+	}
+
+	synonym := &models.SearchSynonym{Terms: strings.Join(terms, ",")}
+	if err := database.DB.Create(synonym).Error; err != nil {
+		return nil, err
+	}
+
+	searchSynonyms.invalidate()
+	logger.Info("创建搜索同义词", zap.String("terms", synonym.Terms))
+	return synonym, nil
+}
+
+// DeleteSynonym 删除同义词组
+func (s *SearchService) DeleteSynonym(id uint) error {
+	result := database.DB.Delete(&models.SearchSynonym{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("同义词组不存在")
+	}
+
+	searchSynonyms.invalidate()
+	return nil
+}
+
+// ReindexProducts 全量重建搜索索引：上架商品写入当前搜索引擎，下架及已删除的商品从索引移除
+// 用于切换搜索引擎、调整分词规则或索引与数据库不一致时
+func (s *SearchService) ReindexProducts() (int, error) {
+	ctx := context.Background()
+	engine := getSearchEngine()
+
+	var products []models.Product
+	count := 0
+	result := database.DB.Unscoped().
+		FindInBatches(&products, 200, func(tx *gorm.DB, batch int) error {
+			indexed, err := applySearchIndex(ctx, engine, products)
+			count += indexed
+			return err
+		})
+	if result.Error != nil {
+		logger.Error("重建搜索索引失败", zap.String("engine", engine.Name()), zap.Int("indexed", count), zap.Error(result.Error))
+		return count, result.Error
+	}
+
+	logger.Info("重建搜索索引完成", zap.String("engine", engine.Name()), zap.Int("count", count))
+	return count, nil
+}
@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/shoppee/ecommerce/internal/config"
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 搜索联想来源
+const (
+	SuggestTypeQuery    = "query"
+	SuggestTypeProduct  = "product"
+	SuggestTypeCategory = "category"
+)
+
+const (
+	searchQueryKeyPrefix = "search:queries:"       // 按天统计的搜索词频次（有序集合）
+	searchQueryRecentKey = "search:queries:recent" // 近期按天衰减加权后的搜索词频次
+	searchQueryDays      = 7                       // 参与统计的天数
+	searchQueryRecentTTL = 5 * time.Minute         // 近期频次汇总的缓存时间
+	searchQueryMaxRunes  = 50                      // 超过该长度的搜索词不计入统计
+	searchQueryScanLimit = 500                     // 前缀匹配热门搜索词时扫描的数量
+	suggestDefaultLimit  = 10
+	suggestMaxLimit      = 20
+	didYouMeanLimit      = 3
+)
+
+// SuggestItem 单条搜索联想
+type SuggestItem struct {
+	Text string `json:"text"`
+	Type string `json:"type"`         // query, product, category
+	ID   uint   `json:"id,omitempty"` // 商品或分类ID
+}
+
+// SuggestResult 搜索联想结果
+type SuggestResult struct {
+	Suggestions []SuggestItem `json:"suggestions"`
+	DidYouMean  []string      `json:"did_you_mean,omitempty"` // 无联想结果时的纠错建议
+}
+
+// suggestCandidate 待排序的联想候选
+type suggestCandidate struct {
+	SuggestItem
+	freq  float64
+	order int
+}
+
+// Suggest 按前缀联想商品名称、分类及热门搜索词，按近期搜索频次排序
+func (s *SearchService) Suggest(prefix string, limit int) (*SuggestResult, error) {
+	prefix = normalizeSearchQuery(prefix)
+	result := &SuggestResult{Suggestions: make([]SuggestItem, 0)}
+	if prefix == "" {
+		return result, nil
+	}
+	if limit < 1 || limit > suggestMaxLimit {
+		limit = suggestDefaultLimit
+	}
+
+	ctx := context.Background()
+	var candidates []suggestCandidate
+	seen := make(map[string]bool)
+	add := func(item SuggestItem, freq float64) {
+		key := strings.ToLower(item.Text)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		candidates = append(candidates, suggestCandidate{SuggestItem: item, freq: freq, order: len(candidates)})
+	}
+
+	// 热门搜索词
+	for _, z := range recentSearchQueries(ctx) {
+		if len(candidates) >= limit {
+			break
+		}
+		if strings.HasPrefix(z.Member, prefix) {
+			add(SuggestItem{Text: z.Member, Type: SuggestTypeQuery}, z.Score)
+		}
+	}
+
+	pattern := escapeLike(prefix) + "%"
+
+	// 商品名称（按销量）
+	var products []models.Product
+	if err := database.DB.Select("id", "name").
+		Where("status = ? AND lower(name) LIKE ?", "active", pattern).
+		Order("sale_count DESC, id DESC").
+		Limit(limit).
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	// 分类名称
+	var categories []models.Category
+	if err := database.DB.Select("id", "name").
+		Where("status = ? AND lower(name) LIKE ?", "active", pattern).
+		Order("sort ASC, id ASC").
+		Limit(limit).
+		Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(products)+len(categories))
+	for _, p := range products {
+		names = append(names, strings.ToLower(p.Name))
+	}
+	for _, c := range categories {
+		names = append(names, strings.ToLower(c.Name))
+	}
+	freqs := searchQueryFrequencies(ctx, names)
+
+	for _, c := range categories {
+		add(SuggestItem{Text: c.Name, Type: SuggestTypeCategory, ID: c.ID}, freqs[strings.ToLower(c.Name)])
+	}
+	for _, p := range products {
+		add(SuggestItem{Text: p.Name, Type: SuggestTypeProduct, ID: p.ID}, freqs[strings.ToLower(p.Name)])
+	}
+
+	// 按近期频次排序，频次相同时保持来源顺序（热门搜索词、分类、商品）
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].freq != candidates[j].freq {
+			return candidates[i].freq > candidates[j].freq
+		}
+		return candidates[i].order < candidates[j].order
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	for _, c := range candidates {
+		result.Suggestions = append(result.Suggestions, c.SuggestItem)
+	}
+
+	if len(result.Suggestions) == 0 {
+		result.DidYouMean = s.DidYouMean(prefix)
+	}
+	return result, nil
+}
+
+// DidYouMean 为无结果的搜索词给出纠错建议：
+// 优先取编辑距离相近的热门搜索词及分类名，其次取名称相似的商品（需 pg_trgm 扩展）
+func (s *SearchService) DidYouMean(keyword string) []string {
+	keyword = normalizeSearchQuery(keyword)
+	if keyword == "" {
+		return nil
+	}
+
+	type correction struct {
+		text string
+		dist int
+		freq float64
+	}
+	var corrections []correction
+	seen := map[string]bool{keyword: true}
+	maxDist := maxEditDistance(keyword)
+
+	consider := func(text string, freq float64) {
+		key := strings.ToLower(text)
+		if seen[key] {
+			return
+		}
+		if d := editDistance(keyword, key); d <= maxDist {
+			seen[key] = true
+			corrections = append(corrections, correction{text: text, dist: d, freq: freq})
+		}
+	}
+
+	for _, z := range recentSearchQueries(context.Background()) {
+		consider(z.Member, z.Score)
+	}
+
+	var categoryNames []string
+	if err := database.DB.Model(&models.Category{}).Where("status = ?", "active").
+		Pluck("name", &categoryNames).Error; err != nil {
+		logger.Warn("加载分类名称失败", zap.Error(err))
+	}
+	for _, name := range categoryNames {
+		consider(name, 0)
+	}
+
+	sort.SliceStable(corrections, func(i, j int) bool {
+		if corrections[i].dist != corrections[j].dist {
+			return corrections[i].dist < corrections[j].dist
+		}
+		return corrections[i].freq > corrections[j].freq
+	})
+
+	result := make([]string, 0, didYouMeanLimit)
+	for _, c := range corrections {
+		if len(result) == didYouMeanLimit {
+			return result
+		}
+		result = append(result, c.text)
+	}
+
+	for _, name := range similarProductNames(keyword, didYouMeanLimit) {
+		if len(result) == didYouMeanLimit {
+			break
+		}
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// similarProductNames 按三元组相似度查找名称相近的商品，pg_trgm 不可用时返回空
+func similarProductNames(keyword string, limit int) []string {
+	threshold := config.AppConfig.Search.FuzzyThreshold
+	if threshold <= 0 {
+		return nil
+	}
+
+	var names []string
+	if err := database.DB.Model(&models.Product{}).
+		Where("status = ? AND word_similarity(?, lower(name)) >= ?", "active", keyword, threshold).
+		Order(gorm.Expr("word_similarity(?, lower(name)) DESC, sale_count DESC", keyword)).
+		Limit(limit).
+		Pluck("name", &names).Error; err != nil {
+		logger.Warn("查找相似商品名称失败", zap.String("keyword", keyword), zap.Error(err))
+		return nil
+	}
+	return names
+}
+
+// recordSearchQuery 记录一次有结果的搜索，用于热门搜索词联想及纠错
+func recordSearchQuery(keyword string) {
+	keyword = normalizeSearchQuery(keyword)
+	if database.RedisClient == nil || keyword == "" || utf8.RuneCountInString(keyword) > searchQueryMaxRunes {
+		return
+	}
+
+	ctx := context.Background()
+	key := searchQueryKeyPrefix + time.Now().Format("20060102")
+	pipe := database.RedisClient.TxPipeline()
+	pipe.ZIncrBy(ctx, key, 1, keyword)
+	pipe.Expire(ctx, key, (searchQueryDays+1)*24*time.Hour)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Warn("记录搜索词失败", zap.String("keyword", keyword), zap.Error(err))
+	}
+}
+
+// recentSearchQueries 获取近期搜索词及按天衰减加权的频次（从高到低）
+func recentSearchQueries(ctx context.Context) []redis.Z {
+	if database.RedisClient == nil {
+		return nil
+	}
+
+	exists, err := database.RedisClient.Exists(ctx, searchQueryRecentKey).Result()
+	if err == nil && exists == 0 {
+		// 越早的搜索权重越低：今天为 1，之后每天递减
+		keys := make([]string, 0, searchQueryDays)
+		weights := make([]float64, 0, searchQueryDays)
+		now := time.Now()
+		for d := 0; d < searchQueryDays; d++ {
+			keys = append(keys, searchQueryKeyPrefix+now.AddDate(0, 0, -d).Format("20060102"))
+			weights = append(weights, float64(searchQueryDays-d)/searchQueryDays)
+		}
+		pipe := database.RedisClient.TxPipeline()
+		pipe.ZUnionStore(ctx, searchQueryRecentKey, &redis.ZStore{Keys: keys, Weights: weights})
+		pipe.Expire(ctx, searchQueryRecentKey, searchQueryRecentTTL)
+		_, err = pipe.Exec(ctx)
+	}
+	if err != nil {
+		logger.Warn("汇总近期搜索词失败", zap.Error(err))
+		return nil
+	}
+
+	queries, err := database.RedisClient.ZRevRangeWithScores(ctx, searchQueryRecentKey, 0, searchQueryScanLimit-1).Result()
+	if err != nil {
+		logger.Warn("读取近期搜索词失败", zap.Error(err))
+		return nil
+	}
+	return queries
+}
+
+// searchQueryFrequencies 获取指定搜索词的近期频次
+func searchQueryFrequencies(ctx context.Context, queries []string) map[string]float64 {
+	freqs := make(map[string]float64, len(queries))
+	if database.RedisClient == nil || len(queries) == 0 {
+		return freqs
+	}
+
+	scores, err := database.RedisClient.ZMScore(ctx, searchQueryRecentKey, queries...).Result()
+	if err != nil {
+		logger.Warn("读取搜索词频次失败", zap.Error(err))
+		return freqs
+	}
+	for i, q := range queries {
+		freqs[q] = scores[i]
+	}
+	return freqs
+}
+
+// normalizeSearchQuery 搜索词规范化：去首尾空白、合并连续空白、转小写
+func normalizeSearchQuery(q string) string {
+	return strings.ToLower(strings.Join(strings.Fields(q), " "))
+}
+
+// escapeLike 转义 LIKE 通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// maxEditDistance 纠错允许的最大编辑距离：短词只容许一处错误
+func maxEditDistance(s string) int {
+	if utf8.RuneCountInString(s) <= 4 {
+		return 1
+	}
+	return 2
+}
+
+// editDistance 计算两个字符串的编辑距离（按字符）
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEditDistance 测试纠错使用的编辑距离
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("iphone", "iphone"))
+	assert.Equal(t, 2, editDistance("iphnoe", "iphone"))
+	assert.Equal(t, 1, editDistance("蓝牙耳鸡", "蓝牙耳机"))
+	assert.Equal(t, 3, editDistance("", "手机壳"))
+
+	assert.Equal(t, 1, maxEditDistance("耳机"))
+	assert.Equal(t, 2, maxEditDistance("iphnoe"))
+}
+
+// TestNormalizeSearchQuery 测试搜索词规范化及 LIKE 转义
+func TestNormalizeSearchQuery(t *testing.T) {
+	assert.Equal(t, "apple iphone", normalizeSearchQuery("  Apple   iPhone "))
+	assert.Equal(t, `100\%\_off\\`, escapeLike(`100%_off\`))
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/slug"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// checkSlug 校验自定义 slug 的格式及唯一性
+func checkSlug(tx *gorm.DB, entityType string, id uint, s string) error {
+	if !slug.Valid(s) {
+		return &FieldError{Field: "slug", Message: "slug 只能包含小写字母、数字和单个连字符"}
+	}
+	taken, err := models.SlugTaken(tx, entityType, s, id)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return &FieldError{Field: "slug", Message: "slug 已被占用"}
+	}
+	return nil
+}
+
+// changeSlug 修改 slug 并将旧 slug 记入历史；requested 为空时由名称重新生成
+// 返回最终的 slug，与旧值相同时不做任何修改
+func changeSlug(tx *gorm.DB, entityType string, id uint, oldSlug, requested, name string) (string, error) {
+	newSlug := requested
+	if newSlug == "" {
+		var err error
+		if newSlug, err = models.UniqueSlug(tx, entityType, name, id); err != nil {
+			return "", err
+		}
+	} else if newSlug != oldSlug {
+		if err := checkSlug(tx, entityType, id, newSlug); err != nil {
+			return "", err
+		}
+	}
+	if newSlug == oldSlug {
+		return newSlug, nil
+	}
+
+	// 当前 slug 优先于历史记录：改回旧 slug 或占用其他记录的历史 slug 时删除对应历史
+	if err := tx.Where("entity_type = ? AND slug = ?", entityType, newSlug).
+		Delete(&models.SlugRedirect{}).Error; err != nil {
+		return "", err
+	}
+	if oldSlug != "" {
+		redirect := models.SlugRedirect{EntityType: entityType, Slug: oldSlug, EntityID: id}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "entity_type"}, {Name: "slug"}},
+			DoUpdates: clause.AssignmentColumns([]string{"entity_id"}),
+		}).Create(&redirect).Error; err != nil {
+			return "", err
+		}
+	}
+	return newSlug, nil
+}
+
+// redirectSlug 查找历史 slug 对应记录的当前 slug
+func redirectSlug(entityType, s string, model interface{}) (string, error) {
+	var redirect models.SlugRedirect
+	if err := database.DB.Where("entity_type = ? AND slug = ?", entityType, s).First(&redirect).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	var current string
+	if err := database.DB.Model(model).Where("id = ?", redirect.EntityID).
+		Pluck("slug", &current).Error; err != nil {
+		return "", err
+	}
+	return current, nil
+}
+
+// GetProductBySlug 根据 slug 获取商品详情；slug 为历史 slug 时返回商品当前的 slug 供调用方跳转
+// 当前 slug 只解析出商品ID，详情走商品缓存
+func (s *ProductService) GetProductBySlug(productSlug string) (*ProductDetail, string, error) {
+	var ids []uint
+	if err := database.DB.Model(&models.Product{}).Where("slug = ?", productSlug).Limit(1).Pluck("id", &ids).Error; err != nil {
+		return nil, "", err
+	}
+	if len(ids) > 0 {
+		detail, err := s.GetProductByID(ids[0])
+		return detail, "", err
+	}
+
+	current, err := redirectSlug(models.SlugEntityProduct, productSlug, &models.Product{})
+	if err != nil {
+		return nil, "", err
+	}
+	if current == "" {
+		return nil, "", ErrProductNotFound
+	}
+	return nil, current, nil
+}
+
+// GetCategoryBySlug 根据 slug 获取分类详情；slug 为历史 slug 时返回分类当前的 slug 供调用方跳转
+func (s *CategoryService) GetCategoryBySlug(categorySlug string) (*CategoryDetail, string, error) {
+	var category models.Category
+	err := database.DB.Select("id").Where("slug = ?", categorySlug).First(&category).Error
+	if err == nil {
+		detail, err := s.GetCategory(category.ID)
+		return detail, "", err
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", err
+	}
+
+	current, err := redirectSlug(models.SlugEntityCategory, categorySlug, &models.Category{})
+	if err != nil {
+		return nil, "", err
+	}
+	if current == "" {
+		return nil, "", ErrCategoryNotFound
+	}
+	return nil, current, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shoppee/ecommerce/internal/config"
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/internal/websocket"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// alertSuppressor 预警抑制器：同一商品在时间窗口内只预警一次
+// 优先使用Redis（多实例共享），Redis不可用时退化为进程内记录
+type alertSuppressor struct {
+	mu     sync.Mutex
+	expiry map[uint]time.Time
+}
+
+var lowStockSuppressor = &alertSuppressor{expiry: make(map[uint]time.Time)}
+
+// allow 判断本次预警是否放行，放行时同时开启抑制窗口
+func (a *alertSuppressor) allow(productID uint, window time.Duration) bool {
+	if window <= 0 {
+		return true
+	}
+
+	if database.RedisClient != nil {
+		key := fmt.Sprintf("stock_alert:%d", productID)
+		ok, err := database.RedisClient.SetNX(context.Background(), key, 1, window).Result()
+		if err == nil {
+			return ok
+		}
+		logger.Warn("预警抑制检查失败，使用本地抑制", zap.Error(err))
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	if until, ok := a.expiry[productID]; ok && now.Before(until) {
+		return false
+	}
+	a.expiry[productID] = now.Add(window)
+	return true
+}
+
+// alertLowStock 库存变更提交后检查补货阈值，低于阈值时通知所有管理员
+func alertLowStock(products ...models.Product) {
+	for _, p := range products {
+		if p.ReorderThreshold <= 0 || p.Stock >= p.ReorderThreshold {
+			continue
+		}
+		if !lowStockSuppressor.allow(p.ID, config.AppConfig.Inventory.LowStockAlertWindow) {
+			continue
+		}
+
+		go dispatchStockAlert(p)
+	}
+}
+
+// dispatchStockAlert 推送给在线管理员，并为所有管理员保存通知（离线管理员上线后补发）
+func dispatchStockAlert(p models.Product) {
+	delivered := websocket.NotifyStockAlert(p.ID, p.Name, p.Stock, p.ReorderThreshold)
+
+	content := websocket.StockAlertContent(p.ID, p.Name, p.Stock, p.ReorderThreshold)
+	title := fmt.Sprintf("商品「%s」库存不足", p.Name)
+	if err := NewNotificationService().StoreForRole("admin", "stock_alert", title, content, delivered); err != nil {
+		logger.Error("保存库存预警通知失败", zap.Uint("product_id", p.ID), zap.Error(err))
+		return
+	}
+
+	logger.Info("发送库存预警",
+		zap.Uint("product_id", p.ID),
+		zap.Int("stock", p.Stock),
+		zap.Int("reorder_threshold", p.ReorderThreshold),
+		zap.Int("online_admins", len(delivered)),
+	)
+}
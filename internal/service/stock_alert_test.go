@@ -0,0 +1,23 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAlertSuppressor 测试同一商品的预警在窗口期内被抑制
+func TestAlertSuppressor(t *testing.T) {
+	s := &alertSuppressor{expiry: make(map[uint]time.Time)}
+
+	assert.True(t, s.allow(1, 50*time.Millisecond), "首次预警应放行")
+	assert.False(t, s.allow(1, 50*time.Millisecond), "窗口期内重复预警应被抑制")
+	assert.True(t, s.allow(2, 50*time.Millisecond), "不同商品互不影响")
+
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, s.allow(1, 50*time.Millisecond), "窗口过后应再次放行")
+
+	assert.True(t, s.allow(3, 0))
+	assert.True(t, s.allow(3, 0), "窗口为0时不抑制")
+}
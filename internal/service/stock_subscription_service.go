@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/shoppee/ecommerce/internal/config"
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/internal/websocket"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockSubscriptionService 到货通知订阅服务
+type StockSubscriptionService struct{}
+
+// NewStockSubscriptionService 创建到货通知订阅服务实例
+func NewStockSubscriptionService() *StockSubscriptionService {
+	return &StockSubscriptionService{}
+}
+
+// Subscribe 订阅缺货商品或售罄规格的到货通知，variantID 为 0 时订阅整个商品（重复订阅会重新排队）
+func (s *StockSubscriptionService) Subscribe(userID, productID, variantID uint) (*models.StockSubscription, error) {
+	var product models.Product
+	if err := database.DB.First(&product, productID).Error; err != nil {
+		return nil, errors.New("商品不存在")
+	}
+	if err := checkSubscribable(&product, variantID); err != nil {
+		return nil, err
+	}
+
+	sub := &models.StockSubscription{
+		UserID:       userID,
+		ProductID:    productID,
+		VariantID:    variantID,
+		Status:       "pending",
+		SubscribedAt: time.Now(),
+	}
+	if err := database.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "product_id"}, {Name: "variant_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"status":        "pending",
+			"subscribed_at": gorm.Expr("CASE WHEN stock_subscriptions.status = 'pending' THEN stock_subscriptions.subscribed_at ELSE EXCLUDED.subscribed_at END"),
+			"notified_at":   nil,
+			"updated_at":    time.Now(),
+		}),
+	}).Create(sub).Error; err != nil {
+		return nil, err
+	}
+
+	logger.Info("订阅到货通知", zap.Uint("user_id", userID), zap.Uint("product_id", productID), zap.Uint("variant_id", variantID))
+	return sub, nil
+}
+
+// checkSubscribable 只能订阅缺货的商品或售罄的规格；商品整体有货时，其下售罄的规格仍可单独订阅
+func checkSubscribable(product *models.Product, variantID uint) error {
+	if variantID == 0 {
+		if product.Status != "out_of_stock" {
+			return errors.New("商品当前有货，无需订阅到货通知")
+		}
+		return nil
+	}
+
+	if !IsPublished(product.Status) {
+		return errors.New("商品不存在")
+	}
+	var variant models.ProductVariant
+	if err := database.DB.Where("id = ? AND product_id = ? AND status = ?", variantID, product.ID, "active").
+		First(&variant).Error; err != nil {
+		return ErrVariantNotFound
+	}
+	if variant.Stock > 0 {
+		return errors.New("该规格当前有货，无需订阅到货通知")
+	}
+	return nil
+}
+
+// Unsubscribe 取消到货通知订阅
+func (s *StockSubscriptionService) Unsubscribe(userID, productID, variantID uint) error {
+	result := database.DB.Model(&models.StockSubscription{}).
+		Where("user_id = ? AND product_id = ? AND variant_id = ? AND status = ?", userID, productID, variantID, "pending").
+		Update("status", "cancelled")
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("订阅不存在")
+	}
+	return nil
+}
+
+// GetUserSubscriptions 获取用户的到货通知订阅
+func (s *StockSubscriptionService) GetUserSubscriptions(userID uint) ([]models.StockSubscription, error) {
+	var subs []models.StockSubscription
+	if err := database.DB.Preload("Product").
+		Where("user_id = ? AND status = ?", userID, "pending").
+		Order("subscribed_at DESC").
+		Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// notifyBackInStock 商品恢复在售（variantID 为 0）或规格恢复有货后异步通知第一批订阅者
+func notifyBackInStock(productID, variantID uint) {
+	go func() {
+		if _, err := NewStockSubscriptionService().NotifyNextWave(productID, variantID); err != nil {
+			logger.Error("发送到货通知失败", zap.Uint("product_id", productID), zap.Uint("variant_id", variantID), zap.Error(err))
+		}
+	}()
+}
+
+// restockWaveSize 每批通知人数：按可售库存乘以系数，避免通知远多于库存的订阅者
+func restockWaveSize(stock int, ratio float64) int {
+	if ratio <= 0 {
+		ratio = 1
+	}
+	n := int(math.Ceil(float64(stock) * ratio))
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// NotifyNextWave 按订阅先后顺序通知商品（variantID 为 0）或规格的下一批订阅者，返回本批通知人数
+func (s *StockSubscriptionService) NotifyNextWave(productID, variantID uint) (int, error) {
+	var product models.Product
+	if err := database.DB.First(&product, productID).Error; err != nil {
+		return 0, err
+	}
+	if product.Status != "active" || product.Stock <= 0 {
+		return 0, nil
+	}
+
+	// 订阅规格时按规格库存确定每批人数
+	stock, name := product.Stock, product.Name
+	if variantID != 0 {
+		var variant models.ProductVariant
+		if err := database.DB.Where("id = ? AND product_id = ?", variantID, productID).First(&variant).Error; err != nil {
+			return 0, err
+		}
+		if variant.Status != "active" || variant.Stock <= 0 {
+			return 0, nil
+		}
+		stock, name = variant.Stock, fmt.Sprintf("%s（%s）", product.Name, variant.Name)
+	}
+
+	size := restockWaveSize(stock, config.AppConfig.Inventory.RestockNotifyRatio)
+	subs, err := claimSubscriptions(productID, variantID, size)
+	if err != nil || len(subs) == 0 {
+		return 0, err
+	}
+
+	userIDs := make([]uint, 0, len(subs))
+	var delivered []uint
+	for _, sub := range subs {
+		userIDs = append(userIDs, sub.UserID)
+		if websocket.NotifyBackInStock(sub.UserID, product.ID, name) {
+			delivered = append(delivered, sub.UserID)
+		}
+	}
+
+	title := fmt.Sprintf("您关注的「%s」已到货", name)
+	content := websocket.BackInStockContent(product.ID, name)
+	if err := NewNotificationService().StoreForUsers(userIDs, "back_in_stock", title, content, delivered); err != nil {
+		return len(subs), err
+	}
+
+	logger.Info("发送到货通知",
+		zap.Uint("product_id", productID),
+		zap.Uint("variant_id", variantID),
+		zap.Int("stock", stock),
+		zap.Int("notified", len(subs)),
+		zap.Int("online", len(delivered)),
+	)
+	return len(subs), nil
+}
+
+// claimSubscriptions 按订阅先后领取一批待通知的订阅并标记为已通知（SKIP LOCKED 保证多实例下同一订阅只通知一次）
+func claimSubscriptions(productID, variantID uint, size int) ([]models.StockSubscription, error) {
+	var subs []models.StockSubscription
+	err := database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("product_id = ? AND variant_id = ? AND status = ?", productID, variantID, "pending").
+			Order("subscribed_at ASC, id ASC").
+			Limit(size).
+			Find(&subs).Error; err != nil {
+			return err
+		}
+		if len(subs) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(subs))
+		for _, sub := range subs {
+			ids = append(ids, sub.ID)
+		}
+		return tx.Model(&models.StockSubscription{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"status": "notified", "notified_at": time.Now()}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// RunRestockNotifier 定期为仍有库存且有排队订阅的商品发送下一批到货通知
+func (s *StockSubscriptionService) RunRestockNotifier(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.notifyPendingWaves()
+		}
+	}
+}
+
+// restockWave 一个待通知的商品或规格（VariantID 为 0 表示订阅整个商品）
+type restockWave struct {
+	ProductID uint
+	VariantID uint
+}
+
+// notifyPendingWaves 为距离上一批通知已超过间隔的商品或规格发送下一批通知
+func (s *StockSubscriptionService) notifyPendingWaves() {
+	waves, err := dueRestockWaves(config.AppConfig.Inventory.RestockNotifyWait)
+	if err != nil {
+		logger.Error("查询待通知商品失败", zap.Error(err))
+		return
+	}
+
+	for _, w := range waves {
+		if _, err := s.NotifyNextWave(w.ProductID, w.VariantID); err != nil {
+			logger.Error("发送到货通知失败", zap.Uint("product_id", w.ProductID), zap.Uint("variant_id", w.VariantID), zap.Error(err))
+		}
+	}
+}
+
+// dueRestockWaves 查询有排队订阅、当前有货且距离上一批通知已超过 wait 的商品或规格
+func dueRestockWaves(wait time.Duration) ([]restockWave, error) {
+	var waves []restockWave
+	err := database.DB.Model(&models.StockSubscription{}).
+		Select("DISTINCT stock_subscriptions.product_id, stock_subscriptions.variant_id").
+		Joins("JOIN products ON products.id = stock_subscriptions.product_id AND products.deleted_at IS NULL").
+		Joins("LEFT JOIN product_variants ON product_variants.id = stock_subscriptions.variant_id").
+		Where("stock_subscriptions.status = ? AND products.status = ? AND products.stock > 0", "pending", "active").
+		Where("stock_subscriptions.variant_id = 0 OR (product_variants.status = ? AND product_variants.stock > 0)", "active").
+		Where("NOT EXISTS (SELECT 1 FROM stock_subscriptions n WHERE n.product_id = stock_subscriptions.product_id AND n.variant_id = stock_subscriptions.variant_id AND n.status = ? AND n.notified_at > ?)",
+			"notified", time.Now().Add(-wait)).
+		Scan(&waves).Error
+	return waves, err
+}
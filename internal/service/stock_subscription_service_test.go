@@ -0,0 +1,201 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRestockWaveSize 测试每批通知人数按库存和系数计算
+func TestRestockWaveSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		stock int
+		ratio float64
+		want  int
+	}{
+		{"库存与人数一比一", 5, 1, 5},
+		{"系数放大后向上取整", 3, 1.5, 5},
+		{"系数小于一", 10, 0.25, 3},
+		{"系数非法时按一比一", 4, 0, 4},
+		{"负系数按一比一", 4, -2, 4},
+		{"至少通知一人", 0, 1, 1},
+		{"极小库存", 1, 0.1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, restockWaveSize(tt.stock, tt.ratio))
+		})
+	}
+}
+
+// createSubscribedProduct 创建有库存的测试商品及一个售罄规格，并按 subscribedAt 顺序创建商品级订阅
+func createSubscribedProduct(t *testing.T, tag string, stock int, subscribedAt []time.Time) (models.Product, models.ProductVariant) {
+	t.Helper()
+
+	product := models.Product{
+		Name:   "到货通知商品" + tag,
+		Price:  59,
+		Stock:  stock,
+		SKU:    "RESTOCK" + tag,
+		Status: "active",
+	}
+	require.NoError(t, database.DB.Create(&product).Error)
+
+	variant := models.ProductVariant{
+		ProductID: product.ID,
+		OptionKey: "soldout",
+		SKU:       "RESTOCK" + tag + "-V",
+		Name:      "红色 / M",
+		Price:     59,
+		Stock:     0,
+		Status:    "active",
+	}
+	require.NoError(t, database.DB.Create(&variant).Error)
+
+	for i, at := range subscribedAt {
+		sub := models.StockSubscription{
+			UserID:       uint(i + 1),
+			ProductID:    product.ID,
+			Status:       "pending",
+			SubscribedAt: at,
+		}
+		require.NoError(t, database.DB.Create(&sub).Error)
+	}
+	return product, variant
+}
+
+// TestClaimSubscriptionsFIFO 按订阅先后分批领取，已领取的订阅不会再次通知，规格订阅单独排队
+func TestClaimSubscriptionsFIFO(t *testing.T) {
+	setupTest()
+
+	base := time.Now().Add(-time.Hour)
+	// 插入顺序与订阅顺序不同：用户3最早，其次用户1、用户4、用户2
+	product, variant := createSubscribedProduct(t, fmt.Sprintf("FIFO%d", time.Now().UnixNano()), 10, []time.Time{
+		base.Add(2 * time.Minute),
+		base.Add(4 * time.Minute),
+		base.Add(1 * time.Minute),
+		base.Add(3 * time.Minute),
+	})
+	variantSub := models.StockSubscription{
+		UserID:       99,
+		ProductID:    product.ID,
+		VariantID:    variant.ID,
+		Status:       "pending",
+		SubscribedAt: base,
+	}
+	require.NoError(t, database.DB.Create(&variantSub).Error)
+
+	userIDs := func(subs []models.StockSubscription) []uint {
+		ids := make([]uint, 0, len(subs))
+		for _, sub := range subs {
+			ids = append(ids, sub.UserID)
+		}
+		return ids
+	}
+
+	first, err := claimSubscriptions(product.ID, 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{3, 1}, userIDs(first))
+
+	second, err := claimSubscriptions(product.ID, 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{4, 2}, userIDs(second))
+
+	rest, err := claimSubscriptions(product.ID, 0, 2)
+	require.NoError(t, err)
+	assert.Empty(t, rest, "所有商品级订阅已通知")
+
+	var notified int64
+	database.DB.Model(&models.StockSubscription{}).
+		Where("product_id = ? AND status = ? AND notified_at IS NOT NULL", product.ID, "notified").
+		Count(&notified)
+	assert.Equal(t, int64(4), notified)
+
+	variantSubs, err := claimSubscriptions(product.ID, variant.ID, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{99}, userIDs(variantSubs))
+}
+
+// TestRestockWaveThrottle 上一批通知后须等待间隔才发送下一批
+func TestRestockWaveThrottle(t *testing.T) {
+	setupTest()
+
+	base := time.Now().Add(-time.Hour)
+	product, _ := createSubscribedProduct(t, fmt.Sprintf("WAVE%d", time.Now().UnixNano()), 1, []time.Time{
+		base,
+		base.Add(time.Minute),
+		base.Add(2 * time.Minute),
+	})
+
+	due := func(wait time.Duration) bool {
+		waves, err := dueRestockWaves(wait)
+		require.NoError(t, err)
+		for _, w := range waves {
+			if w.ProductID == product.ID && w.VariantID == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	assert.True(t, due(time.Hour), "尚未通知过，应立即发送第一批")
+
+	first, err := claimSubscriptions(product.ID, 0, restockWaveSize(product.Stock, 1))
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+
+	assert.False(t, due(time.Hour), "间隔内不发送下一批")
+
+	// 把上一批的通知时间推到间隔之前
+	require.NoError(t, database.DB.Model(&models.StockSubscription{}).
+		Where("id = ?", first[0].ID).
+		Update("notified_at", time.Now().Add(-2*time.Hour)).Error)
+	assert.True(t, due(time.Hour), "超过间隔后发送下一批")
+
+	// 商品售罄后不再发送
+	require.NoError(t, database.DB.Model(&product).
+		Updates(map[string]interface{}{"stock": 0, "status": "out_of_stock"}).Error)
+	assert.False(t, due(time.Hour))
+}
+
+// TestSubscribeSoldOutVariant 商品有货时仍可订阅其下售罄的规格，有货的商品或规格不能订阅
+func TestSubscribeSoldOutVariant(t *testing.T) {
+	setupTest()
+
+	subscriptionService := NewStockSubscriptionService()
+	product, soldOut := createSubscribedProduct(t, fmt.Sprintf("VAR%d", time.Now().UnixNano()), 5, nil)
+
+	inStock := models.ProductVariant{
+		ProductID: product.ID,
+		OptionKey: "instock",
+		SKU:       product.SKU + "-S",
+		Name:      "蓝色 / L",
+		Price:     59,
+		Stock:     5,
+		Status:    "active",
+	}
+	require.NoError(t, database.DB.Create(&inStock).Error)
+
+	_, err := subscriptionService.Subscribe(1, product.ID, 0)
+	assert.Error(t, err, "商品有货时不能订阅整个商品")
+
+	sub, err := subscriptionService.Subscribe(1, product.ID, soldOut.ID)
+	require.NoError(t, err)
+	assert.Equal(t, soldOut.ID, sub.VariantID)
+	assert.Equal(t, "pending", sub.Status)
+
+	_, err = subscriptionService.Subscribe(1, product.ID, inStock.ID)
+	assert.Error(t, err, "有货的规格不能订阅")
+
+	_, err = subscriptionService.Subscribe(1, product.ID, 999999999)
+	assert.ErrorIs(t, err, ErrVariantNotFound)
+
+	assert.NoError(t, subscriptionService.Unsubscribe(1, product.ID, soldOut.ID))
+}
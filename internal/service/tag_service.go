@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrTagNotFound 标签不存在
+	ErrTagNotFound = errors.New("标签不存在")
+	// ErrInvalidCode 编码格式错误
+	ErrInvalidCode = errors.New("编码只能包含小写字母、数字、- 和 _，且以字母或数字开头")
+)
+
+// codePattern 标签、合集编码（用作商品列表筛选参数）
+var codePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// TagService 商品标签服务
+type TagService struct{}
+
+// NewTagService 创建商品标签服务实例
+func NewTagService() *TagService {
+	return &TagService{}
+}
+
+// TagRequest 创建/更新标签请求
+type TagRequest struct {
+	Code  string `json:"code" binding:"required,max=50"`
+	Name  string `json:"name" binding:"required,max=50"`
+	Color string `json:"color" binding:"max=20"`
+}
+
+// ProductTagsRequest 设置商品标签请求
+type ProductTagsRequest struct {
+	TagIDs []uint `json:"tag_ids" binding:"max=20"`
+}
+
+// GetTags 获取全部标签
+func (s *TagService) GetTags() ([]models.Tag, error) {
+	var tags []models.Tag
+	if err := database.DB.Order("id ASC").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// CreateTag 创建标签
+func (s *TagService) CreateTag(req *TagRequest) (*models.Tag, error) {
+	if !codePattern.MatchString(req.Code) {
+		return nil, ErrInvalidCode
+	}
+	if err := checkTagUnique(0, req); err != nil {
+		return nil, err
+	}
+
+	tag := &models.Tag{Code: req.Code, Name: req.Name, Color: req.Color}
+	if err := database.DB.Create(tag).Error; err != nil {
+		return nil, err
+	}
+
+	logger.Info("创建标签", zap.Uint("tag_id", tag.ID), zap.String("code", tag.Code))
+	return tag, nil
+}
+
+// UpdateTag 更新标签
+func (s *TagService) UpdateTag(id uint, req *TagRequest) (*models.Tag, error) {
+	var tag models.Tag
+	if err := database.DB.First(&tag, id).Error; err != nil {
+		return nil, ErrTagNotFound
+	}
+	if !codePattern.MatchString(req.Code) {
+		return nil, ErrInvalidCode
+	}
+	if err := checkTagUnique(id, req); err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Model(&tag).Updates(map[string]interface{}{
+		"code":  req.Code,
+		"name":  req.Name,
+		"color": req.Color,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	// 商品详情缓存中含标签
+	var productIDs []uint
+	if err := database.DB.Table("product_tags").Where("tag_id = ?", id).Pluck("product_id", &productIDs).Error; err != nil {
+		logger.Warn("查询标签商品失败，商品缓存未清除", zap.Uint("tag_id", id), zap.Error(err))
+	}
+	invalidateProductCache(productIDs...)
+	return &tag, nil
+}
+
+// DeleteTag 删除标签及其商品关联（被规则合集引用时不能删除）
+func (s *TagService) DeleteTag(id uint) error {
+	var productIDs []uint
+	err := database.Transaction(func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.First(&tag, id).Error; err != nil {
+			return ErrTagNotFound
+		}
+		var used int64
+		if err := tx.Model(&models.Collection{}).Where("rule_tag_id = ?", id).Count(&used).Error; err != nil {
+			return err
+		}
+		if used > 0 {
+			return errors.New("标签被规则合集引用，不能删除")
+		}
+		if err := tx.Table("product_tags").Where("tag_id = ?", id).Pluck("product_id", &productIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM product_tags WHERE tag_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	invalidateProductCache(productIDs...)
+	logger.Info("删除标签", zap.Uint("tag_id", id))
+	return nil
+}
+
+// SetProductTags 替换商品的标签
+func (s *TagService) SetProductTags(productID uint, req *ProductTagsRequest) ([]models.Tag, error) {
+	var product models.Product
+	if err := database.DB.First(&product, productID).Error; err != nil {
+		return nil, ErrProductNotFound
+	}
+
+	tags := make([]models.Tag, 0, len(req.TagIDs))
+	if len(req.TagIDs) > 0 {
+		if err := database.DB.Where("id IN ?", req.TagIDs).Order("id ASC").Find(&tags).Error; err != nil {
+			return nil, err
+		}
+		if len(tags) != len(uniqueIDs(req.TagIDs)) {
+			return nil, ErrTagNotFound
+		}
+	}
+
+	if err := database.DB.Model(&product).Association("Tags").Replace(tags); err != nil {
+		return nil, err
+	}
+
+	invalidateProductCache(productID)
+	return tags, nil
+}
+
+// checkTagUnique 校验标签编码、名称唯一
+func checkTagUnique(id uint, req *TagRequest) error {
+	var count int64
+	if err := database.DB.Model(&models.Tag{}).
+		Where("(code = ? OR name = ?) AND id <> ?", req.Code, req.Name, id).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("标签编码或名称已存在")
+	}
+	return nil
+}
+
+// uniqueIDs 去重并保持原顺序
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,438 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrVariantRequired 多规格商品未指定规格
+	ErrVariantRequired = errors.New("请选择商品规格")
+	// ErrVariantNotFound 规格不存在或不属于该商品
+	ErrVariantNotFound = errors.New("商品规格不存在")
+)
+
+// VariantService 商品规格服务
+type VariantService struct{}
+
+// NewVariantService 创建商品规格服务实例
+func NewVariantService() *VariantService {
+	return &VariantService{}
+}
+
+// ProductOptionRequest 新增规格类型请求（同名规格类型已存在时追加规格值）
+type ProductOptionRequest struct {
+	Name   string   `json:"name" binding:"required,max=50"`
+	Values []string `json:"values" binding:"required,min=1,dive,required,max=50"`
+}
+
+// CreateVariantRequest 创建规格组合请求
+type CreateVariantRequest struct {
+	OptionValueIDs []uint  `json:"option_value_ids" binding:"required,min=1"` // 每个规格类型各选一个值
+	SKU            string  `json:"sku" binding:"required,max=100"`
+	Price          float64 `json:"price" binding:"required,gt=0"`
+	Stock          int     `json:"stock" binding:"gte=0"`
+	Image          string  `json:"image" binding:"max=255"`
+}
+
+// UpdateVariantRequest 更新规格组合请求（库存通过库存接口调整）
+type UpdateVariantRequest struct {
+	Price  *float64 `json:"price" binding:"omitempty,gt=0"`
+	Image  *string  `json:"image" binding:"omitempty,max=255"`
+	Status string   `json:"status" binding:"omitempty,oneof=active inactive"`
+}
+
+// GetOptions 获取商品的规格类型及规格值
+func (s *VariantService) GetOptions(productID uint) ([]models.ProductOption, error) {
+	return loadProductOptions(database.DB, productID)
+}
+
+// CreateOption 新增规格类型，或为已有规格类型追加规格值
+// 已有规格组合的商品不能再新增规格类型，否则已有组合将缺少该规格
+func (s *VariantService) CreateOption(productID uint, req *ProductOptionRequest) (*models.ProductOption, error) {
+	var option models.ProductOption
+	err := database.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+			return errors.New("商品不存在")
+		}
+
+		err := tx.Preload("Values").Where("product_id = ? AND name = ?", productID, req.Name).First(&option).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			var variantCount, optionCount int64
+			if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", productID).Count(&variantCount).Error; err != nil {
+				return err
+			}
+			if variantCount > 0 {
+				return errors.New("商品已有规格组合，不能新增规格类型")
+			}
+			if err := tx.Model(&models.ProductOption{}).Where("product_id = ?", productID).Count(&optionCount).Error; err != nil {
+				return err
+			}
+
+			option = models.ProductOption{ProductID: productID, Name: req.Name, Sort: int(optionCount)}
+			if err := tx.Create(&option).Error; err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+
+		existing := make(map[string]bool, len(option.Values))
+		for _, v := range option.Values {
+			existing[v.Value] = true
+		}
+		for _, value := range req.Values {
+			value = strings.TrimSpace(value)
+			if value == "" || existing[value] {
+				continue
+			}
+			existing[value] = true
+
+			v := models.ProductOptionValue{OptionID: option.ID, Value: value, Sort: len(option.Values)}
+			if err := tx.Create(&v).Error; err != nil {
+				return err
+			}
+			option.Values = append(option.Values, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateProductCache(productID)
+	logger.Info("保存商品规格类型", zap.Uint("product_id", productID), zap.String("name", option.Name), zap.Int("values", len(option.Values)))
+	return &option, nil
+}
+
+// GetVariants 获取商品的规格组合，includeInactive 为 false 时只返回启用的规格
+func (s *VariantService) GetVariants(productID uint, includeInactive bool) ([]models.ProductVariant, error) {
+	query := database.DB.Preload("OptionValues").Where("product_id = ?", productID)
+	if !includeInactive {
+		query = query.Where("status = ?", "active")
+	}
+
+	var variants []models.ProductVariant
+	if err := query.
+		Order("id ASC").
+		Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+// CreateVariant 创建规格组合，初始库存计入商品总库存并记录流水
+func (s *VariantService) CreateVariant(operatorID, productID uint, req *CreateVariantRequest) (*models.ProductVariant, error) {
+	var variant *models.ProductVariant
+	var change *stockChangeResult
+	err := database.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+			return errors.New("商品不存在")
+		}
+
+		var variantCount, warehouseCount int64
+		if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", productID).Count(&variantCount).Error; err != nil {
+			return err
+		}
+		// 商品总库存为各规格库存之和，首个规格创建前不能有未归属规格的库存
+		if variantCount == 0 && product.Stock > 0 {
+			return errors.New("商品已有库存，请先将库存调整为0再创建规格组合")
+		}
+		if err := tx.Model(&models.WarehouseStock{}).Where("product_id = ?", productID).Count(&warehouseCount).Error; err != nil {
+			return err
+		}
+		if warehouseCount > 0 {
+			return errors.New("商品已配置分仓库存，暂不支持多规格")
+		}
+
+		options, err := loadProductOptions(tx, productID)
+		if err != nil {
+			return err
+		}
+		values, err := resolveOptionValues(options, req.OptionValueIDs)
+		if err != nil {
+			return err
+		}
+
+		variant = &models.ProductVariant{
+			ProductID:    productID,
+			OptionKey:    variantOptionKey(values),
+			SKU:          req.SKU,
+			Name:         variantName(values),
+			Price:        req.Price,
+			Stock:        req.Stock,
+			Image:        req.Image,
+			Status:       "active",
+			OptionValues: values,
+		}
+		var exists int64
+		if err := tx.Model(&models.ProductVariant{}).
+			Where("product_id = ? AND option_key = ?", productID, variant.OptionKey).
+			Count(&exists).Error; err != nil {
+			return err
+		}
+		if exists > 0 {
+			return errors.New("该规格组合已存在")
+		}
+		if err := tx.Create(variant).Error; err != nil {
+			return err
+		}
+
+		if req.Stock == 0 {
+			return nil
+		}
+		change = &stockChangeResult{Before: product.Stock}
+		wasOutOfStock := product.Status == "out_of_stock"
+		if err := syncProductStock(tx, &product, product.Stock+req.Stock); err != nil {
+			return err
+		}
+		change.Restocked = wasOutOfStock && product.Status == "active"
+		change.Product = product
+		return recordStockMovement(tx, productID, change.Before, product.Stock, stockMovementMeta{
+			Reason:    models.StockReasonRestock,
+			RefID:     "variant_create",
+			ActorID:   operatorID,
+			VariantID: variant.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if change != nil {
+		afterStockChange(change)
+	} else {
+		invalidateProductCache(productID)
+	}
+	logger.Info("创建商品规格",
+		zap.Uint("product_id", productID),
+		zap.Uint("variant_id", variant.ID),
+		zap.String("sku", variant.SKU),
+	)
+	return variant, nil
+}
+
+// UpdateVariant 更新规格组合的价格、图片或状态
+// 停用或启用规格时在同一事务内按启用规格重新汇总商品总库存及缺货状态
+func (s *VariantService) UpdateVariant(productID, variantID uint, req *UpdateVariantRequest) (*models.ProductVariant, error) {
+	updates := make(map[string]interface{})
+	if req.Price != nil {
+		updates["price"] = *req.Price
+	}
+	if req.Image != nil {
+		updates["image"] = *req.Image
+	}
+	if req.Status != "" {
+		updates["status"] = req.Status
+	}
+	if len(updates) == 0 {
+		return findVariant(database.DB, productID, variantID)
+	}
+
+	var variant *models.ProductVariant
+	var change *stockChangeResult
+	err := database.Transaction(func(tx *gorm.DB) error {
+		// 先锁商品再改规格，与库存变更的加锁顺序一致
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrVariantNotFound
+			}
+			return err
+		}
+		var err error
+		if variant, err = findVariant(tx, productID, variantID); err != nil {
+			return err
+		}
+		statusChanged := req.Status != "" && req.Status != variant.Status
+		if err := tx.Model(variant).Updates(updates).Error; err != nil {
+			return err
+		}
+		if !statusChanged {
+			return nil
+		}
+
+		total, err := variantStockTotal(tx, productID)
+		if err != nil {
+			return err
+		}
+		change = &stockChangeResult{Before: product.Stock}
+		wasOutOfStock := product.Status == "out_of_stock"
+		if err := syncProductStock(tx, &product, total); err != nil {
+			return err
+		}
+		change.Restocked = wasOutOfStock && product.Status == "active"
+		change.Product = product
+		return recordStockMovement(tx, productID, change.Before, product.Stock, stockMovementMeta{
+			Reason:    models.StockReasonAdjustment,
+			RefID:     "variant_" + req.Status,
+			VariantID: variant.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if change != nil {
+		afterStockChange(change)
+	} else {
+		invalidateProductCache(productID)
+	}
+	return variant, nil
+}
+
+// loadProductOptions 按排序加载商品的规格类型及规格值
+func loadProductOptions(db *gorm.DB, productID uint) ([]models.ProductOption, error) {
+	var options []models.ProductOption
+	if err := db.Preload("Values", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort ASC, id ASC")
+	}).Where("product_id = ?", productID).
+		Order("sort ASC, id ASC").
+		Find(&options).Error; err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// resolveOptionValues 校验所选规格值：每个规格类型恰好选择一个值，结果按规格类型顺序排列
+func resolveOptionValues(options []models.ProductOption, valueIDs []uint) ([]models.ProductOptionValue, error) {
+	if len(options) == 0 {
+		return nil, errors.New("请先为商品添加规格类型")
+	}
+
+	selected := make(map[uint]bool, len(valueIDs))
+	for _, id := range valueIDs {
+		selected[id] = true
+	}
+
+	values := make([]models.ProductOptionValue, 0, len(options))
+	for _, option := range options {
+		var picked []models.ProductOptionValue
+		for _, v := range option.Values {
+			if selected[v.ID] {
+				picked = append(picked, v)
+			}
+		}
+		if len(picked) != 1 {
+			return nil, errors.New("规格「" + option.Name + "」须且只能选择一个值")
+		}
+		values = append(values, picked[0])
+	}
+
+	if len(values) != len(selected) {
+		return nil, errors.New("包含不属于该商品的规格值")
+	}
+	return values, nil
+}
+
+// variantOptionKey 规格值ID升序拼接，作为组合的唯一标识
+func variantOptionKey(values []models.ProductOptionValue) string {
+	ids := make([]int, 0, len(values))
+	for _, v := range values {
+		ids = append(ids, int(v.ID))
+	}
+	sort.Ints(ids)
+
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.Itoa(id))
+	}
+	return strings.Join(parts, "-")
+}
+
+// variantName 按规格类型顺序拼接规格值作为组合名称
+func variantName(values []models.ProductOptionValue) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		parts = append(parts, v.Value)
+	}
+	return strings.Join(parts, " / ")
+}
+
+// findVariant 查询属于指定商品的规格
+func findVariant(db *gorm.DB, productID, variantID uint) (*models.ProductVariant, error) {
+	var variant models.ProductVariant
+	if err := db.Where("id = ? AND product_id = ?", variantID, productID).First(&variant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVariantNotFound
+		}
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// productsWithVariants 返回给定商品中有规格组合的商品
+func productsWithVariants(db *gorm.DB, productIDs []uint) (map[uint]bool, error) {
+	var ids []uint
+	if err := db.Model(&models.ProductVariant{}).
+		Where("product_id IN ?", productIDs).
+		Distinct("product_id").
+		Pluck("product_id", &ids).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		result[id] = true
+	}
+	return result, nil
+}
+
+// variantStockTotal 商品各启用规格的库存之和，停用规格的库存不计入商品总库存
+func variantStockTotal(tx *gorm.DB, productID uint) (int, error) {
+	var total int
+	err := tx.Model(&models.ProductVariant{}).Where("product_id = ? AND status = ?", productID, "active").
+		Select("COALESCE(SUM(stock), 0)").Scan(&total).Error
+	return total, err
+}
+
+// changeVariantStock 变更规格库存（调用方须已锁定商品行），返回规格是否由售罄恢复有货
+// variantID 为 0 时要求商品没有规格组合
+func changeVariantStock(tx *gorm.DB, productID, variantID uint, quantity int) (bool, error) {
+	if variantID == 0 {
+		has, err := productsWithVariants(tx, []uint{productID})
+		if err != nil {
+			return false, err
+		}
+		if has[productID] {
+			return false, ErrVariantRequired
+		}
+		return false, nil
+	}
+
+	var variant models.ProductVariant
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND product_id = ?", variantID, productID).
+		First(&variant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ErrVariantNotFound
+		}
+		return false, err
+	}
+	if variant.Stock+quantity < 0 {
+		return false, ErrInsufficientStock
+	}
+	if err := tx.Model(&variant).UpdateColumn("stock", variant.Stock+quantity).Error; err != nil {
+		return false, err
+	}
+	return variant.Stock == 0 && quantity > 0, nil
+}
+
+// variantIDOf 可空规格ID转换为流水中的规格ID（0 表示无规格）
+func variantIDOf(id *uint) uint {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
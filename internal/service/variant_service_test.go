@@ -0,0 +1,81 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResolveOptionValues 测试规格组合须为每个规格类型恰好选择一个值
+func TestResolveOptionValues(t *testing.T) {
+	options := []models.ProductOption{
+		{ID: 1, Name: "颜色", Values: []models.ProductOptionValue{{ID: 11, Value: "红色"}, {ID: 12, Value: "蓝色"}}},
+		{ID: 2, Name: "尺码", Values: []models.ProductOptionValue{{ID: 21, Value: "M"}, {ID: 22, Value: "L"}}},
+	}
+
+	values, err := resolveOptionValues(options, []uint{22, 11})
+	assert.NoError(t, err)
+	assert.Equal(t, "红色 / L", variantName(values), "名称按规格类型顺序拼接")
+	assert.Equal(t, "11-22", variantOptionKey(values))
+
+	_, err = resolveOptionValues(options, []uint{11})
+	assert.Error(t, err, "缺少尺码")
+
+	_, err = resolveOptionValues(options, []uint{11, 12, 21})
+	assert.Error(t, err, "同一规格类型选择了两个值")
+
+	_, err = resolveOptionValues(options, []uint{11, 21, 99})
+	assert.Error(t, err, "包含其他商品的规格值")
+
+	_, err = resolveOptionValues(nil, []uint{11})
+	assert.Error(t, err, "商品没有规格类型")
+}
+
+// TestUpdateVariantStatusSyncsStock 停用规格后其库存不计入商品总库存，全部停用时商品缺货，重新启用后恢复
+func TestUpdateVariantStatusSyncsStock(t *testing.T) {
+	setupTest()
+
+	variantService := NewVariantService()
+	tag := fmt.Sprintf("VST%d", time.Now().UnixNano())
+	product := models.Product{Name: "规格状态商品" + tag, Price: 99, Stock: 8, SKU: tag, Status: "active"}
+	require.NoError(t, database.DB.Create(&product).Error)
+	red := models.ProductVariant{ProductID: product.ID, OptionKey: "red", SKU: tag + "-R", Name: "红色", Price: 99, Stock: 5, Status: "active"}
+	blue := models.ProductVariant{ProductID: product.ID, OptionKey: "blue", SKU: tag + "-B", Name: "蓝色", Price: 99, Stock: 3, Status: "active"}
+	require.NoError(t, database.DB.Create(&red).Error)
+	require.NoError(t, database.DB.Create(&blue).Error)
+
+	reload := func() models.Product {
+		var p models.Product
+		require.NoError(t, database.DB.First(&p, product.ID).Error)
+		return p
+	}
+
+	_, err := variantService.UpdateVariant(product.ID, red.ID, &UpdateVariantRequest{Status: "inactive"})
+	require.NoError(t, err)
+	p := reload()
+	assert.Equal(t, 3, p.Stock, "停用规格的库存不计入")
+	assert.Equal(t, "active", p.Status)
+
+	_, err = variantService.UpdateVariant(product.ID, blue.ID, &UpdateVariantRequest{Status: "inactive"})
+	require.NoError(t, err)
+	p = reload()
+	assert.Equal(t, 0, p.Stock)
+	assert.Equal(t, "out_of_stock", p.Status, "全部规格停用后缺货")
+
+	_, err = variantService.UpdateVariant(product.ID, red.ID, &UpdateVariantRequest{Status: "active"})
+	require.NoError(t, err)
+	p = reload()
+	assert.Equal(t, 5, p.Stock)
+	assert.Equal(t, "active", p.Status, "重新启用后恢复在售")
+
+	price := 89.0
+	updated, err := variantService.UpdateVariant(product.ID, red.ID, &UpdateVariantRequest{Price: &price})
+	require.NoError(t, err)
+	assert.Equal(t, price, updated.Price)
+	assert.Equal(t, 5, reload().Stock, "只改价格不影响库存")
+}
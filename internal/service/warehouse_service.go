@@ -0,0 +1,769 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shoppee/ecommerce/internal/config"
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 多仓库存分配策略
+const (
+	AllocationNearest   = "nearest"    // 优先从离收货省份最近的仓库发货
+	AllocationMostStock = "most_stock" // 优先从库存最多的仓库发货
+)
+
+// ErrWarehouseRequired 商品已按仓库管理库存，调整库存时须指定仓库
+var ErrWarehouseRequired = errors.New("该商品按仓库管理库存，请指定仓库")
+
+// WarehouseService 仓库服务
+type WarehouseService struct{}
+
+// NewWarehouseService 创建仓库服务实例
+func NewWarehouseService() *WarehouseService {
+	return &WarehouseService{}
+}
+
+// WarehouseRequest 创建/更新仓库请求
+type WarehouseRequest struct {
+	Code     string `json:"code" binding:"required,max=50"`
+	Name     string `json:"name" binding:"required,max=100"`
+	Province string `json:"province" binding:"required"`
+	City     string `json:"city"`
+	Address  string `json:"address"`
+	Priority int    `json:"priority"`
+	Status   string `json:"status" binding:"omitempty,oneof=active inactive"`
+}
+
+// StockTransferRequest 仓库间调拨请求
+type StockTransferRequest struct {
+	FromWarehouseID uint   `json:"from_warehouse_id" binding:"required"`
+	ToWarehouseID   uint   `json:"to_warehouse_id" binding:"required,nefield=FromWarehouseID"`
+	ProductID       uint   `json:"product_id" binding:"required"`
+	Quantity        int    `json:"quantity" binding:"required,gt=0"`
+	Remark          string `json:"remark"`
+}
+
+// GetWarehouses 获取仓库列表
+func (s *WarehouseService) GetWarehouses() ([]models.Warehouse, error) {
+	var warehouses []models.Warehouse
+	if err := database.DB.Order("priority DESC, id ASC").Find(&warehouses).Error; err != nil {
+		return nil, err
+	}
+	return warehouses, nil
+}
+
+// CreateWarehouse 创建仓库
+func (s *WarehouseService) CreateWarehouse(req *WarehouseRequest) (*models.Warehouse, error) {
+	status := req.Status
+	if status == "" {
+		status = "active"
+	}
+
+	warehouse := &models.Warehouse{
+		Code:     req.Code,
+		Name:     req.Name,
+		Province: req.Province,
+		City:     req.City,
+		Address:  req.Address,
+		Priority: req.Priority,
+		Status:   status,
+	}
+	if err := database.DB.Create(warehouse).Error; err != nil {
+		return nil, err
+	}
+
+	logger.Info("创建仓库成功", zap.Uint("warehouse_id", warehouse.ID), zap.String("code", warehouse.Code))
+	return warehouse, nil
+}
+
+// UpdateWarehouse 更新仓库，启用或停用仓库时重新汇总该仓库内商品的总库存
+func (s *WarehouseService) UpdateWarehouse(id uint, req *WarehouseRequest) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	if err := database.DB.First(&warehouse, id).Error; err != nil {
+		return nil, errors.New("仓库不存在")
+	}
+
+	updates := map[string]interface{}{
+		"code":     req.Code,
+		"name":     req.Name,
+		"province": req.Province,
+		"city":     req.City,
+		"address":  req.Address,
+		"priority": req.Priority,
+	}
+	statusChanged := req.Status != "" && req.Status != warehouse.Status
+	if req.Status != "" {
+		updates["status"] = req.Status
+	}
+
+	var changes []*stockChangeResult
+	err := database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&warehouse).Updates(updates).Error; err != nil {
+			return err
+		}
+		if !statusChanged {
+			return nil
+		}
+		var err error
+		changes, err = resyncWarehouseProducts(tx, warehouse.ID, stockMovementMeta{
+			Reason:      models.StockReasonAdjustment,
+			RefID:       req.Code,
+			WarehouseID: warehouse.ID,
+			Remark:      "仓库状态变更为 " + req.Status,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range changes {
+		afterStockChange(r)
+	}
+	if statusChanged {
+		logger.Info("仓库状态变更",
+			zap.Uint("warehouse_id", warehouse.ID),
+			zap.String("status", req.Status),
+			zap.Int("products", len(changes)),
+		)
+	}
+	return &warehouse, nil
+}
+
+// GetWarehouseStocks 获取仓库内各商品库存
+func (s *WarehouseService) GetWarehouseStocks(warehouseID uint) ([]models.WarehouseStock, error) {
+	var stocks []models.WarehouseStock
+	if err := database.DB.Preload("Product").
+		Where("warehouse_id = ?", warehouseID).
+		Order("product_id ASC").
+		Find(&stocks).Error; err != nil {
+		return nil, err
+	}
+	return stocks, nil
+}
+
+// GetProductStocks 获取商品在各仓库的库存分布
+func (s *WarehouseService) GetProductStocks(productID uint) ([]models.WarehouseStock, error) {
+	var stocks []models.WarehouseStock
+	if err := database.DB.Preload("Warehouse").
+		Where("product_id = ?", productID).
+		Order("warehouse_id ASC").
+		Find(&stocks).Error; err != nil {
+		return nil, err
+	}
+	return stocks, nil
+}
+
+// SetWarehouseStock 设置商品在某仓库的库存，并将商品总库存同步为各启用仓库库存之和
+func (s *WarehouseService) SetWarehouseStock(operatorID, warehouseID, productID uint, stock int) (*models.WarehouseStock, error) {
+	if stock < 0 {
+		return nil, errors.New("库存不能为负数")
+	}
+
+	var ws models.WarehouseStock
+	var result *stockChangeResult
+	err := database.Transaction(func(tx *gorm.DB) error {
+		var warehouse models.Warehouse
+		if err := tx.First(&warehouse, warehouseID).Error; err != nil {
+			return errors.New("仓库不存在")
+		}
+
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+			return errors.New("商品不存在")
+		}
+		// 仓库库存按商品维度管理，多规格商品的库存须按规格调整
+		hasVariants, err := productsWithVariants(tx, []uint{productID})
+		if err != nil {
+			return err
+		}
+		if hasVariants[productID] {
+			return errors.New("多规格商品暂不支持分仓库存，请按规格调整库存")
+		}
+
+		if err := ensureWarehouseManaged(tx, &product, warehouseID); err != nil {
+			return err
+		}
+
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("warehouse_id = ? AND product_id = ?", warehouseID, productID).
+			First(&ws).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ws = models.WarehouseStock{WarehouseID: warehouseID, ProductID: productID, Stock: stock}
+			if err := tx.Create(&ws).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Model(&ws).Update("stock", stock).Error; err != nil {
+			return err
+		}
+
+		total, err := warehouseStockTotal(tx, productID)
+		if err != nil {
+			return err
+		}
+		before := product.Stock
+		wasOutOfStock := product.Status == "out_of_stock"
+		if err := syncProductStock(tx, &product, total); err != nil {
+			return err
+		}
+		result = &stockChangeResult{
+			Product:   product,
+			Before:    before,
+			Restocked: wasOutOfStock && product.Status == "active",
+		}
+		return recordStockMovement(tx, productID, before, total, stockMovementMeta{
+			Reason:      models.StockReasonAdjustment,
+			RefID:       warehouse.Code,
+			ActorID:     operatorID,
+			WarehouseID: warehouseID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	afterStockChange(result)
+	logger.Info("设置仓库库存成功",
+		zap.Uint("warehouse_id", warehouseID),
+		zap.Uint("product_id", productID),
+		zap.Int("stock", stock),
+	)
+	return &ws, nil
+}
+
+// TransferStock 仓库间调拨库存（商品总库存不变）
+func (s *WarehouseService) TransferStock(operatorID uint, req *StockTransferRequest) (*models.StockTransfer, error) {
+	if req.FromWarehouseID == req.ToWarehouseID {
+		return nil, errors.New("调出仓库与调入仓库不能相同")
+	}
+
+	var transfer *models.StockTransfer
+	err := database.Transaction(func(tx *gorm.DB) error {
+		var warehouses []models.Warehouse
+		if err := tx.Where("id IN ?", []uint{req.FromWarehouseID, req.ToWarehouseID}).
+			Find(&warehouses).Error; err != nil {
+			return err
+		}
+		if len(warehouses) != 2 {
+			return errors.New("仓库不存在")
+		}
+		// 与发货分配一致，停用的仓库不参与库存流转
+		for _, w := range warehouses {
+			if w.Status != "active" {
+				return fmt.Errorf("仓库 %s 已停用，不能调拨", w.Code)
+			}
+		}
+
+		// 按仓库ID顺序加锁，避免相反方向的并发调拨互相死锁
+		var locked []models.WarehouseStock
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("product_id = ? AND warehouse_id IN ?", req.ProductID, []uint{req.FromWarehouseID, req.ToWarehouseID}).
+			Order("warehouse_id ASC").
+			Find(&locked).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.WarehouseStock{}).
+			Where("warehouse_id = ? AND product_id = ? AND stock >= ?", req.FromWarehouseID, req.ProductID, req.Quantity).
+			UpdateColumn("stock", gorm.Expr("stock - ?", req.Quantity))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("调出仓库%w", ErrInsufficientStock)
+		}
+
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "warehouse_id"}, {Name: "product_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"stock": gorm.Expr("warehouse_stocks.stock + ?", req.Quantity)}),
+		}).Create(&models.WarehouseStock{
+			WarehouseID: req.ToWarehouseID,
+			ProductID:   req.ProductID,
+			Stock:       req.Quantity,
+		}).Error; err != nil {
+			return err
+		}
+
+		transfer = &models.StockTransfer{
+			FromWarehouseID: req.FromWarehouseID,
+			ToWarehouseID:   req.ToWarehouseID,
+			ProductID:       req.ProductID,
+			Quantity:        req.Quantity,
+			OperatorID:      operatorID,
+			Remark:          req.Remark,
+		}
+		return tx.Create(transfer).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("仓库调拨成功",
+		zap.Uint("from_warehouse_id", req.FromWarehouseID),
+		zap.Uint("to_warehouse_id", req.ToWarehouseID),
+		zap.Uint("product_id", req.ProductID),
+		zap.Int("quantity", req.Quantity),
+	)
+	return transfer, nil
+}
+
+// ensureWarehouseManaged 商品首次配置仓库库存时，将原有的未分仓库存记入默认仓库
+// （优先级最高的启用仓库，没有启用仓库时为 fallbackID），保证商品总库存始终等于各仓库库存之和
+func ensureWarehouseManaged(tx *gorm.DB, product *models.Product, fallbackID uint) error {
+	var count int64
+	if err := tx.Model(&models.WarehouseStock{}).Where("product_id = ?", product.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 || product.Stock <= 0 {
+		return nil
+	}
+
+	var warehouse models.Warehouse
+	err := tx.Where("status = ?", "active").Order("priority DESC, id ASC").First(&warehouse).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	warehouseID := fallbackID
+	if err == nil {
+		warehouseID = warehouse.ID
+	}
+
+	logger.Info("商品改为按仓库管理库存，原库存记入默认仓库",
+		zap.Uint("product_id", product.ID), zap.Uint("warehouse_id", warehouseID), zap.Int("stock", product.Stock))
+	return tx.Create(&models.WarehouseStock{WarehouseID: warehouseID, ProductID: product.ID, Stock: product.Stock}).Error
+}
+
+// warehouseStockTotal 商品在各启用仓库的库存之和，停用仓库的库存不计入商品总库存
+func warehouseStockTotal(tx *gorm.DB, productID uint) (int, error) {
+	var total int
+	err := tx.Model(&models.WarehouseStock{}).
+		Joins("JOIN warehouses ON warehouses.id = warehouse_stocks.warehouse_id AND warehouses.deleted_at IS NULL").
+		Where("warehouse_stocks.product_id = ? AND warehouses.status = ?", productID, "active").
+		Select("COALESCE(SUM(warehouse_stocks.stock), 0)").Scan(&total).Error
+	return total, err
+}
+
+// resyncProductStock 加行锁后按启用仓库库存重新汇总商品总库存并记录流水，库存未变化时返回 nil
+func resyncProductStock(tx *gorm.DB, productID uint, meta stockMovementMeta) (*stockChangeResult, error) {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	total, err := warehouseStockTotal(tx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if total == product.Stock {
+		return nil, nil
+	}
+
+	result := &stockChangeResult{Before: product.Stock}
+	wasOutOfStock := product.Status == "out_of_stock"
+	if err := syncProductStock(tx, &product, total); err != nil {
+		return nil, err
+	}
+	result.Restocked = wasOutOfStock && product.Status == "active"
+	result.Product = product
+	return result, recordStockMovement(tx, productID, result.Before, total, meta)
+}
+
+// resyncWarehouseProducts 仓库启用或停用后，重新汇总该仓库内有库存的商品的总库存（按商品ID顺序加锁）
+func resyncWarehouseProducts(tx *gorm.DB, warehouseID uint, meta stockMovementMeta) ([]*stockChangeResult, error) {
+	var productIDs []uint
+	if err := tx.Model(&models.WarehouseStock{}).
+		Where("warehouse_id = ? AND stock > 0", warehouseID).
+		Order("product_id ASC").
+		Pluck("product_id", &productIDs).Error; err != nil {
+		return nil, err
+	}
+
+	var changes []*stockChangeResult
+	for _, productID := range productIDs {
+		r, err := resyncProductStock(tx, productID, meta)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			changes = append(changes, r)
+		}
+	}
+	return changes, nil
+}
+
+// changeWarehouseStock 按仓库管理库存的商品变更指定仓库的库存（需在已锁定商品行的事务内调用）
+// 未配置仓库库存的商品沿用单一库存，返回 false
+func changeWarehouseStock(tx *gorm.DB, productID, warehouseID uint, quantity int) (bool, error) {
+	var count int64
+	if err := tx.Model(&models.WarehouseStock{}).Where("product_id = ?", productID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count == 0 {
+		if warehouseID != 0 {
+			return false, errors.New("商品未配置仓库库存，请先设置仓库库存")
+		}
+		return false, nil
+	}
+	if warehouseID == 0 {
+		return true, ErrWarehouseRequired
+	}
+
+	result := tx.Model(&models.WarehouseStock{}).
+		Where("warehouse_id = ? AND product_id = ? AND stock + ? >= 0", warehouseID, productID, quantity).
+		UpdateColumn("stock", gorm.Expr("stock + ?", quantity))
+	if result.Error != nil {
+		return true, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	// 该仓库尚无此商品的库存记录：入库时新建，出库时库存不足
+	var exists int64
+	if err := tx.Model(&models.WarehouseStock{}).
+		Where("warehouse_id = ? AND product_id = ?", warehouseID, productID).
+		Count(&exists).Error; err != nil {
+		return true, err
+	}
+	if exists > 0 || quantity < 0 {
+		return true, ErrInsufficientStock
+	}
+	var warehouse models.Warehouse
+	if err := tx.First(&warehouse, warehouseID).Error; err != nil {
+		return true, errors.New("仓库不存在")
+	}
+	return true, tx.Create(&models.WarehouseStock{WarehouseID: warehouseID, ProductID: productID, Stock: quantity}).Error
+}
+
+// syncProductStock 更新商品总库存，并根据库存同步缺货状态
+func syncProductStock(tx *gorm.DB, product *models.Product, newStock int) error {
+	if newStock < 0 {
+		return ErrInsufficientStock
+	}
+
+	// 只在上架与缺货之间切换，草稿和已下架商品保持原状态
+	status := product.Status
+	if newStock == 0 && product.Status == "active" {
+		status = "out_of_stock"
+	} else if newStock > 0 && product.Status == "out_of_stock" {
+		status = "active"
+	}
+	if err := tx.Model(product).Updates(map[string]interface{}{"stock": newStock, "status": status}).Error; err != nil {
+		return err
+	}
+
+	product.Stock = newStock
+	product.Status = status
+	return nil
+}
+
+// allocationItem 待分配的订单项
+type allocationItem struct {
+	OrderItemID uint
+	ProductID   uint
+	Quantity    int
+}
+
+// shipmentPlan 单个仓库的发货计划
+type shipmentPlan struct {
+	WarehouseID uint
+	Items       []models.ShipmentItem
+}
+
+// allocateShipments 为订单分配发货仓库并扣减仓库库存（需在下单事务内调用）
+// 未配置仓库库存的商品沿用单一库存，不生成发货单
+func allocateShipments(tx *gorm.DB, order *models.Order, orderItems []models.OrderItem, province string) error {
+	productIDs := make([]uint, 0, len(orderItems))
+	for _, item := range orderItems {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	// 锁定相关仓库库存行（固定顺序加锁避免死锁）
+	var stocks []models.WarehouseStock
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id IN ?", productIDs).
+		Order("product_id ASC, warehouse_id ASC").
+		Find(&stocks).Error; err != nil {
+		return err
+	}
+	if len(stocks) == 0 {
+		return nil
+	}
+
+	managed := make(map[uint]bool, len(stocks))
+	warehouseIDs := make([]uint, 0, len(stocks))
+	for _, st := range stocks {
+		managed[st.ProductID] = true
+		warehouseIDs = append(warehouseIDs, st.WarehouseID)
+	}
+
+	var warehouses []models.Warehouse
+	if err := tx.Where("id IN ? AND status = ?", warehouseIDs, "active").Find(&warehouses).Error; err != nil {
+		return err
+	}
+
+	var items []allocationItem
+	for _, item := range orderItems {
+		if managed[item.ProductID] {
+			items = append(items, allocationItem{OrderItemID: item.ID, ProductID: item.ProductID, Quantity: item.Quantity})
+		}
+	}
+
+	plans, err := planShipments(config.AppConfig.Inventory.AllocationStrategy, province, warehouses, stocks, items)
+	if err != nil {
+		return err
+	}
+
+	for i, plan := range plans {
+		for _, item := range plan.Items {
+			result := tx.Model(&models.WarehouseStock{}).
+				Where("warehouse_id = ? AND product_id = ? AND stock >= ?", plan.WarehouseID, item.ProductID, item.Quantity).
+				UpdateColumn("stock", gorm.Expr("stock - ?", item.Quantity))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrInsufficientStock
+			}
+		}
+
+		shipment := &models.Shipment{
+			ShipmentNo:  fmt.Sprintf("%s-%d", order.OrderNo, i+1),
+			OrderID:     order.ID,
+			WarehouseID: plan.WarehouseID,
+			Status:      "pending",
+			Items:       plan.Items,
+		}
+		if err := tx.Create(shipment).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(plans) > 1 {
+		logger.Info("订单拆分发货", zap.Uint("order_id", order.ID), zap.Int("shipments", len(plans)))
+	}
+	return nil
+}
+
+// releaseShipments 取消订单时将发货单占用的仓库库存退回
+// 退回到已停用仓库的库存不计入商品总库存，相关商品按启用仓库库存重新汇总
+func releaseShipments(tx *gorm.DB, orderID uint, meta stockMovementMeta) error {
+	var shipments []models.Shipment
+	if err := tx.Preload("Items").Preload("Warehouse").
+		Where("order_id = ? AND status = ?", orderID, "pending").
+		Find(&shipments).Error; err != nil {
+		return err
+	}
+
+	var resync []uint
+	for _, shipment := range shipments {
+		for _, item := range shipment.Items {
+			if err := tx.Model(&models.WarehouseStock{}).
+				Where("warehouse_id = ? AND product_id = ?", shipment.WarehouseID, item.ProductID).
+				UpdateColumn("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
+				return err
+			}
+			if shipment.Warehouse == nil || shipment.Warehouse.Status != "active" {
+				resync = append(resync, item.ProductID)
+			}
+		}
+		if err := tx.Model(&shipment).Update("status", "cancelled").Error; err != nil {
+			return err
+		}
+	}
+
+	for _, productID := range resync {
+		if _, err := resyncProductStock(tx, productID, meta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// planShipments 根据分配策略生成发货计划
+// 优先选择能独立满足整单的仓库；否则按策略顺序逐项拆分到多个仓库
+func planShipments(strategy, province string, warehouses []models.Warehouse, stocks []models.WarehouseStock, items []allocationItem) ([]shipmentPlan, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	available := make(map[uint]map[uint]int, len(warehouses))
+	for _, w := range warehouses {
+		available[w.ID] = make(map[uint]int)
+	}
+	for _, st := range stocks {
+		if avail, ok := available[st.WarehouseID]; ok {
+			avail[st.ProductID] += st.Stock
+		}
+	}
+
+	ranked := rankWarehouses(strategy, province, warehouses, available, items)
+
+	// 单仓即可满足整单时不拆分
+	for _, w := range ranked {
+		if canFulfill(available[w.ID], items) {
+			plan := shipmentPlan{WarehouseID: w.ID}
+			for _, item := range items {
+				plan.Items = append(plan.Items, models.ShipmentItem{
+					OrderItemID: item.OrderItemID,
+					ProductID:   item.ProductID,
+					Quantity:    item.Quantity,
+				})
+			}
+			return []shipmentPlan{plan}, nil
+		}
+	}
+
+	// 拆单：逐项按仓库顺序分配
+	byWarehouse := make(map[uint]*shipmentPlan)
+	for _, item := range items {
+		candidates := ranked
+		if strategy == AllocationMostStock {
+			candidates = rankByProductStock(ranked, available, item.ProductID)
+		}
+
+		remaining := item.Quantity
+		for _, w := range candidates {
+			if remaining == 0 {
+				break
+			}
+			take := available[w.ID][item.ProductID]
+			if take <= 0 {
+				continue
+			}
+			if take > remaining {
+				take = remaining
+			}
+			available[w.ID][item.ProductID] -= take
+			remaining -= take
+
+			plan, ok := byWarehouse[w.ID]
+			if !ok {
+				plan = &shipmentPlan{WarehouseID: w.ID}
+				byWarehouse[w.ID] = plan
+			}
+			plan.Items = append(plan.Items, models.ShipmentItem{
+				OrderItemID: item.OrderItemID,
+				ProductID:   item.ProductID,
+				Quantity:    take,
+			})
+		}
+		if remaining > 0 {
+			return nil, fmt.Errorf("商品 %d 各仓库%w", item.ProductID, ErrInsufficientStock)
+		}
+	}
+
+	plans := make([]shipmentPlan, 0, len(byWarehouse))
+	for _, w := range ranked {
+		if plan, ok := byWarehouse[w.ID]; ok {
+			plans = append(plans, *plan)
+		}
+	}
+	return plans, nil
+}
+
+// canFulfill 判断仓库能否独立满足所有订单项
+func canFulfill(avail map[uint]int, items []allocationItem) bool {
+	need := make(map[uint]int, len(items))
+	for _, item := range items {
+		need[item.ProductID] += item.Quantity
+	}
+	for productID, qty := range need {
+		if avail[productID] < qty {
+			return false
+		}
+	}
+	return true
+}
+
+// rankWarehouses 按分配策略对仓库排序
+func rankWarehouses(strategy, province string, warehouses []models.Warehouse, available map[uint]map[uint]int, items []allocationItem) []models.Warehouse {
+	ranked := make([]models.Warehouse, len(warehouses))
+	copy(ranked, warehouses)
+
+	total := make(map[uint]int, len(warehouses))
+	for _, w := range warehouses {
+		for _, item := range items {
+			total[w.ID] += available[w.ID][item.ProductID]
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if strategy == AllocationMostStock {
+			if total[a.ID] != total[b.ID] {
+				return total[a.ID] > total[b.ID]
+			}
+		} else {
+			da, db := provinceDistance(a.Province, province), provinceDistance(b.Province, province)
+			if da != db {
+				return da < db
+			}
+		}
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return a.ID < b.ID
+	})
+	return ranked
+}
+
+// rankByProductStock 按单个商品的可用库存从多到少排序
+func rankByProductStock(warehouses []models.Warehouse, available map[uint]map[uint]int, productID uint) []models.Warehouse {
+	ranked := make([]models.Warehouse, len(warehouses))
+	copy(ranked, warehouses)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return available[ranked[i].ID][productID] > available[ranked[j].ID][productID]
+	})
+	return ranked
+}
+
+// regionOfProvince 省份所属大区，用于估算仓库与收货地址的远近
+var regionOfProvince = map[string]string{
+	"北京": "华北", "天津": "华北", "河北": "华北", "山西": "华北", "内蒙古": "华北",
+	"辽宁": "东北", "吉林": "东北", "黑龙江": "东北",
+	"上海": "华东", "江苏": "华东", "浙江": "华东", "安徽": "华东", "福建": "华东", "江西": "华东", "山东": "华东",
+	"河南": "华中", "湖北": "华中", "湖南": "华中",
+	"广东": "华南", "广西": "华南", "海南": "华南", "香港": "华南", "澳门": "华南",
+	"重庆": "西南", "四川": "西南", "贵州": "西南", "云南": "西南", "西藏": "西南",
+	"陕西": "西北", "甘肃": "西北", "青海": "西北", "宁夏": "西北", "新疆": "西北",
+	"台湾": "华东",
+}
+
+// normalizeProvince 去掉“省/市/自治区”等后缀，便于匹配
+func normalizeProvince(province string) string {
+	province = strings.TrimSpace(province)
+	for name := range regionOfProvince {
+		if strings.HasPrefix(province, name) {
+			return name
+		}
+	}
+	return province
+}
+
+// provinceDistance 仓库省份与收货省份的距离等级：0 同省，1 同大区，2 其他
+func provinceDistance(warehouseProvince, deliveryProvince string) int {
+	a, b := normalizeProvince(warehouseProvince), normalizeProvince(deliveryProvince)
+	if a == b {
+		return 0
+	}
+	if ra, ok := regionOfProvince[a]; ok && ra == regionOfProvince[b] {
+		return 1
+	}
+	return 2
+}
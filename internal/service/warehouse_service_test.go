@@ -0,0 +1,220 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPlanShipments 测试多仓分配策略与拆单
+func TestPlanShipments(t *testing.T) {
+	warehouses := []models.Warehouse{
+		{ID: 1, Code: "BJ", Province: "北京市"},
+		{ID: 2, Code: "SZ", Province: "广东省"},
+		{ID: 3, Code: "GZ", Province: "广西壮族自治区"},
+	}
+	stocks := []models.WarehouseStock{
+		{WarehouseID: 1, ProductID: 100, Stock: 50},
+		{WarehouseID: 1, ProductID: 200, Stock: 50},
+		{WarehouseID: 2, ProductID: 100, Stock: 5},
+		{WarehouseID: 3, ProductID: 100, Stock: 3},
+		{WarehouseID: 3, ProductID: 200, Stock: 8},
+	}
+
+	tests := []struct {
+		name     string
+		strategy string
+		province string
+		items    []allocationItem
+		want     map[uint]map[uint]int // warehouse -> product -> quantity
+		wantErr  bool
+	}{
+		{
+			name:     "就近发货：同省仓库满足",
+			strategy: AllocationNearest,
+			province: "广东省",
+			items:    []allocationItem{{OrderItemID: 1, ProductID: 100, Quantity: 4}},
+			want:     map[uint]map[uint]int{2: {100: 4}},
+		},
+		{
+			name:     "就近发货：同省不足时选同大区单仓",
+			strategy: AllocationNearest,
+			province: "广东",
+			items: []allocationItem{
+				{OrderItemID: 1, ProductID: 100, Quantity: 2},
+				{OrderItemID: 2, ProductID: 200, Quantity: 2},
+			},
+			want: map[uint]map[uint]int{3: {100: 2, 200: 2}},
+		},
+		{
+			name:     "库存最多优先",
+			strategy: AllocationMostStock,
+			province: "广东省",
+			items:    []allocationItem{{OrderItemID: 1, ProductID: 100, Quantity: 4}},
+			want:     map[uint]map[uint]int{1: {100: 4}},
+		},
+		{
+			name:     "无单仓可满足时拆单",
+			strategy: AllocationNearest,
+			province: "广东省",
+			items: []allocationItem{
+				{OrderItemID: 1, ProductID: 100, Quantity: 7},
+				{OrderItemID: 2, ProductID: 200, Quantity: 55},
+			},
+			want: map[uint]map[uint]int{
+				2: {100: 5},
+				3: {100: 2, 200: 8},
+				1: {200: 47},
+			},
+		},
+		{
+			name:     "所有仓库合计仍不足",
+			strategy: AllocationNearest,
+			province: "广东省",
+			items:    []allocationItem{{OrderItemID: 1, ProductID: 100, Quantity: 100}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plans, err := planShipments(tt.strategy, tt.province, warehouses, stocks, tt.items)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrInsufficientStock))
+				return
+			}
+			require.NoError(t, err)
+
+			got := make(map[uint]map[uint]int)
+			for _, plan := range plans {
+				got[plan.WarehouseID] = make(map[uint]int)
+				for _, item := range plan.Items {
+					got[plan.WarehouseID][item.ProductID] += item.Quantity
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestProvinceDistance 测试省份距离等级
+func TestProvinceDistance(t *testing.T) {
+	assert.Equal(t, 0, provinceDistance("广东省", "广东"))
+	assert.Equal(t, 1, provinceDistance("广西壮族自治区", "海南省"))
+	assert.Equal(t, 2, provinceDistance("北京市", "广东省"))
+}
+
+// TestWarehouseStockInSync 测试商品总库存始终等于各启用仓库库存之和
+func TestWarehouseStockInSync(t *testing.T) {
+	setupTest()
+
+	warehouseService := NewWarehouseService()
+	inventoryService := NewInventoryService()
+
+	run := time.Now().UnixNano()
+	product := models.Product{Name: "分仓商品", Price: 10, Stock: 10, SKU: fmt.Sprintf("WHSYNC%d", run), Status: "active"}
+	require.NoError(t, database.DB.Create(&product).Error)
+	warehouse, err := warehouseService.CreateWarehouse(&WarehouseRequest{
+		Code: fmt.Sprintf("WH%d", run), Name: "测试仓", Province: "广东省",
+	})
+	require.NoError(t, err)
+
+	assertInSync := func(want int) {
+		t.Helper()
+		var current models.Product
+		require.NoError(t, database.DB.First(&current, product.ID).Error)
+		total, err := warehouseStockTotal(database.DB, product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, want, current.Stock)
+		assert.Equal(t, want, total)
+	}
+
+	// 首次设置仓库库存时，原有的 10 件记入默认仓库
+	_, err = warehouseService.SetWarehouseStock(1, warehouse.ID, product.ID, 5)
+	require.NoError(t, err)
+	var count int64
+	database.DB.Model(&models.WarehouseStock{}).Where("product_id = ?", product.ID).Count(&count)
+	if count > 1 {
+		assertInSync(15)
+	} else {
+		assertInSync(5) // 默认仓库即为本仓库，设置值覆盖原库存
+	}
+	var before int
+	require.NoError(t, database.DB.Model(&models.Product{}).Where("id = ?", product.ID).Select("stock").Scan(&before).Error)
+
+	// 按仓库管理的商品调整库存须指定仓库
+	_, err = inventoryService.AdjustStock(1, product.ID, &StockAdjustRequest{Quantity: 3, Reason: models.StockReasonRestock})
+	assert.ErrorIs(t, err, ErrWarehouseRequired)
+
+	_, err = inventoryService.AdjustStock(1, product.ID, &StockAdjustRequest{
+		WarehouseID: warehouse.ID, Quantity: 3, Reason: models.StockReasonRestock,
+	})
+	require.NoError(t, err)
+	assertInSync(before + 3)
+
+	_, err = inventoryService.AdjustStock(1, product.ID, &StockAdjustRequest{
+		WarehouseID: warehouse.ID, Quantity: -100, Reason: models.StockReasonAdjustment,
+	})
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+	assertInSync(before + 3)
+}
+
+// TestDeactivateWarehouseResyncsStock 停用仓库后其库存不计入商品总库存，重新启用后恢复
+func TestDeactivateWarehouseResyncsStock(t *testing.T) {
+	setupTest()
+
+	warehouseService := NewWarehouseService()
+
+	run := time.Now().UnixNano()
+	product := models.Product{Name: "停用仓库商品", Price: 10, Stock: 0, SKU: fmt.Sprintf("WHOFF%d", run), Status: "active"}
+	require.NoError(t, database.DB.Create(&product).Error)
+	mainReq := &WarehouseRequest{Code: fmt.Sprintf("WHM%d", run), Name: "主仓", Province: "广东省"}
+	backupReq := &WarehouseRequest{Code: fmt.Sprintf("WHB%d", run), Name: "备仓", Province: "浙江省"}
+	mainWarehouse, err := warehouseService.CreateWarehouse(mainReq)
+	require.NoError(t, err)
+	backup, err := warehouseService.CreateWarehouse(backupReq)
+	require.NoError(t, err)
+
+	_, err = warehouseService.SetWarehouseStock(1, mainWarehouse.ID, product.ID, 4)
+	require.NoError(t, err)
+	_, err = warehouseService.SetWarehouseStock(1, backup.ID, product.ID, 6)
+	require.NoError(t, err)
+
+	reload := func() models.Product {
+		t.Helper()
+		var p models.Product
+		require.NoError(t, database.DB.First(&p, product.ID).Error)
+		return p
+	}
+	assert.Equal(t, 10, reload().Stock)
+
+	backupReq.Status = "inactive"
+	_, err = warehouseService.UpdateWarehouse(backup.ID, backupReq)
+	require.NoError(t, err)
+	assert.Equal(t, 4, reload().Stock, "停用仓库的库存不计入")
+
+	mainReq.Status = "inactive"
+	_, err = warehouseService.UpdateWarehouse(mainWarehouse.ID, mainReq)
+	require.NoError(t, err)
+	p := reload()
+	assert.Equal(t, 0, p.Stock)
+	assert.Equal(t, "out_of_stock", p.Status, "全部仓库停用后缺货")
+
+	backupReq.Status = "active"
+	_, err = warehouseService.UpdateWarehouse(backup.ID, backupReq)
+	require.NoError(t, err)
+	p = reload()
+	assert.Equal(t, 6, p.Stock)
+	assert.Equal(t, "active", p.Status, "重新启用仓库后恢复在售")
+
+	// 停用仓库内的库存调整不影响商品总库存
+	_, err = warehouseService.SetWarehouseStock(1, mainWarehouse.ID, product.ID, 20)
+	require.NoError(t, err)
+	assert.Equal(t, 6, reload().Stock)
+}
@@ -0,0 +1,378 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/shoppee/ecommerce/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrWishlistNotFound 收藏夹不存在
+	ErrWishlistNotFound = errors.New("收藏夹不存在")
+	// ErrWishlistItemNotFound 收藏的商品不存在
+	ErrWishlistItemNotFound = errors.New("收藏的商品不存在")
+)
+
+// 收藏夹限制
+const (
+	defaultWishlistName = "默认收藏夹"
+	maxWishlists        = 20  // 每个用户最多的收藏夹数
+	maxWishlistItems    = 200 // 每个收藏夹最多的商品数
+)
+
+// WishlistService 收藏夹服务
+type WishlistService struct {
+	cartService *CartService
+}
+
+// NewWishlistService 创建收藏夹服务实例
+func NewWishlistService() *WishlistService {
+	return &WishlistService{cartService: NewCartService()}
+}
+
+// WishlistRequest 创建/重命名收藏夹请求
+type WishlistRequest struct {
+	Name string `json:"name" binding:"required,max=50"`
+}
+
+// AddWishlistItemRequest 收藏商品请求
+type AddWishlistItemRequest struct {
+	WishlistID uint `json:"wishlist_id"` // 为空时加入默认收藏夹
+	ProductID  uint `json:"product_id" binding:"required"`
+	VariantID  uint `json:"variant_id"`
+}
+
+// MoveToCartRequest 收藏商品加入购物车请求
+type MoveToCartRequest struct {
+	Quantity  int  `json:"quantity" binding:"omitempty,min=1"` // 默认 1
+	VariantID uint `json:"variant_id"`                         // 收藏时未选规格的多规格商品必填
+	Keep      bool `json:"keep"`                               // 加入购物车后保留在收藏夹
+}
+
+// GetWishlists 获取用户的收藏夹及商品数
+func (s *WishlistService) GetWishlists(userID uint) ([]models.Wishlist, error) {
+	var wishlists []models.Wishlist
+	if err := database.DB.Where("user_id = ?", userID).
+		Order("is_default DESC, id ASC").
+		Find(&wishlists).Error; err != nil {
+		return nil, err
+	}
+	if len(wishlists) == 0 {
+		return wishlists, nil
+	}
+
+	ids := make([]uint, 0, len(wishlists))
+	for _, w := range wishlists {
+		ids = append(ids, w.ID)
+	}
+	var counts []struct {
+		WishlistID uint
+		Count      int
+	}
+	if err := database.DB.Model(&models.WishlistItem{}).
+		Select("wishlist_id, COUNT(*) AS count").
+		Where("wishlist_id IN ?", ids).
+		Group("wishlist_id").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	countMap := make(map[uint]int, len(counts))
+	for _, c := range counts {
+		countMap[c.WishlistID] = c.Count
+	}
+	for i := range wishlists {
+		wishlists[i].ItemCount = countMap[wishlists[i].ID]
+	}
+	return wishlists, nil
+}
+
+// GetWishlist 获取收藏夹及商品（按收藏时间倒序），并标记降价商品
+func (s *WishlistService) GetWishlist(userID, wishlistID uint) (*models.Wishlist, error) {
+	var wishlist models.Wishlist
+	if err := database.DB.Where("id = ? AND user_id = ?", wishlistID, userID).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC, id DESC") }).
+		Preload("Items.Product.Media", "sort = ?", 0).
+		Preload("Items.Product.Media.Media.Thumbnails").
+		Preload("Items.Variant").
+		First(&wishlist).Error; err != nil {
+		return nil, ErrWishlistNotFound
+	}
+
+	// 已删除的商品不再展示
+	items := wishlist.Items[:0]
+	for _, item := range wishlist.Items {
+		if item.Product == nil {
+			continue
+		}
+		fillPriceDrop(&item)
+		items = append(items, item)
+	}
+	wishlist.Items = items
+	wishlist.ItemCount = len(items)
+	return &wishlist, nil
+}
+
+// CreateWishlist 创建收藏夹
+func (s *WishlistService) CreateWishlist(userID uint, req *WishlistRequest) (*models.Wishlist, error) {
+	var wishlist *models.Wishlist
+	err := database.Transaction(func(tx *gorm.DB) error {
+		var err error
+		wishlist, err = createWishlist(tx, userID, req.Name, false)
+		return err
+	})
+	return wishlist, err
+}
+
+// RenameWishlist 重命名收藏夹
+func (s *WishlistService) RenameWishlist(userID, wishlistID uint, req *WishlistRequest) (*models.Wishlist, error) {
+	var wishlist models.Wishlist
+	if err := database.DB.Where("id = ? AND user_id = ?", wishlistID, userID).First(&wishlist).Error; err != nil {
+		return nil, ErrWishlistNotFound
+	}
+	if err := checkWishlistName(database.DB, userID, wishlistID, req.Name); err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Model(&wishlist).Update("name", req.Name).Error; err != nil {
+		return nil, err
+	}
+	return &wishlist, nil
+}
+
+// DeleteWishlist 删除收藏夹及其中的商品
+func (s *WishlistService) DeleteWishlist(userID, wishlistID uint) error {
+	var productIDs []uint
+	err := database.Transaction(func(tx *gorm.DB) error {
+		var wishlist models.Wishlist
+		if err := tx.Where("id = ? AND user_id = ?", wishlistID, userID).First(&wishlist).Error; err != nil {
+			return ErrWishlistNotFound
+		}
+		if err := tx.Model(&models.WishlistItem{}).Where("wishlist_id = ?", wishlistID).
+			Distinct().Pluck("product_id", &productIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("wishlist_id = ?", wishlistID).Delete(&models.WishlistItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&wishlist).Error; err != nil {
+			return err
+		}
+		return refreshFavoriteCounts(tx, productIDs...)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, productID := range productIDs {
+		invalidateProductCache(productID)
+	}
+	return nil
+}
+
+// AddItem 收藏商品；同一收藏夹内重复收藏同一商品（规格）时返回已有记录，保留最初的收藏价格
+func (s *WishlistService) AddItem(userID uint, req *AddWishlistItemRequest) (*models.WishlistItem, error) {
+	var product models.Product
+	if err := database.DB.First(&product, req.ProductID).Error; err != nil || !IsPublished(product.Status) {
+		return nil, ErrProductNotFound
+	}
+	price := product.Price
+	if req.VariantID != 0 {
+		variant, err := findVariant(database.DB, product.ID, req.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		price = variant.Price
+	}
+
+	var item models.WishlistItem
+	err := database.Transaction(func(tx *gorm.DB) error {
+		wishlist, err := resolveWishlist(tx, userID, req.WishlistID)
+		if err != nil {
+			return err
+		}
+
+		existing := func() *gorm.DB {
+			return tx.Where("wishlist_id = ? AND product_id = ? AND COALESCE(variant_id, 0) = ?",
+				wishlist.ID, product.ID, req.VariantID)
+		}
+		if err := existing().First(&item).Error; err == nil {
+			return nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.WishlistItem{}).Where("wishlist_id = ?", wishlist.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= maxWishlistItems {
+			return fmt.Errorf("每个收藏夹最多收藏 %d 个商品", maxWishlistItems)
+		}
+
+		item = models.WishlistItem{WishlistID: wishlist.ID, ProductID: product.ID, PriceAtAdd: price}
+		if req.VariantID != 0 {
+			item.VariantID = &req.VariantID
+		}
+		// 并发收藏同一商品时由唯一索引去重，后写入的请求返回已有收藏项
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&item)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return existing().First(&item).Error
+		}
+		return refreshFavoriteCounts(tx, product.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateProductCache(product.ID)
+	return &item, nil
+}
+
+// RemoveItem 从收藏夹移除商品
+func (s *WishlistService) RemoveItem(userID, wishlistID, itemID uint) error {
+	var item models.WishlistItem
+	err := database.Transaction(func(tx *gorm.DB) error {
+		var err error
+		item, err = findWishlistItem(tx, userID, wishlistID, itemID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&item).Error; err != nil {
+			return err
+		}
+		return refreshFavoriteCounts(tx, item.ProductID)
+	})
+	if err != nil {
+		return err
+	}
+
+	invalidateProductCache(item.ProductID)
+	return nil
+}
+
+// MoveToCart 将收藏的商品加入购物车，默认随后从收藏夹移除
+func (s *WishlistService) MoveToCart(userID, wishlistID, itemID uint, req *MoveToCartRequest) error {
+	item, err := findWishlistItem(database.DB, userID, wishlistID, itemID)
+	if err != nil {
+		return err
+	}
+
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+	variantID := req.VariantID
+	if variantID == 0 && item.VariantID != nil {
+		variantID = *item.VariantID
+	}
+	if err := s.cartService.AddCartItem(userID, item.ProductID, variantID, quantity); err != nil {
+		return err
+	}
+
+	if !req.Keep {
+		if err := s.RemoveItem(userID, wishlistID, itemID); err != nil {
+			// 已加入购物车，移除失败不影响结果
+			logger.Warn("收藏商品加入购物车后移除失败", zap.Uint("item_id", itemID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// resolveWishlist 获取指定收藏夹，未指定时获取或创建默认收藏夹
+func resolveWishlist(tx *gorm.DB, userID, wishlistID uint) (*models.Wishlist, error) {
+	var wishlist models.Wishlist
+	if wishlistID != 0 {
+		if err := tx.Where("id = ? AND user_id = ?", wishlistID, userID).First(&wishlist).Error; err != nil {
+			return nil, ErrWishlistNotFound
+		}
+		return &wishlist, nil
+	}
+
+	err := tx.Where("user_id = ? AND is_default = ?", userID, true).First(&wishlist).Error
+	if err == nil {
+		return &wishlist, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return createWishlist(tx, userID, defaultWishlistName, true)
+}
+
+// createWishlist 创建收藏夹（校验数量上限与名称唯一）
+func createWishlist(tx *gorm.DB, userID uint, name string, isDefault bool) (*models.Wishlist, error) {
+	var count int64
+	if err := tx.Model(&models.Wishlist{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= maxWishlists {
+		return nil, fmt.Errorf("最多创建 %d 个收藏夹", maxWishlists)
+	}
+	if err := checkWishlistName(tx, userID, 0, name); err != nil {
+		return nil, err
+	}
+
+	wishlist := &models.Wishlist{UserID: userID, Name: name, IsDefault: isDefault}
+	if err := tx.Create(wishlist).Error; err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+// checkWishlistName 校验同一用户的收藏夹名称唯一
+func checkWishlistName(tx *gorm.DB, userID, wishlistID uint, name string) error {
+	var count int64
+	if err := tx.Model(&models.Wishlist{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, wishlistID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("已存在同名收藏夹")
+	}
+	return nil
+}
+
+// findWishlistItem 查找用户收藏夹中的商品
+func findWishlistItem(tx *gorm.DB, userID, wishlistID, itemID uint) (models.WishlistItem, error) {
+	var item models.WishlistItem
+	if err := tx.Joins("JOIN wishlists ON wishlists.id = wishlist_items.wishlist_id").
+		Where("wishlist_items.id = ? AND wishlist_items.wishlist_id = ? AND wishlists.user_id = ?", itemID, wishlistID, userID).
+		First(&item).Error; err != nil {
+		return item, ErrWishlistItemNotFound
+	}
+	return item, nil
+}
+
+// refreshFavoriteCounts 重新统计商品的收藏人数（同一用户多个收藏夹收藏同一商品只计一次）
+func refreshFavoriteCounts(tx *gorm.DB, productIDs ...uint) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+	return tx.Exec(`UPDATE products SET favorite_count = (
+			SELECT COUNT(DISTINCT w.user_id) FROM wishlist_items wi
+			JOIN wishlists w ON w.id = wi.wishlist_id
+			WHERE wi.product_id = products.id
+		) WHERE id IN ?`, productIDs).Error
+}
+
+// fillPriceDrop 计算当前价格及相对收藏时的降价
+func fillPriceDrop(item *models.WishlistItem) {
+	item.CurrentPrice = item.Product.Price
+	if item.Variant != nil {
+		item.CurrentPrice = item.Variant.Price
+	}
+	if item.CurrentPrice < item.PriceAtAdd {
+		item.PriceDropped = true
+		item.PriceDrop = math.Round((item.PriceAtAdd-item.CurrentPrice)*100) / 100
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shoppee/ecommerce/internal/database"
+	"github.com/shoppee/ecommerce/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFillPriceDrop 测试相对收藏价格的降价标记
+func TestFillPriceDrop(t *testing.T) {
+	// 降价
+	item := models.WishlistItem{PriceAtAdd: 99.9, Product: &models.Product{Price: 79.8}}
+	fillPriceDrop(&item)
+	assert.True(t, item.PriceDropped)
+	assert.Equal(t, 79.8, item.CurrentPrice)
+	assert.Equal(t, 20.1, item.PriceDrop)
+
+	// 涨价不标记
+	item = models.WishlistItem{PriceAtAdd: 50, Product: &models.Product{Price: 60}}
+	fillPriceDrop(&item)
+	assert.False(t, item.PriceDropped)
+	assert.Zero(t, item.PriceDrop)
+
+	// 有规格时以规格价格为准
+	item = models.WishlistItem{
+		PriceAtAdd: 120,
+		Product:    &models.Product{Price: 100},
+		Variant:    &models.ProductVariant{Price: 110},
+	}
+	fillPriceDrop(&item)
+	assert.Equal(t, 110.0, item.CurrentPrice)
+	assert.Equal(t, 10.0, item.PriceDrop)
+}
+
+// TestAddItemConcurrent 并发收藏同一商品（如连点）只产生一条收藏项，草稿商品不能收藏
+func TestAddItemConcurrent(t *testing.T) {
+	setupTest()
+
+	wishlistService := NewWishlistService()
+	run := time.Now().UnixNano()
+
+	product := models.Product{Name: "收藏商品", Price: 88, Stock: 10, SKU: fmt.Sprintf("WISH%d", run), Status: "active"}
+	require.NoError(t, database.DB.Create(&product).Error)
+	draft := models.Product{Name: "草稿商品", Price: 88, Stock: 10, SKU: fmt.Sprintf("WISHD%d", run), Status: "draft"}
+	require.NoError(t, database.DB.Create(&draft).Error)
+
+	userID := uint(run % 1000000000)
+	wishlist := models.Wishlist{UserID: userID, Name: "测试收藏夹"}
+	require.NoError(t, database.DB.Create(&wishlist).Error)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := wishlistService.AddItem(userID, &AddWishlistItemRequest{WishlistID: wishlist.ID, ProductID: product.ID})
+			assert.NoError(t, err)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	var count int64
+	database.DB.Model(&models.WishlistItem{}).Where("wishlist_id = ? AND product_id = ?", wishlist.ID, product.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	var updated models.Product
+	require.NoError(t, database.DB.First(&updated, product.ID).Error)
+	assert.Equal(t, 1, updated.FavoriteCount)
+
+	_, err := wishlistService.AddItem(userID, &AddWishlistItemRequest{WishlistID: wishlist.ID, ProductID: draft.ID})
+	assert.ErrorIs(t, err, ErrProductNotFound)
+}
@@ -42,6 +42,9 @@ type Client struct {
 
 	// 用户ID
 	userID uint
+
+	// 用户角色（user, admin）
+	role string
 }
 
 // readPump 从WebSocket连接读取消息并转发到Hub
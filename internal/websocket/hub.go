@@ -136,6 +136,34 @@ func (h *Hub) SendToUser(userID uint, msg *Message) bool {
 	}
 }
 
+// SendToRole 发送消息给指定角色的所有在线用户，返回已送达的用户ID
+func (h *Hub) SendToRole(role string, msg *Message) []uint {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logger.Error("序列化消息失败", zap.Error(err))
+		return nil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var delivered []uint
+	for userID, client := range h.userClients {
+		if client.role != role {
+			continue
+		}
+		select {
+		case client.send <- data:
+			delivered = append(delivered, userID)
+		default:
+			logger.Warn("发送消息失败，通道已满", zap.Uint("user_id", userID))
+		}
+	}
+
+	logger.Debug("按角色发送消息", zap.String("role", role), zap.String("type", msg.Type), zap.Int("delivered", len(delivered)))
+	return delivered
+}
+
 // GetOnlineUserCount 获取在线用户数
 func (h *Hub) GetOnlineUserCount() int {
 	h.mu.RLock()
@@ -15,6 +15,9 @@ var (
 	// GlobalHub 全局WebSocket Hub实例
 	GlobalHub *Hub
 
+	// connectHandler 客户端连接建立后的回调（用于补发离线消息）
+	connectHandler func(userID uint)
+
 	// upgrader WebSocket升级器
 	upgrader = websocket.Upgrader{
 		ReadBufferSize:  1024,
@@ -33,10 +36,16 @@ func InitWebSocket() {
 	logger.Info("WebSocket服务已启动")
 }
 
+// SetConnectHandler 设置客户端连接建立后的回调
+func SetConnectHandler(fn func(userID uint)) {
+	connectHandler = fn
+}
+
 // HandleWebSocket 处理WebSocket连接
 func HandleWebSocket(c *gin.Context) {
 	// 获取当前用户ID（从JWT认证中间件）
 	userID := middleware.GetCurrentUserID(c)
+	role := c.GetString("role")
 
 	// 升级HTTP连接为WebSocket
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
@@ -51,6 +60,7 @@ func HandleWebSocket(c *gin.Context) {
 		conn:   conn,
 		send:   make(chan []byte, 256),
 		userID: userID,
+		role:   role,
 	}
 
 	// 注册客户端
@@ -64,6 +74,11 @@ func HandleWebSocket(c *gin.Context) {
 	}
 	GlobalHub.SendToUser(userID, welcomeMsg)
 
+	// 补发离线期间的消息
+	if connectHandler != nil {
+		go connectHandler(userID)
+	}
+
 	// 启动读写协程
 	go client.writePump()
 	go client.readPump()
@@ -107,23 +122,53 @@ func BroadcastPromotion(title, content string) {
 	GlobalHub.BroadcastMessage(msg)
 }
 
-// NotifyStockAlert 库存预警通知
-func NotifyStockAlert(adminUserID uint, productID uint, productName string, stock int) {
+// NotifyBackInStock 到货通知
+func NotifyBackInStock(userID uint, productID uint, productName string) bool {
 	if GlobalHub == nil {
-		return
+		return false
 	}
 
 	msg := &Message{
-		Type: "stock_alert",
-		Content: map[string]interface{}{
-			"product_id":   productID,
-			"product_name": productName,
-			"stock":        stock,
-			"message":      "商品库存不足，请及时补货",
-		},
-		UserID: adminUserID,
-		Time:   time.Now().Unix(),
+		Type:    "back_in_stock",
+		Content: BackInStockContent(productID, productName),
+		UserID:  userID,
+		Time:    time.Now().Unix(),
 	}
 
-	GlobalHub.SendToUser(adminUserID, msg)
+	return GlobalHub.SendToUser(userID, msg)
+}
+
+// BackInStockContent 到货通知消息内容
+func BackInStockContent(productID uint, productName string) map[string]interface{} {
+	return map[string]interface{}{
+		"product_id":   productID,
+		"product_name": productName,
+		"message":      "您关注的商品已到货，库存有限，欲购从速",
+	}
+}
+
+// NotifyStockAlert 库存预警通知（发送给所有在线管理员，返回已送达的管理员ID）
+func NotifyStockAlert(productID uint, productName string, stock, threshold int) []uint {
+	if GlobalHub == nil {
+		return nil
+	}
+
+	msg := &Message{
+		Type:    "stock_alert",
+		Content: StockAlertContent(productID, productName, stock, threshold),
+		Time:    time.Now().Unix(),
+	}
+
+	return GlobalHub.SendToRole("admin", msg)
+}
+
+// StockAlertContent 库存预警消息内容
+func StockAlertContent(productID uint, productName string, stock, threshold int) map[string]interface{} {
+	return map[string]interface{}{
+		"product_id":        productID,
+		"product_name":      productName,
+		"stock":             stock,
+		"reorder_threshold": threshold,
+		"message":           "商品库存不足，请及时补货",
+	}
 }
@@ -0,0 +1,89 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Fit 等比缩小图片使其不超过 maxWidth × maxHeight，不放大；
+// 缩小时按区域平均取色（盒式滤波），避免直接抽样产生的锯齿
+func Fit(src image.Image, maxWidth, maxHeight int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxWidth && h <= maxHeight {
+		return src
+	}
+
+	dw, dh := FitSize(w, h, maxWidth, maxHeight)
+	return resize(toRGBA(src), dw, dh)
+}
+
+// FitSize 计算等比缩放到指定范围内的尺寸（不放大）
+func FitSize(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= maxWidth && height <= maxHeight {
+		return width, height
+	}
+	if width*maxHeight > height*maxWidth {
+		h := height * maxWidth / width
+		if h < 1 {
+			h = 1
+		}
+		return maxWidth, h
+	}
+	w := width * maxHeight / height
+	if w < 1 {
+		w = 1
+	}
+	return w, maxHeight
+}
+
+// toRGBA 转换为预乘 alpha 的 RGBA 图像，便于按像素取平均
+func toRGBA(src image.Image) *image.RGBA {
+	if rgba, ok := src.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+// resize 盒式滤波缩小：目标像素取其覆盖的源像素区域的平均值
+func resize(src *image.RGBA, dw, dh int) *image.RGBA {
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for dy := 0; dy < dh; dy++ {
+		y0 := dy * sh / dh
+		y1 := (dy + 1) * sh / dh
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for dx := 0; dx < dw; dx++ {
+			x0 := dx * sw / dw
+			x1 := (dx + 1) * sw / dw
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				row := src.Pix[y*src.Stride+x0*4 : y*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r += uint64(row[i])
+					g += uint64(row[i+1])
+					b += uint64(row[i+2])
+					a += uint64(row[i+3])
+					n++
+				}
+			}
+
+			off := dy*dst.Stride + dx*4
+			dst.Pix[off] = uint8(r / n)
+			dst.Pix[off+1] = uint8(g / n)
+			dst.Pix[off+2] = uint8(b / n)
+			dst.Pix[off+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,43 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFitSize 测试等比缩放尺寸计算
+func TestFitSize(t *testing.T) {
+	w, h := FitSize(1600, 900, 400, 400)
+	assert.Equal(t, []int{400, 225}, []int{w, h})
+
+	w, h = FitSize(900, 1600, 400, 400)
+	assert.Equal(t, []int{225, 400}, []int{w, h})
+
+	w, h = FitSize(100, 80, 400, 400)
+	assert.Equal(t, []int{100, 80}, []int{w, h}, "小图不放大")
+}
+
+// TestFit 测试缩小后按区域平均取色
+func TestFit(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			c := color.NRGBA{A: 255}
+			if x%2 == 0 {
+				c.R = 200
+			}
+			src.SetNRGBA(x, y, c)
+		}
+	}
+
+	dst := Fit(src, 2, 2)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), dst.Bounds())
+	r, _, _, a := dst.At(0, 0).RGBA()
+	assert.Equal(t, uint32(100), r>>8)
+	assert.Equal(t, uint32(255), a>>8)
+
+	assert.Same(t, src, Fit(src, 10, 10))
+}
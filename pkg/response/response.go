@@ -20,6 +20,8 @@ type PageData struct {
 	Page       int         `json:"page"`
 	PageSize   int         `json:"page_size"`
 	TotalPages int         `json:"total_pages"`
+	Facets     interface{} `json:"facets,omitempty"`       // 筛选项统计（商品列表）
+	DidYouMean []string    `json:"did_you_mean,omitempty"` // 纠错建议（搜索无结果时）
 }
 
 // Success 成功响应
@@ -59,16 +61,35 @@ func ErrorWithData(c *gin.Context, code int, message string, data interface{}) {
 
 // Page 分页响应
 func Page(c *gin.Context, list interface{}, total int64, page, pageSize int) {
+	PageWithFacets(c, list, total, page, pageSize, nil)
+}
+
+// PageWithFacets 分页响应（附带筛选项统计）
+func PageWithFacets(c *gin.Context, list interface{}, total int64, page, pageSize int, facets interface{}) {
+	data := newPageData(list, total, page, pageSize)
+	data.Facets = facets
+	Success(c, data)
+}
+
+// PageWithCorrections 分页响应（附带纠错建议）
+func PageWithCorrections(c *gin.Context, list interface{}, total int64, page, pageSize int, didYouMean []string) {
+	data := newPageData(list, total, page, pageSize)
+	data.DidYouMean = didYouMean
+	Success(c, data)
+}
+
+// newPageData 构建分页数据
+func newPageData(list interface{}, total int64, page, pageSize int) PageData {
 	totalPages := int(total) / pageSize
 	if int(total)%pageSize > 0 {
 		totalPages++
 	}
 
-	Success(c, PageData{
+	return PageData{
 		List:       list,
 		Total:      total,
 		Page:       page,
 		PageSize:   pageSize,
 		TotalPages: totalPages,
-	})
+	}
 }
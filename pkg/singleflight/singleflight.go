@@ -0,0 +1,54 @@
+package singleflight
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrPanicked 执行函数发生 panic 时，等待同一结果的其他调用方收到该错误
+var ErrPanicked = errors.New("singleflight: 执行函数发生 panic")
+
+// call 一次正在执行或已完成的调用
+type call struct {
+	wg   sync.WaitGroup
+	val  interface{}
+	err  error
+	dups int
+}
+
+// Group 合并相同 key 的并发调用：同一时刻只执行一次，其余调用方等待并共享结果
+type Group struct {
+	mu sync.Mutex
+	m  map[string]*call
+}
+
+// Do 执行 fn 并返回结果；已有相同 key 的调用在执行时不再执行，等待其完成后返回同一结果。
+// shared 表示结果是否被多个调用方共享，调用方修改返回值前应先复制
+func (g *Group) Do(key string, fn func() (interface{}, error)) (v interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if g.m == nil {
+		g.m = make(map[string]*call)
+	}
+	if c, ok := g.m[key]; ok {
+		c.dups++
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+	c := &call{err: ErrPanicked}
+	c.wg.Add(1)
+	g.m[key] = c
+	g.mu.Unlock()
+
+	// fn panic 时同样移除调用并唤醒等待方，panic 继续向上传递
+	defer func() {
+		g.mu.Lock()
+		delete(g.m, key)
+		shared = c.dups > 0
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+
+	c.val, c.err = fn()
+	return c.val, c.err, false
+}
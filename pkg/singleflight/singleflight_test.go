@@ -0,0 +1,87 @@
+package singleflight
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDoCoalesces 测试相同 key 的并发调用只执行一次并共享结果
+func TestDoCoalesces(t *testing.T) {
+	var g Group
+	var calls int32
+	release := make(chan struct{})
+
+	const n = 20
+	var wg sync.WaitGroup
+	results := make([]interface{}, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err, _ := g.Do("key", func() (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return "value", nil
+			})
+			assert.NoError(t, err)
+			results[i] = v
+		}(i)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, v := range results {
+		assert.Equal(t, "value", v)
+	}
+}
+
+// TestDoSequential 测试调用完成后相同 key 会重新执行，错误原样返回
+func TestDoSequential(t *testing.T) {
+	var g Group
+	errLoad := errors.New("load failed")
+
+	_, err, shared := g.Do("key", func() (interface{}, error) { return nil, errLoad })
+	assert.ErrorIs(t, err, errLoad)
+	assert.False(t, shared)
+
+	v, err, _ := g.Do("key", func() (interface{}, error) { return 2, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 2, v)
+}
+
+// TestDoPanic 测试执行函数 panic 时等待方收到错误，且 key 被释放
+func TestDoPanic(t *testing.T) {
+	var g Group
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	waiterErr := make(chan error, 1)
+	go func() {
+		defer func() { recover() }()
+		g.Do("key", func() (interface{}, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+	go func() {
+		_, err, _ := g.Do("key", func() (interface{}, error) { return nil, nil })
+		waiterErr <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	assert.ErrorIs(t, <-waiterErr, ErrPanicked)
+	v, err, _ := g.Do("key", func() (interface{}, error) { return 1, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+}
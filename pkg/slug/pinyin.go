@@ -0,0 +1,402 @@
+package slug
+
+// pinyinTable 常用汉字拼音表（不带声调，ü 记作 v），按音节列出汉字。
+// 只收录商品、分类名称中的常用字，多音字取商品名称中最常见的读音
+var pinyinTable = map[string]string{
+	"a":      "啊阿",
+	"ai":     "爱艾哀矮碍",
+	"an":     "安按案暗岸氨鞍",
+	"ang":    "昂",
+	"ao":     "奥澳傲熬袄",
+	"ba":     "八巴把吧爸拔霸坝芭",
+	"bai":    "白百摆败拜柏佰",
+	"ban":    "半办板版班般伴扮搬斑瓣",
+	"bang":   "帮棒榜绑磅邦",
+	"bao":    "包保宝报抱饱薄爆豹堡褒",
+	"bei":    "北被备背杯贝倍辈悲碑",
+	"ben":    "本奔笨",
+	"beng":   "泵崩蹦",
+	"bi":     "比笔必币闭壁避鼻彼碧毕臂弊",
+	"bian":   "边变便编遍辨辩鞭扁",
+	"biao":   "表标彪膘镖",
+	"bie":    "别",
+	"bin":    "宾滨彬缤",
+	"bing":   "并病冰兵饼丙柄",
+	"bo":     "波博播玻伯泊驳勃脖帛",
+	"bu":     "不部步布补捕卜簿",
+	"ca":     "擦",
+	"cai":    "才菜彩材财采踩猜裁",
+	"can":    "参餐残蚕惨灿",
+	"cang":   "仓藏苍舱",
+	"cao":    "草操曹槽",
+	"ce":     "测策侧册厕",
+	"ceng":   "层曾",
+	"cha":    "茶查差插叉察",
+	"chai":   "柴拆",
+	"chan":   "产缠蝉馋铲",
+	"chang":  "长常场厂唱肠尝畅昌",
+	"chao":   "超朝潮炒抄巢",
+	"che":    "车彻撤",
+	"chen":   "陈沉晨尘衬趁辰",
+	"cheng":  "成城程称承乘橙诚撑秤",
+	"chi":    "吃尺池持迟齿赤翅匙驰",
+	"chong":  "充冲虫宠崇",
+	"chou":   "抽丑臭愁筹绸",
+	"chu":    "出处初除厨础储楚触",
+	"chuan":  "川穿传船串",
+	"chuang": "窗床创闯",
+	"chui":   "吹垂锤",
+	"chun":   "春纯唇醇",
+	"ci":     "次此词磁瓷刺慈辞",
+	"cong":   "从丛聪葱匆",
+	"cu":     "粗促醋",
+	"cui":    "脆翠催",
+	"cun":    "村存寸",
+	"cuo":    "错措",
+	"da":     "大打达答搭",
+	"dai":    "带代袋待戴贷呆",
+	"dan":    "单但蛋担淡胆丹",
+	"dang":   "当党档挡",
+	"dao":    "到道导刀岛倒稻",
+	"de":     "的得德",
+	"deng":   "等灯登邓凳",
+	"di":     "地第底低弟滴帝递迪敌笛",
+	"dian":   "电点店典垫殿淀",
+	"diao":   "吊钓雕掉",
+	"die":    "叠蝶跌碟",
+	"ding":   "定顶订丁钉鼎",
+	"diu":    "丢",
+	"dong":   "东动冬懂洞冻",
+	"dou":    "豆斗抖逗兜",
+	"du":     "都度读毒独堵肚渡镀杜",
+	"duan":   "短段断端锻缎",
+	"dui":    "对队堆",
+	"dun":    "吨顿盾蹲",
+	"duo":    "多朵躲夺",
+	"e":      "饿额鹅俄恶",
+	"en":     "恩",
+	"er":     "儿二而耳尔",
+	"fa":     "发法罚乏",
+	"fan":    "反饭范番翻烦凡繁泛帆",
+	"fang":   "方放房防访仿纺芳",
+	"fei":    "飞非费肥废啡菲",
+	"fen":    "分粉份奋芬纷坟",
+	"feng":   "风丰封峰蜂锋疯",
+	"fo":     "佛",
+	"fou":    "否",
+	"fu":     "服福父付府复富负扶符夫浮辅腐妇肤附覆",
+	"ga":     "嘎",
+	"gai":    "改该盖概钙",
+	"gan":    "干感赶敢甘杆肝柑",
+	"gang":   "刚钢港岗缸",
+	"gao":    "高告稿搞糕膏",
+	"ge":     "个各歌哥格隔割鸽阁革",
+	"gei":    "给",
+	"gen":    "根跟",
+	"geng":   "更耕",
+	"gong":   "工公共功供宫攻贡恭弓",
+	"gou":    "够狗购构沟钩",
+	"gu":     "古故骨谷顾固鼓姑孤股",
+	"gua":    "瓜挂刮寡",
+	"guai":   "怪乖",
+	"guan":   "关管观官馆冠惯灌罐",
+	"guang":  "光广逛",
+	"gui":    "贵规归鬼柜轨桂龟",
+	"gun":    "滚棍",
+	"guo":    "国果过锅裹",
+	"ha":     "哈",
+	"hai":    "还海孩害亥",
+	"han":    "汉含寒汗喊韩旱",
+	"hang":   "航",
+	"hao":    "好号毫豪耗浩",
+	"he":     "和合河何喝盒荷核贺",
+	"hei":    "黑",
+	"hen":    "很恨狠",
+	"heng":   "横衡恒",
+	"hong":   "红洪宏虹烘轰",
+	"hou":    "后候厚猴喉",
+	"hu":     "湖户护呼互虎胡壶糊葫狐",
+	"hua":    "花话化画华划滑",
+	"huai":   "坏怀",
+	"huan":   "欢换环缓幻",
+	"huang":  "黄皇慌煌",
+	"hui":    "会回灰汇挥辉惠绘徽",
+	"hun":    "婚混",
+	"huo":    "火活或货获",
+	"ji":     "机几鸡及级记技基计极集急季寄纪积即击激吉籍剂济继肌辑",
+	"jia":    "家加价假甲架夹佳嘉",
+	"jian":   "件见间建简减剑检坚健尖键鉴渐兼肩",
+	"jiang":  "将江讲降奖酱姜",
+	"jiao":   "交教较角脚叫胶焦骄娇饺",
+	"jie":    "接节街结解界借洁姐介届戒杰",
+	"jin":    "进金今近紧斤仅尽锦筋津",
+	"jing":   "经精京静景境镜井晶竞敬惊净径",
+	"jiong":  "窘",
+	"jiu":    "就九酒久旧救究",
+	"ju":     "局具据举句巨聚居菊剧橘拒距",
+	"juan":   "卷捐",
+	"jue":    "决觉绝爵",
+	"jun":    "军均君菌俊",
+	"ka":     "卡咖",
+	"kai":    "开凯",
+	"kan":    "看刊砍",
+	"kang":   "康抗炕",
+	"kao":    "考靠烤",
+	"ke":     "可科克客课刻颗壳渴",
+	"ken":    "肯",
+	"kong":   "空控孔恐",
+	"kou":    "口扣",
+	"ku":     "苦库裤酷哭",
+	"kua":    "夸跨垮",
+	"kuai":   "快块筷",
+	"kuan":   "宽款",
+	"kuang":  "况矿框狂",
+	"kui":    "亏葵",
+	"kun":    "困昆",
+	"kuo":    "扩阔",
+	"la":     "拉啦辣蜡",
+	"lai":    "来赖莱",
+	"lan":    "蓝兰烂拦篮懒览",
+	"lang":   "浪朗狼郎",
+	"lao":    "老劳牢",
+	"le":     "了乐勒",
+	"lei":    "类累雷泪蕾",
+	"leng":   "冷",
+	"li":     "里理力利立离礼李丽历例粒莉厘梨黎",
+	"lian":   "连联练脸恋链莲帘",
+	"liang":  "量两亮良凉粮梁",
+	"liao":   "料疗聊辽",
+	"lie":    "列烈裂猎",
+	"lin":    "林临邻淋磷",
+	"ling":   "领零另令灵铃龄岭凌",
+	"liu":    "六流留刘柳溜",
+	"long":   "龙隆笼聋",
+	"lou":    "楼漏搂",
+	"lu":     "路录露陆鹿炉卤芦",
+	"lv":     "绿旅律铝驴",
+	"luan":   "乱卵",
+	"lue":    "略",
+	"lun":    "论轮伦",
+	"luo":    "落罗络逻萝螺洛骆",
+	"ma":     "马吗妈码麻",
+	"mai":    "买卖麦迈",
+	"man":    "满慢漫曼蔓",
+	"mang":   "忙芒盲",
+	"mao":    "毛猫帽冒贸茂",
+	"me":     "么",
+	"mei":    "没每美妹煤梅媒眉莓",
+	"men":    "们门闷",
+	"meng":   "梦蒙猛萌盟",
+	"mi":     "米密迷蜜秘",
+	"mian":   "面棉免眠绵",
+	"miao":   "秒妙苗描",
+	"mie":    "灭",
+	"min":    "民敏",
+	"ming":   "明名命鸣铭",
+	"mo":     "模末磨摸抹魔墨默膜",
+	"mou":    "某",
+	"mu":     "木目母牧幕墓慕",
+	"na":     "那拿纳哪",
+	"nai":    "奶耐乃",
+	"nan":    "南男难",
+	"nao":    "脑闹",
+	"ne":     "呢",
+	"nei":    "内",
+	"neng":   "能",
+	"ni":     "你泥尼拟",
+	"nian":   "年念黏",
+	"niang":  "娘酿",
+	"niao":   "鸟尿",
+	"nie":    "捏",
+	"nin":    "您",
+	"ning":   "宁柠凝",
+	"niu":    "牛扭纽",
+	"nong":   "农浓",
+	"nu":     "努怒奴",
+	"nv":     "女",
+	"nuan":   "暖",
+	"nuo":    "诺",
+	"ou":     "欧偶",
+	"pa":     "怕爬帕",
+	"pai":    "派排牌拍",
+	"pan":    "盘判盼",
+	"pang":   "旁胖",
+	"pao":    "跑泡炮袍",
+	"pei":    "配陪培佩",
+	"pen":    "盆喷",
+	"peng":   "朋棚碰蓬鹏",
+	"pi":     "皮批披脾匹啤",
+	"pian":   "片篇偏骗",
+	"piao":   "票漂飘",
+	"pin":    "品拼贫频",
+	"ping":   "平评瓶苹屏凭",
+	"po":     "破坡婆",
+	"pu":     "普铺葡扑朴谱",
+	"qi":     "其起气期七器汽齐奇骑企棋旗漆妻",
+	"qia":    "恰",
+	"qian":   "前钱千签浅铅牵欠",
+	"qiang":  "强墙枪腔",
+	"qiao":   "桥巧敲乔",
+	"qie":    "切且",
+	"qin":    "亲琴勤芹",
+	"qing":   "情清青轻请晴庆",
+	"qiong":  "穷琼",
+	"qiu":    "求球秋",
+	"qu":     "去区取曲趣渠",
+	"quan":   "全权泉拳圈劝",
+	"que":    "却确缺雀",
+	"qun":    "群裙",
+	"ran":    "然燃染",
+	"rang":   "让",
+	"rao":    "绕",
+	"re":     "热",
+	"ren":    "人认任仁忍",
+	"reng":   "仍",
+	"ri":     "日",
+	"rong":   "容荣融绒溶",
+	"rou":    "肉柔",
+	"ru":     "如入乳儒",
+	"ruan":   "软",
+	"rui":    "瑞锐",
+	"run":    "润",
+	"ruo":    "若弱",
+	"sa":     "撒洒萨",
+	"sai":    "赛塞",
+	"san":    "三散伞",
+	"sang":   "桑",
+	"sao":    "扫",
+	"se":     "色",
+	"sen":    "森",
+	"sha":    "沙杀纱傻砂",
+	"shai":   "晒",
+	"shan":   "山闪善衫扇珊",
+	"shang":  "上商伤尚裳",
+	"shao":   "少烧勺绍",
+	"she":    "设社射舍蛇摄",
+	"shen":   "身深神什甚审肾伸",
+	"sheng":  "生声省胜升盛剩绳",
+	"shi":    "是时十事实使世市式始石食师识室试示视施适史诗士湿饰释",
+	"shou":   "手收首受守售瘦寿兽",
+	"shu":    "书数树术属输熟鼠蔬舒束叔薯",
+	"shua":   "刷",
+	"shuai":  "帅率摔",
+	"shuang": "双霜爽",
+	"shui":   "水谁睡税",
+	"shun":   "顺",
+	"shuo":   "说硕",
+	"si":     "四思死司丝私斯寺似",
+	"song":   "送松宋",
+	"sou":    "搜",
+	"su":     "素速苏诉宿塑酥",
+	"suan":   "算酸蒜",
+	"sui":    "随岁碎",
+	"sun":    "孙损笋",
+	"suo":    "所锁索缩",
+	"ta":     "他她它塔踏",
+	"tai":    "太台态泰胎",
+	"tan":    "谈探弹坦毯碳炭",
+	"tang":   "糖汤堂躺唐",
+	"tao":    "套桃逃讨陶",
+	"te":     "特",
+	"teng":   "疼腾藤",
+	"ti":     "体提题替梯踢",
+	"tian":   "天田甜填添",
+	"tiao":   "条调跳挑",
+	"tie":    "铁贴",
+	"ting":   "听停庭挺厅",
+	"tong":   "同通童铜桶统痛",
+	"tou":    "头投透偷",
+	"tu":     "图土突涂兔途",
+	"tuan":   "团",
+	"tui":    "推腿退",
+	"tun":    "吞",
+	"tuo":    "脱托拖妥",
+	"wa":     "瓦袜挖娃蛙",
+	"wai":    "外",
+	"wan":    "完万晚玩碗湾丸",
+	"wang":   "网王往忘望旺",
+	"wei":    "为位未味卫围维伟微尾喂唯",
+	"wen":    "问文温闻稳纹",
+	"weng":   "翁",
+	"wo":     "我握卧窝",
+	"wu":     "无五物务午舞屋武误雾吴",
+	"xi":     "西系洗希习细喜吸息析席戏稀溪锡膝夕",
+	"xia":    "下夏吓虾峡霞",
+	"xian":   "先现线鲜显县限险仙闲纤献",
+	"xiang":  "想向相香象像项乡箱详",
+	"xiao":   "小笑校效消销晓",
+	"xie":    "些写鞋谢协斜携蟹",
+	"xin":    "心新信欣辛",
+	"xing":   "行性形星型兴醒幸",
+	"xiong":  "兄熊胸雄",
+	"xiu":    "修休秀袖绣",
+	"xu":     "需许续虚须序蓄绪",
+	"xuan":   "选宣旋悬玄",
+	"xue":    "学雪血靴",
+	"xun":    "寻训迅讯",
+	"ya":     "压牙呀亚鸭雅",
+	"yan":    "眼言严研烟颜验沿盐延岩燕",
+	"yang":   "样养阳洋羊扬仰氧",
+	"yao":    "要药腰摇遥咬耀",
+	"ye":     "也业夜叶页野爷",
+	"yi":     "一以已意义医衣易依益异移艺议亿椅仪宜",
+	"yin":    "因音银饮引印阴隐",
+	"ying":   "应英影营迎硬鹰婴樱",
+	"yong":   "用永勇拥泳",
+	"you":    "有又由友油游右优邮幼",
+	"yu":     "于与语鱼雨玉育预遇域羽宇浴",
+	"yuan":   "员元原远院园圆源愿缘",
+	"yue":    "月越约阅跃",
+	"yun":    "云运允韵孕",
+	"za":     "杂",
+	"zai":    "在再载灾",
+	"zan":    "赞暂",
+	"zang":   "脏",
+	"zao":    "早造澡枣糟",
+	"ze":     "则责泽",
+	"zen":    "怎",
+	"zeng":   "增赠",
+	"zha":    "扎炸闸榨",
+	"zhai":   "摘宅窄",
+	"zhan":   "站展战占沾",
+	"zhang":  "张章掌账涨丈帐",
+	"zhao":   "找照招赵罩",
+	"zhe":    "这者着折哲",
+	"zhen":   "真针镇阵珍振枕",
+	"zheng":  "正政整证争征蒸",
+	"zhi":    "之只知直制至治指纸支质志值智织汁脂植枝",
+	"zhong":  "中种重众终钟忠",
+	"zhou":   "周州洲粥轴",
+	"zhu":    "主住注助竹猪珠煮柱筑祝著",
+	"zhua":   "抓",
+	"zhuan":  "专转砖",
+	"zhuang": "装状庄壮",
+	"zhui":   "追",
+	"zhun":   "准",
+	"zhuo":   "桌捉卓",
+	"zi":     "子自字资紫姿",
+	"zong":   "总宗综棕",
+	"zou":    "走",
+	"zu":     "组足族祖租",
+	"zuan":   "钻",
+	"zui":    "最嘴醉",
+	"zun":    "尊",
+	"zuo":    "做作坐左座昨",
+}
+
+// pinyinIndex 汉字到拼音的索引，由 pinyinTable 生成
+var pinyinIndex = func() map[rune]string {
+	index := make(map[rune]string, 2048)
+	for syllable, chars := range pinyinTable {
+		for _, r := range chars {
+			index[r] = syllable
+		}
+	}
+	return index
+}()
+
+// Pinyin 返回汉字的拼音（不带声调），未收录时返回 false
+func Pinyin(r rune) (string, bool) {
+	py, ok := pinyinIndex[r]
+	return py, ok
+}
@@ -0,0 +1,64 @@
+package slug
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// MaxLength slug 的最大长度
+const MaxLength = 80
+
+var pattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Make 由名称生成 slug：英文、数字转小写后保留，汉字逐字转为拼音（不带声调），
+// 其余字符视为分隔符，各部分以连字符连接并截断到 MaxLength。
+// 拼音表未收录的汉字会被忽略，结果可能为空，由调用方兜底
+func Make(text string) string {
+	var parts []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			parts = append(parts, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case r <= unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(unicode.ToLower(r))
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if py, ok := Pinyin(r); ok {
+				parts = append(parts, py)
+			}
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	var b strings.Builder
+	for _, part := range parts {
+		if b.Len() > 0 {
+			// 在完整的词处截断
+			if b.Len()+1+len(part) > MaxLength {
+				break
+			}
+			b.WriteByte('-')
+		}
+		b.WriteString(part)
+	}
+
+	s := b.String()
+	if len(s) > MaxLength {
+		s = strings.TrimRight(s[:MaxLength], "-")
+	}
+	return s
+}
+
+// Valid 判断是否为合法 slug：小写字母、数字，以单个连字符分隔
+func Valid(s string) bool {
+	return len(s) <= MaxLength && pattern.MatchString(s)
+}
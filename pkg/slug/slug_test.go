@@ -0,0 +1,35 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMake 测试由中英文名称生成 slug
+func TestMake(t *testing.T) {
+	cases := map[string]string{
+		"Apple iPhone 15 Pro 手机": "apple-iphone-15-pro-shou-ji",
+		"纯棉短袖T恤（男款）":             "chun-mian-duan-xiu-t-nan-kuan",
+		"  运动鞋 -- Nike/耐克  ":     "yun-dong-xie-nike-nai-ke",
+		"绿茶 500ml×12瓶":           "lv-cha-500ml-12-ping",
+		"！！！":                    "",
+	}
+	for name, want := range cases {
+		assert.Equal(t, want, Make(name), name)
+	}
+
+	long := Make(strings.Repeat("智能手机 ", 20))
+	assert.LessOrEqual(t, len(long), MaxLength)
+	assert.True(t, Valid(long), "截断后不以连字符结尾")
+}
+
+// TestValid 测试 slug 格式校验
+func TestValid(t *testing.T) {
+	assert.True(t, Valid("iphone-15"))
+	assert.False(t, Valid("iPhone"))
+	assert.False(t, Valid("a--b"))
+	assert.False(t, Valid("-a"))
+	assert.False(t, Valid(""))
+}
@@ -0,0 +1,103 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenize 将文本切分为检索词，用于写入全文检索列
+// 英文、数字按单词切分并转为小写；连续汉字输出单字及相邻二元组，
+// 这样不依赖中文分词词典也能匹配任意长度的中文关键词
+func Tokenize(text string) []string {
+	var tokens []string
+	for _, seg := range segments(text) {
+		if !seg.han {
+			tokens = append(tokens, seg.text)
+			continue
+		}
+		runes := []rune(seg.text)
+		for i := range runes {
+			tokens = append(tokens, string(runes[i]))
+			if i+1 < len(runes) {
+				tokens = append(tokens, string(runes[i:i+2]))
+			}
+		}
+	}
+	return tokens
+}
+
+// Join 分词后以空格拼接
+func Join(text string) string {
+	return strings.Join(Tokenize(text), " ")
+}
+
+// QueryTokens 将单个检索词切分为查询词（须全部命中）
+// 单个汉字直接查询单字，多个连续汉字查询相邻二元组，与 Tokenize 的写入方式对应
+func QueryTokens(term string) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	add := func(t string) {
+		if !seen[t] {
+			seen[t] = true
+			tokens = append(tokens, t)
+		}
+	}
+
+	for _, seg := range segments(term) {
+		if !seg.han {
+			add(seg.text)
+			continue
+		}
+		runes := []rune(seg.text)
+		if len(runes) == 1 {
+			add(seg.text)
+			continue
+		}
+		for i := 0; i+1 < len(runes); i++ {
+			add(string(runes[i : i+2]))
+		}
+	}
+	return tokens
+}
+
+// segment 连续的同类字符
+type segment struct {
+	text string
+	han  bool
+}
+
+// segments 按汉字、字母数字切分文本，其余字符视为分隔符
+func segments(text string) []segment {
+	var result []segment
+	var buf []rune
+	han := false
+
+	flush := func() {
+		if len(buf) > 0 {
+			result = append(result, segment{text: string(buf), han: han})
+			buf = buf[:0]
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			if !han {
+				flush()
+				han = true
+			}
+			buf = append(buf, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if han {
+				flush()
+				han = false
+			}
+			buf = append(buf, unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return result
+}